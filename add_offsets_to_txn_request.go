@@ -0,0 +1,54 @@
+package sarama
+
+// AddOffsetsToTxnRequest adds the offsets of a consumer group to the transaction in progress, ahead
+// of committing them with a TxnOffsetCommitRequest.
+type AddOffsetsToTxnRequest struct {
+	TransactionalID string
+	ProducerID      int64
+	ProducerEpoch   int16
+	GroupID         string
+
+	// Version can be:
+	// - 0 (kafka 0.11 and later)
+	// - 1 (kafka 2.0 and later, laid out as 0)
+	IVersion int16
+}
+
+func (r *AddOffsetsToTxnRequest) Encode(pe packetEncoder) error {
+	if r.IVersion < 0 || r.IVersion > 1 {
+		return PacketEncodingError{"invalid or unsupported AddOffsetsToTxnRequest version field"}
+	}
+
+	if err := pe.putString(r.TransactionalID); err != nil {
+		return err
+	}
+	pe.putInt64(r.ProducerID)
+	pe.putInt16(r.ProducerEpoch)
+	return pe.putString(r.GroupID)
+}
+
+func (r *AddOffsetsToTxnRequest) Decode(pd packetDecoder) (err error) {
+	if r.TransactionalID, err = pd.getString(); err != nil {
+		return err
+	}
+	if r.ProducerID, err = pd.getInt64(); err != nil {
+		return err
+	}
+	if r.ProducerEpoch, err = pd.getInt16(); err != nil {
+		return err
+	}
+	r.GroupID, err = pd.getString()
+	return err
+}
+
+func (r *AddOffsetsToTxnRequest) Key() int16 {
+	return 25
+}
+
+func (r *AddOffsetsToTxnRequest) Version() int16 {
+	return r.IVersion
+}
+
+func (r *AddOffsetsToTxnRequest) String() string {
+	return dump(r)
+}
@@ -0,0 +1,20 @@
+package sarama
+
+import "testing"
+
+var addOffsetsToTxnRequest = []byte{
+	0, 3, 't', 'x', 'n',
+	0, 0, 0, 0, 0, 0, 31, 64, // producer ID 8000
+	0, 0, // epoch 0
+	0, 7, 'g', 'r', 'o', 'u', 'p', 'i', 'd',
+}
+
+func TestAddOffsetsToTxnRequest(t *testing.T) {
+	request := &AddOffsetsToTxnRequest{
+		TransactionalID: "txn",
+		ProducerID:      8000,
+		ProducerEpoch:   0,
+		GroupID:         "groupid",
+	}
+	testRequest(t, "", request, addOffsetsToTxnRequest)
+}
@@ -0,0 +1,34 @@
+package sarama
+
+import "time"
+
+type AddOffsetsToTxnResponse struct {
+	ThrottleTime time.Duration
+	Err          KError
+}
+
+func (r *AddOffsetsToTxnResponse) Encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	pe.putInt16(int16(r.Err))
+	return nil
+}
+
+func (r *AddOffsetsToTxnResponse) Decode(pd packetDecoder) (err error) {
+	millis, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(millis) * time.Millisecond
+
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.Err = KError(kerr)
+
+	return nil
+}
+
+func (r *AddOffsetsToTxnResponse) String() string {
+	return dump(r)
+}
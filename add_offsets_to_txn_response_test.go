@@ -0,0 +1,20 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var addOffsetsToTxnResponse = []byte{
+	0, 0, 0, 100,
+	0, 47, // ErrInvalidProducerEpoch
+}
+
+func TestAddOffsetsToTxnResponse(t *testing.T) {
+	response := new(AddOffsetsToTxnResponse)
+	testDecodable(t, "", response, addOffsetsToTxnResponse)
+	if response.ThrottleTime != 100*time.Millisecond || response.Err != ErrInvalidProducerEpoch {
+		t.Error("Decoding failed, got", response)
+	}
+	testEncodable(t, "", response, addOffsetsToTxnResponse)
+}
@@ -0,0 +1,82 @@
+package sarama
+
+// AddPartitionsToTxnRequest adds partitions to the transaction in progress, before the producer
+// writes to them for the first time in that transaction.
+type AddPartitionsToTxnRequest struct {
+	TransactionalID string
+	ProducerID      int64
+	ProducerEpoch   int16
+	TopicPartitions map[string][]int32
+
+	// Version can be:
+	// - 0 (kafka 0.11 and later)
+	// - 1 (kafka 2.0 and later, laid out as 0)
+	IVersion int16
+}
+
+func (r *AddPartitionsToTxnRequest) Encode(pe packetEncoder) error {
+	if r.IVersion < 0 || r.IVersion > 1 {
+		return PacketEncodingError{"invalid or unsupported AddPartitionsToTxnRequest version field"}
+	}
+
+	if err := pe.putString(r.TransactionalID); err != nil {
+		return err
+	}
+	pe.putInt64(r.ProducerID)
+	pe.putInt16(r.ProducerEpoch)
+
+	if err := pe.putArrayLength(len(r.TopicPartitions)); err != nil {
+		return err
+	}
+	for topic, partitions := range r.TopicPartitions {
+		if err := pe.putString(topic); err != nil {
+			return err
+		}
+		if err := pe.putInt32Array(partitions); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *AddPartitionsToTxnRequest) Decode(pd packetDecoder) (err error) {
+	if r.TransactionalID, err = pd.getString(); err != nil {
+		return err
+	}
+	if r.ProducerID, err = pd.getInt64(); err != nil {
+		return err
+	}
+	if r.ProducerEpoch, err = pd.getInt16(); err != nil {
+		return err
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.TopicPartitions = make(map[string][]int32, n)
+	for i := 0; i < n; i++ {
+		topic, err := pd.getString()
+		if err != nil {
+			return err
+		}
+		if r.TopicPartitions[topic], err = pd.getInt32Array(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *AddPartitionsToTxnRequest) Key() int16 {
+	return 24
+}
+
+func (r *AddPartitionsToTxnRequest) Version() int16 {
+	return r.IVersion
+}
+
+func (r *AddPartitionsToTxnRequest) String() string {
+	return dump(r)
+}
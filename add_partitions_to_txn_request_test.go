@@ -0,0 +1,25 @@
+package sarama
+
+import "testing"
+
+var addPartitionsToTxnRequest = []byte{
+	0, 3, 't', 'x', 'n',
+	0, 0, 0, 0, 0, 0, 31, 64, // producer ID 8000
+	0, 0, // epoch 0
+	0, 0, 0, 1,
+	0, 5, 't', 'o', 'p', 'i', 'c',
+	0, 0, 0, 2, 0, 0, 0, 1, 0, 0, 0, 4,
+}
+
+func TestAddPartitionsToTxnRequest(t *testing.T) {
+	request := &AddPartitionsToTxnRequest{
+		TransactionalID: "txn",
+		ProducerID:      8000,
+		ProducerEpoch:   0,
+		TopicPartitions: map[string][]int32{"topic": {1, 4}},
+	}
+	testRequest(t, "", request, addPartitionsToTxnRequest)
+
+	request.IVersion = 1
+	testRequest(t, "v1", request, addPartitionsToTxnRequest)
+}
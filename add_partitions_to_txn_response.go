@@ -0,0 +1,98 @@
+package sarama
+
+import "time"
+
+// PartitionError is the outcome for a single partition of a transactional request.
+type PartitionError struct {
+	Partition int32
+	Err       KError
+}
+
+func (e *PartitionError) encode(pe packetEncoder) error {
+	pe.putInt32(e.Partition)
+	pe.putInt16(int16(e.Err))
+	return nil
+}
+
+func (e *PartitionError) decode(pd packetDecoder) (err error) {
+	if e.Partition, err = pd.getInt32(); err != nil {
+		return err
+	}
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	e.Err = KError(kerr)
+	return nil
+}
+
+func encodeTopicPartitionErrors(pe packetEncoder, errors map[string][]*PartitionError) error {
+	if err := pe.putArrayLength(len(errors)); err != nil {
+		return err
+	}
+	for topic, partitionErrors := range errors {
+		if err := pe.putString(topic); err != nil {
+			return err
+		}
+		if err := pe.putArrayLength(len(partitionErrors)); err != nil {
+			return err
+		}
+		for _, partitionError := range partitionErrors {
+			if err := partitionError.encode(pe); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func decodeTopicPartitionErrors(pd packetDecoder) (map[string][]*PartitionError, error) {
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return nil, err
+	}
+	errors := make(map[string][]*PartitionError, n)
+	for i := 0; i < n; i++ {
+		topic, err := pd.getString()
+		if err != nil {
+			return nil, err
+		}
+		m, err := pd.getArrayLength()
+		if err != nil {
+			return nil, err
+		}
+		errors[topic] = make([]*PartitionError, m)
+		for j := range errors[topic] {
+			errors[topic][j] = new(PartitionError)
+			if err := errors[topic][j].decode(pd); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return errors, nil
+}
+
+type AddPartitionsToTxnResponse struct {
+	ThrottleTime time.Duration
+	Errors       map[string][]*PartitionError
+}
+
+func (r *AddPartitionsToTxnResponse) Encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	return encodeTopicPartitionErrors(pe, r.Errors)
+}
+
+func (r *AddPartitionsToTxnResponse) Decode(pd packetDecoder) (err error) {
+	millis, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(millis) * time.Millisecond
+
+	r.Errors, err = decodeTopicPartitionErrors(pd)
+	return err
+}
+
+func (r *AddPartitionsToTxnResponse) String() string {
+	return dump(r)
+}
@@ -0,0 +1,27 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var addPartitionsToTxnResponse = []byte{
+	0, 0, 0, 100,
+	0, 0, 0, 1,
+	0, 5, 't', 'o', 'p', 'i', 'c',
+	0, 0, 0, 1,
+	0, 0, 0, 2, // partition 2
+	0, 48, // ErrInvalidTxnState
+}
+
+func TestAddPartitionsToTxnResponse(t *testing.T) {
+	response := new(AddPartitionsToTxnResponse)
+	testDecodable(t, "", response, addPartitionsToTxnResponse)
+	if response.ThrottleTime != 100*time.Millisecond {
+		t.Error("Decoding throttle time failed, got", response.ThrottleTime)
+	}
+	if errors := response.Errors["topic"]; len(errors) != 1 || errors[0].Partition != 2 || errors[0].Err != ErrInvalidTxnState {
+		t.Error("Decoding partition errors failed, got", response.Errors)
+	}
+	testEncodable(t, "", response, addPartitionsToTxnResponse)
+}
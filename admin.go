@@ -0,0 +1,586 @@
+package sarama
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+)
+
+// ClusterAdmin is the administrative client for Kafka, which manages the topics of a cluster and the
+// configuration of its topics and brokers by sending requests to its controller broker, and inspects
+// its consumer groups. It requires Kafka 0.10.1 or later. You MUST call Close() on a cluster admin
+// to avoid leaks, it will not be garbage-collected automatically when it passes out of scope.
+//
+// The errors returned for a topic or broker are KErrors, unless the broker explains them with a
+// message, in which case they are *TopicErrors carrying both.
+type ClusterAdmin interface {
+	// CreateTopic creates a new topic. It may take several seconds after CreateTopic returns
+	// for all the brokers to become aware that the topic has been created. When validateOnly is
+	// true, the controller only checks that the topic could be created (Kafka 0.11 and later).
+	CreateTopic(topic string, detail *TopicDetail, validateOnly bool) error
+
+	// DeleteTopic deletes a topic. It may take several seconds after DeleteTopic returns for
+	// all the brokers to become aware that the topic is gone.
+	DeleteTopic(topic string) error
+
+	// CreatePartitions grows a topic to count partitions in total, optionally with the
+	// replicas of each new partition. When validateOnly is true, the controller only checks
+	// that the partitions could be created. This requires Kafka 1.0 or later.
+	CreatePartitions(topic string, count int32, assignment [][]int32, validateOnly bool) error
+
+	// DescribeConfig returns the configuration entries of a topic or broker, or only those named
+	// by the resource if it names any. From Kafka 1.1 on, each entry also comes with the values it
+	// overrides as synonyms. This requires Kafka 0.11 or later.
+	DescribeConfig(resource ConfigResource) ([]*ConfigEntry, error)
+
+	// AlterConfig replaces the dynamic configuration of a topic or broker with the given entries;
+	// entries that are left out revert to their defaults. When validateOnly is true, the broker
+	// only checks that the configuration could be altered. This requires Kafka 0.11 or later.
+	AlterConfig(resourceType ConfigResourceType, name string, entries map[string]*string, validateOnly bool) error
+
+	// IncrementalAlterConfig changes only the given configuration entries of a topic or broker,
+	// leaving the others as they are. When validateOnly is true, the broker only checks that the
+	// configuration could be altered. This requires Kafka 2.3 or later.
+	IncrementalAlterConfig(resourceType ConfigResourceType, name string, entries map[string]IncrementalAlterConfigsEntry, validateOnly bool) error
+
+	// ListConsumerGroups returns the groups of the cluster, mapped to their protocol types ("consumer"
+	// for consumer groups). Each broker only knows the groups it coordinates, so every broker of the
+	// cluster is asked.
+	ListConsumerGroups() (map[string]string, error)
+
+	// DescribeConsumerGroups describes the given groups, in the same order, asking the coordinator
+	// of each. The error of a single group is in the Err of its description.
+	DescribeConsumerGroups(groups []string) ([]*GroupDescription, error)
+
+	// ConsumerGroupLag returns how far a consumer group is behind on each partition of the given
+	// topics. Without topics, it covers every partition the group has committed an offset for,
+	// which requires Kafka 0.10.2 or later. Lag of a single partition that could not be
+	// determined comes with the error in its Err.
+	ConsumerGroupLag(group string, topics ...string) (map[string]map[int32]*PartitionLag, error)
+
+	// Close closes the connection to the controller. It is required to call this function
+	// before a cluster admin object passes out of scope, as it will otherwise leak memory. You
+	// must call this before calling Close on the underlying client.
+	Close() error
+}
+
+// PartitionLag is how far a consumer group is behind on a partition. Lag is End - Committed, which
+// counts the messages not yet consumed when the committed offset is that of the next message to
+// consume, as the JVM consumer commits it. The offset manager commits the offset of the last
+// message processed instead, so for the groups it manages Lag is one more than that count.
+type PartitionLag struct {
+	Committed int64 // the committed offset, or -1 if the group has not committed one
+	Metadata  string
+	End       int64 // the offset the next message produced to the partition will get
+	Lag       int64 // -1 if the group has not committed an offset
+	Err       error
+}
+
+type clusterAdmin struct {
+	client    Client
+	conf      *Config
+	ownClient bool
+}
+
+// NewClusterAdmin creates a new ClusterAdmin using a new client with the given broker addresses and
+// configuration.
+func NewClusterAdmin(addrs []string, conf *Config) (ClusterAdmin, error) {
+	client, err := NewClient(addrs, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	admin, err := NewClusterAdminFromClient(client)
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	admin.(*clusterAdmin).ownClient = true
+	return admin, nil
+}
+
+// NewClusterAdminFromClient creates a new ClusterAdmin using the given client. It is still necessary
+// to call Close() on the underlying client when shutting down this cluster admin.
+func NewClusterAdminFromClient(client Client) (ClusterAdmin, error) {
+	// Check that we are not dealing with a closed Client before processing any other arguments
+	if client.Closed() {
+		return nil, ErrClosedClient
+	}
+
+	conf := client.Config()
+	if !conf.Version.IsAtLeast(V0_10_1_0) {
+		return nil, ConfigurationError("the cluster admin requires Version >= " + V0_10_1_0.String())
+	}
+
+	return &clusterAdmin{client: client, conf: conf}, nil
+}
+
+func (ca *clusterAdmin) CreateTopic(topic string, detail *TopicDetail, validateOnly bool) error {
+	if topic == "" {
+		return ErrInvalidTopic
+	}
+	if detail == nil {
+		return errors.New("kafka: you must specify the details of the topic to create")
+	}
+
+	return ca.onController(func(controller *Broker) error {
+		version, err := controller.requestVersion(19, 0)
+		if err != nil {
+			return err
+		}
+		if validateOnly && version < 1 {
+			return ConfigurationError("validating topic creation requires Version >= " + V0_11_0_0.String())
+		}
+
+		response, err := controller.CreateTopics(&CreateTopicsRequest{
+			TopicDetails: map[string]*TopicDetail{topic: detail},
+			Timeout:      ca.conf.Admin.Timeout,
+			ValidateOnly: validateOnly,
+			IVersion:     version,
+		})
+		if err != nil {
+			return err
+		}
+
+		topicErr, ok := response.TopicErrors[topic]
+		if !ok {
+			return ErrIncompleteResponse
+		}
+		return topicErr.asError()
+	})
+}
+
+func (ca *clusterAdmin) DeleteTopic(topic string) error {
+	if topic == "" {
+		return ErrInvalidTopic
+	}
+
+	return ca.onController(func(controller *Broker) error {
+		version, err := controller.requestVersion(20, 0)
+		if err != nil {
+			return err
+		}
+
+		response, err := controller.DeleteTopics(&DeleteTopicsRequest{
+			Topics:   []string{topic},
+			Timeout:  ca.conf.Admin.Timeout,
+			IVersion: version,
+		})
+		if err != nil {
+			return err
+		}
+
+		kerr, ok := response.TopicErrorCodes[topic]
+		if !ok {
+			return ErrIncompleteResponse
+		}
+		if kerr != ErrNoError {
+			return kerr
+		}
+		return nil
+	})
+}
+
+func (ca *clusterAdmin) CreatePartitions(topic string, count int32, assignment [][]int32, validateOnly bool) error {
+	if topic == "" {
+		return ErrInvalidTopic
+	}
+
+	return ca.onController(func(controller *Broker) error {
+		version, err := controller.requestVersion(37, 0)
+		if err != nil {
+			return err
+		}
+
+		response, err := controller.CreatePartitions(&CreatePartitionsRequest{
+			TopicPartitions: map[string]*TopicPartition{topic: {Count: count, Assignment: assignment}},
+			Timeout:         ca.conf.Admin.Timeout,
+			ValidateOnly:    validateOnly,
+			IVersion:        version,
+		})
+		if err != nil {
+			return err
+		}
+
+		topicErr, ok := response.TopicPartitionErrors[topic]
+		if !ok {
+			return ErrIncompleteResponse
+		}
+		return topicErr.asError()
+	})
+}
+
+func (ca *clusterAdmin) DescribeConfig(resource ConfigResource) ([]*ConfigEntry, error) {
+	var entries []*ConfigEntry
+
+	err := ca.onResource(resource.Type, resource.Name, func(broker *Broker) error {
+		version, err := broker.requestVersion(32, 0)
+		if err != nil {
+			return err
+		}
+
+		response, err := broker.DescribeConfigs(&DescribeConfigsRequest{
+			Resources:       []*ConfigResource{&resource},
+			IncludeSynonyms: version >= 1,
+			IVersion:        version,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, result := range response.Resources {
+			if result.Type != resource.Type || result.Name != resource.Name {
+				continue
+			}
+			if err := (&TopicError{Err: result.Err, ErrMsg: result.ErrMsg}).asError(); err != nil {
+				return err
+			}
+			entries = result.Configs
+			return nil
+		}
+		return ErrIncompleteResponse
+	})
+
+	return entries, err
+}
+
+func (ca *clusterAdmin) AlterConfig(resourceType ConfigResourceType, name string, entries map[string]*string, validateOnly bool) error {
+	return ca.onResource(resourceType, name, func(broker *Broker) error {
+		version, err := broker.requestVersion(33, 0)
+		if err != nil {
+			return err
+		}
+
+		response, err := broker.AlterConfigs(&AlterConfigsRequest{
+			Resources:    []*AlterConfigsResource{{Type: resourceType, Name: name, ConfigEntries: entries}},
+			ValidateOnly: validateOnly,
+			IVersion:     version,
+		})
+		if err != nil {
+			return err
+		}
+
+		return alterConfigsError(response.Resources, resourceType, name)
+	})
+}
+
+func (ca *clusterAdmin) IncrementalAlterConfig(resourceType ConfigResourceType, name string, entries map[string]IncrementalAlterConfigsEntry, validateOnly bool) error {
+	return ca.onResource(resourceType, name, func(broker *Broker) error {
+		version, err := broker.requestVersion(44, 0)
+		if err != nil {
+			return err
+		}
+
+		response, err := broker.IncrementalAlterConfigs(&IncrementalAlterConfigsRequest{
+			Resources:    []*IncrementalAlterConfigsResource{{Type: resourceType, Name: name, ConfigEntries: entries}},
+			ValidateOnly: validateOnly,
+			IVersion:     version,
+		})
+		if err != nil {
+			return err
+		}
+
+		return alterConfigsError(response.Resources, resourceType, name)
+	})
+}
+
+func alterConfigsError(results []*AlterConfigsResourceResponse, resourceType ConfigResourceType, name string) error {
+	for _, result := range results {
+		if result.Type == resourceType && result.Name == name {
+			return (&TopicError{Err: result.Err, ErrMsg: result.ErrMsg}).asError()
+		}
+	}
+	return ErrIncompleteResponse
+}
+
+func (ca *clusterAdmin) ListConsumerGroups() (map[string]string, error) {
+	brokers := ca.client.Brokers()
+	if len(brokers) == 0 {
+		return nil, ErrOutOfBrokers
+	}
+
+	var (
+		wg     sync.WaitGroup
+		lock   sync.Mutex
+		groups = make(map[string]string)
+		errs   = make(chan error, len(brokers))
+	)
+	for _, broker := range brokers {
+		wg.Add(1)
+		go func(broker *Broker) {
+			defer wg.Done()
+
+			brokerGroups, err := listGroups(broker)
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			lock.Lock()
+			for group, protocolType := range brokerGroups {
+				groups[group] = protocolType
+			}
+			lock.Unlock()
+		}(broker)
+	}
+	wg.Wait()
+	close(errs)
+
+	if err := <-errs; err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+func listGroups(broker *Broker) (map[string]string, error) {
+	version, err := broker.requestVersion(16, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := broker.ListGroups(&ListGroupsRequest{IVersion: version})
+	if err != nil {
+		return nil, err
+	}
+	if response.Err != ErrNoError {
+		return nil, response.Err
+	}
+	return response.Groups, nil
+}
+
+func (ca *clusterAdmin) DescribeConsumerGroups(groups []string) ([]*GroupDescription, error) {
+	byCoordinator := make(map[*Broker][]string)
+	for _, group := range groups {
+		coordinator, err := ca.client.Coordinator(group)
+		if err != nil {
+			return nil, err
+		}
+		byCoordinator[coordinator] = append(byCoordinator[coordinator], group)
+	}
+
+	descriptions := make(map[string]*GroupDescription, len(groups))
+	for coordinator, coordinated := range byCoordinator {
+		version, err := coordinator.requestVersion(15, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := coordinator.DescribeGroups(&DescribeGroupsRequest{Groups: coordinated, IVersion: version})
+		if err != nil {
+			return nil, err
+		}
+		for _, description := range response.Groups {
+			descriptions[description.GroupID] = description
+		}
+	}
+
+	ret := make([]*GroupDescription, len(groups))
+	for i, group := range groups {
+		if ret[i] = descriptions[group]; ret[i] == nil {
+			return nil, ErrIncompleteResponse
+		}
+	}
+	return ret, nil
+}
+
+func (ca *clusterAdmin) ConsumerGroupLag(group string, topics ...string) (map[string]map[int32]*PartitionLag, error) {
+	coordinator, err := ca.client.Coordinator(group)
+	if err != nil {
+		return nil, err
+	}
+
+	// version 0 reads offsets from zookeeper, we always want the kafka-stored ones
+	version, err := coordinator.requestVersion(9, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &OffsetFetchRequest{ConsumerGroup: group, IVersion: version}
+	if len(topics) == 0 && version < 2 {
+		return nil, ConfigurationError("fetching the offsets of every partition of a group requires Version >= " + V0_10_2_0.String())
+	}
+	for _, topic := range topics {
+		partitions, err := ca.client.Partitions(topic)
+		if err != nil {
+			return nil, err
+		}
+		for _, partition := range partitions {
+			request.AddPartition(topic, partition)
+		}
+	}
+
+	response, err := coordinator.FetchOffset(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.Err != ErrNoError {
+		return nil, response.Err
+	}
+
+	lags := make(map[string]map[int32]*PartitionLag, len(response.Blocks))
+	for topic, blocks := range response.Blocks {
+		lags[topic] = make(map[int32]*PartitionLag, len(blocks))
+		for partition, block := range blocks {
+			lag := &PartitionLag{Committed: block.Offset, Metadata: block.Metadata, End: -1, Lag: -1}
+			if block.Err != ErrNoError {
+				lag.Err = block.Err
+			}
+			lags[topic][partition] = lag
+		}
+	}
+
+	ca.fetchLogEndOffsets(lags)
+	return lags, nil
+}
+
+// fetchLogEndOffsets fills in the log-end offset and lag of each partition, with a single
+// OffsetRequest to each partition leader.
+func (ca *clusterAdmin) fetchLogEndOffsets(lags map[string]map[int32]*PartitionLag) {
+	requests := make(map[*Broker]*OffsetRequest)
+	for topic, partitions := range lags {
+		for partition, lag := range partitions {
+			if lag.Err != nil {
+				continue
+			}
+			leader, err := ca.client.Leader(topic, partition)
+			if err != nil {
+				lag.Err = err
+				continue
+			}
+			if requests[leader] == nil {
+				requests[leader] = new(OffsetRequest)
+			}
+			requests[leader].AddBlock(topic, partition, OffsetNewest, 1)
+		}
+	}
+
+	for leader, request := range requests {
+		response, err := leader.GetAvailableOffsets(request)
+		if err != nil {
+			_ = leader.Close() // the client will reopen it when it is next needed
+		}
+
+		for topic, partitions := range request.Blocks {
+			for partition := range partitions {
+				lag := lags[topic][partition]
+				if err != nil {
+					lag.Err = err
+					continue
+				}
+
+				block := response.GetBlock(topic, partition)
+				switch {
+				case block == nil:
+					lag.Err = ErrIncompleteResponse
+				case block.Err != ErrNoError:
+					lag.Err = block.Err
+				case len(block.Offsets) != 1:
+					lag.Err = ErrOffsetOutOfRange
+				default:
+					lag.End = block.Offsets[0]
+					if lag.Committed >= 0 {
+						lag.Lag = lag.End - lag.Committed
+					}
+				}
+			}
+		}
+	}
+}
+
+func (ca *clusterAdmin) Close() error {
+	if ca.ownClient {
+		return ca.client.Close()
+	}
+	return nil
+}
+
+// onController sends a request to the controller, looking the controller up again and retrying once
+// if the broker we knew as the controller no longer is.
+func (ca *clusterAdmin) onController(fn func(controller *Broker) error) error {
+	for retried := false; ; retried = true {
+		controller, err := ca.getController()
+		if err != nil {
+			return err
+		}
+
+		err = fn(controller)
+		switch err.(type) {
+		case nil, KError, *TopicError, ConfigurationError:
+			// the connection is fine
+		default:
+			ca.forgetController(controller)
+			return err
+		}
+
+		if err == ErrNotController && !retried {
+			Logger.Printf("admin/controller broker #%d is no longer the controller\n", controller.ID())
+			ca.forgetController(controller)
+			continue
+		}
+		return err
+	}
+}
+
+// onResource sends a request about the configuration of a resource. Only a broker itself knows its
+// own configuration, while any broker does for topics and for the defaults shared by all the
+// brokers, so those go to the controller we already know.
+func (ca *clusterAdmin) onResource(resourceType ConfigResourceType, name string, fn func(broker *Broker) error) error {
+	if resourceType != BrokerResource || name == "" {
+		return ca.onController(fn)
+	}
+
+	id, err := strconv.ParseInt(name, 10, 32)
+	if err != nil {
+		return ConfigurationError("the name of a broker resource must be the ID of the broker, got " + name)
+	}
+
+	broker, err := ca.findBroker(int32(id))
+	if err != nil {
+		return err
+	}
+
+	return fn(broker)
+}
+
+func (ca *clusterAdmin) getController() (*Broker, error) {
+	controller, err := ca.client.Controller()
+	if err != nil {
+		return nil, err
+	}
+	if err := controller.Open(ca.conf); err != nil && err != ErrAlreadyConnected {
+		return nil, err
+	}
+	return controller, nil
+}
+
+// findBroker opens a connection to the broker with the given ID, refreshing the cluster metadata
+// once if the client does not know about that broker yet.
+func (ca *clusterAdmin) findBroker(id int32) (*Broker, error) {
+	for refreshed := false; ; refreshed = true {
+		for _, b := range ca.client.Brokers() {
+			if b.ID() == id {
+				if err := b.Open(ca.conf); err != nil && err != ErrAlreadyConnected {
+					return nil, err
+				}
+				return b, nil
+			}
+		}
+
+		if refreshed {
+			return nil, ErrBrokerNotFound
+		}
+		if err := ca.client.RefreshMetadata(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// forgetController drops the connection to a broker we can no longer use as the controller and has
+// the client look the controller up again on the next request.
+func (ca *clusterAdmin) forgetController(controller *Broker) {
+	_ = controller.Close() // we don't care about the error this might return, we already have one
+
+	if _, err := ca.client.RefreshController(); err != nil {
+		Logger.Printf("admin/controller failed to refresh the controller: %v\n", err)
+	}
+}
@@ -0,0 +1,605 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+func newClusterAdminTestConfig() *Config {
+	config := NewConfig()
+	config.Version = V1_0_0_0
+	return config
+}
+
+func TestClusterAdmin(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+	})
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, newClusterAdminTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := admin.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminInvalidVersion(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+	})
+
+	config := NewConfig()
+	config.Version = V0_10_0_0
+	if _, err := NewClusterAdmin([]string{seedBroker.Addr()}, config); err == nil {
+		t.Fatal("Expected an error for a Kafka version without admin requests")
+	}
+}
+
+func TestClusterAdminCreateTopic(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"CreateTopicsRequest": newMockWrapper(&CreateTopicsResponse{
+			ThrottleTime: 100 * time.Millisecond,
+			TopicErrors:  map[string]*TopicError{"my_topic": {Err: ErrNoError}},
+			IVersion:     2,
+		}),
+	})
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, newClusterAdminTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = admin.CreateTopic("my_topic", &TopicDetail{NumPartitions: 1, ReplicationFactor: 1}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := admin.CreateTopic("my_topic", nil, false); err == nil {
+		t.Error("Expected an error for a topic without details")
+	}
+
+	var request *CreateTopicsRequest
+	for _, rr := range seedBroker.History() {
+		if req, ok := rr.Request.(*CreateTopicsRequest); ok {
+			request = req
+		}
+	}
+	if request == nil {
+		t.Fatal("The controller never received the request")
+	}
+	if request.IVersion != 2 || request.Timeout != 3*time.Second || request.TopicDetails["my_topic"].NumPartitions != 1 {
+		t.Error("Unexpected request", request)
+	}
+
+	if err := admin.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminCreateTopicError(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	message := "Topic 'my_topic' already exists."
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"CreateTopicsRequest": newMockWrapper(&CreateTopicsResponse{
+			TopicErrors: map[string]*TopicError{"my_topic": {Err: ErrTopicAlreadyExists, ErrMsg: &message}},
+			IVersion:    2,
+		}),
+	})
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, newClusterAdminTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = admin.CreateTopic("my_topic", &TopicDetail{NumPartitions: 1, ReplicationFactor: 1}, false)
+	if topicErr, ok := err.(*TopicError); !ok || topicErr.Err != ErrTopicAlreadyExists || *topicErr.ErrMsg != message {
+		t.Error("Expected the topic error with its message, got", err)
+	}
+
+	if err := admin.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminDeleteTopic(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"DeleteTopicsRequest": newMockWrapper(&DeleteTopicsResponse{
+			TopicErrorCodes: map[string]KError{"my_topic": ErrTopicDeletionDisabled},
+			IVersion:        1,
+		}),
+	})
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, newClusterAdminTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := admin.DeleteTopic("my_topic"); err != ErrTopicDeletionDisabled {
+		t.Error("Expected ErrTopicDeletionDisabled, got", err)
+	}
+	if err := admin.DeleteTopic(""); err != ErrInvalidTopic {
+		t.Error("Expected ErrInvalidTopic, got", err)
+	}
+
+	if err := admin.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminCreatePartitions(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"CreatePartitionsRequest": newMockWrapper(&CreatePartitionsResponse{
+			TopicPartitionErrors: map[string]*TopicError{"my_topic": {Err: ErrNoError}},
+		}),
+	})
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, newClusterAdminTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := admin.CreatePartitions("my_topic", 3, nil, false); err != nil {
+		t.Error(err)
+	}
+
+	if err := admin.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminCreatePartitionsUnsupportedVersion(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+	})
+
+	config := NewConfig()
+	config.Version = V0_11_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := admin.CreatePartitions("my_topic", 3, nil, false); err != ErrUnsupportedVersion {
+		t.Error("Expected ErrUnsupportedVersion, got", err)
+	}
+
+	if err := admin.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminRetriesOnNotController(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+	controller := newMockBroker(t, 2)
+	defer controller.Close()
+
+	// the seed broker still believes it is the controller when the admin first asks
+	staleMetadata := newMockMetadataResponse(t).
+		SetController(seedBroker.BrokerID()).
+		SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+		SetBroker(controller.Addr(), controller.BrokerID())
+	metadata := newMockMetadataResponse(t).
+		SetController(controller.BrokerID()).
+		SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+		SetBroker(controller.Addr(), controller.BrokerID())
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockSequence(staleMetadata, metadata),
+		"DeleteTopicsRequest": newMockWrapper(&DeleteTopicsResponse{
+			TopicErrorCodes: map[string]KError{"my_topic": ErrNotController},
+			IVersion:        1,
+		}),
+	})
+	controller.SetHandlerByMap(map[string]MockResponse{
+		"DeleteTopicsRequest": newMockWrapper(&DeleteTopicsResponse{
+			TopicErrorCodes: map[string]KError{"my_topic": ErrNoError},
+			IVersion:        1,
+		}),
+	})
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, newClusterAdminTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := admin.DeleteTopic("my_topic"); err != nil {
+		t.Error("Expected the request to be retried on the new controller, got", err)
+	}
+	if len(controller.History()) != 1 {
+		t.Error("Expected the new controller to receive the request once, got", len(controller.History()))
+	}
+
+	if err := admin.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminDescribeConfig(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	retention := "-1"
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"DescribeConfigsRequest": newMockWrapper(&DescribeConfigsResponse{
+			Resources: []*ResourceConfigs{{
+				Type:    TopicResource,
+				Name:    "my_topic",
+				Configs: []*ConfigEntry{{Name: "retention.ms", Value: &retention, Source: SourceTopic}},
+			}},
+		}),
+	})
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, newClusterAdminTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := admin.DescribeConfig(ConfigResource{Type: TopicResource, Name: "my_topic"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name != "retention.ms" || *entries[0].Value != "-1" || entries[0].Source != SourceTopic {
+		t.Error("Unexpected entries", entries)
+	}
+
+	if _, err := admin.DescribeConfig(ConfigResource{Type: TopicResource, Name: "other_topic"}); err != ErrIncompleteResponse {
+		t.Error("Expected ErrIncompleteResponse, got", err)
+	}
+
+	if err := admin.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminDescribeBrokerConfig(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+	otherBroker := newMockBroker(t, 2)
+	defer otherBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetBroker(otherBroker.Addr(), otherBroker.BrokerID()),
+	})
+
+	threads := "2"
+	otherBroker.SetHandlerByMap(map[string]MockResponse{
+		"DescribeConfigsRequest": newMockWrapper(&DescribeConfigsResponse{
+			Resources: []*ResourceConfigs{{
+				Type:    BrokerResource,
+				Name:    "2",
+				Configs: []*ConfigEntry{{Name: "log.cleaner.threads", Value: &threads, Source: SourceStaticBroker}},
+			}},
+		}),
+	})
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, newClusterAdminTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := admin.DescribeConfig(ConfigResource{Type: BrokerResource, Name: "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name != "log.cleaner.threads" {
+		t.Error("Unexpected entries", entries)
+	}
+
+	if _, err := admin.DescribeConfig(ConfigResource{Type: BrokerResource, Name: "3"}); err != ErrBrokerNotFound {
+		t.Error("Expected ErrBrokerNotFound, got", err)
+	}
+	if _, err := admin.DescribeConfig(ConfigResource{Type: BrokerResource, Name: "broker"}); err == nil {
+		t.Error("Expected an error for a broker name that is not an ID")
+	}
+
+	if err := admin.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminAlterConfig(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	message := "Invalid value -2 for configuration retention.ms"
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"AlterConfigsRequest": newMockWrapper(&AlterConfigsResponse{
+			Resources: []*AlterConfigsResourceResponse{{
+				Err:    ErrInvalidConfig,
+				ErrMsg: &message,
+				Type:   TopicResource,
+				Name:   "my_topic",
+			}},
+		}),
+	})
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, newClusterAdminTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	retention := "-2"
+	err = admin.AlterConfig(TopicResource, "my_topic", map[string]*string{"retention.ms": &retention}, true)
+	if topicErr, ok := err.(*TopicError); !ok || topicErr.Err != ErrInvalidConfig || *topicErr.ErrMsg != message {
+		t.Error("Expected the config error with its message, got", err)
+	}
+
+	if err := admin.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminIncrementalAlterConfig(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"IncrementalAlterConfigsRequest": newMockWrapper(&IncrementalAlterConfigsResponse{
+			Resources: []*AlterConfigsResourceResponse{{Type: TopicResource, Name: "my_topic"}},
+		}),
+	})
+
+	config := NewConfig()
+	config.Version = V2_3_0_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replicas := "2"
+	entries := map[string]IncrementalAlterConfigsEntry{
+		"min.insync.replicas": {Operation: IncrementalAlterConfigsOperationSet, Value: &replicas},
+	}
+	if err := admin.IncrementalAlterConfig(TopicResource, "my_topic", entries, false); err != nil {
+		t.Error(err)
+	}
+
+	if err := admin.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	admin, err = NewClusterAdmin([]string{seedBroker.Addr()}, newClusterAdminTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := admin.IncrementalAlterConfig(TopicResource, "my_topic", entries, false); err != ErrUnsupportedVersion {
+		t.Error("Expected ErrUnsupportedVersion, got", err)
+	}
+	if err := admin.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminListConsumerGroups(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+	otherBroker := newMockBroker(t, 2)
+	defer otherBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetBroker(otherBroker.Addr(), otherBroker.BrokerID()),
+		"ListGroupsRequest": newMockWrapper(&ListGroupsResponse{
+			Groups:   map[string]string{"my_group": "consumer"},
+			IVersion: 1,
+		}),
+	})
+	otherBroker.SetHandlerByMap(map[string]MockResponse{
+		"ListGroupsRequest": newMockWrapper(&ListGroupsResponse{
+			Groups:   map[string]string{"other_group": "connect"},
+			IVersion: 1,
+		}),
+	})
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, newClusterAdminTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := admin.ListConsumerGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 || groups["my_group"] != "consumer" || groups["other_group"] != "connect" {
+		t.Error("Expected the groups of both brokers, got", groups)
+	}
+
+	if err := admin.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminDescribeConsumerGroups(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+	coordinator := newMockBroker(t, 2)
+	defer coordinator.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetBroker(coordinator.Addr(), coordinator.BrokerID()),
+		"ConsumerMetadataRequest": newMockConsumerMetadataResponse(t).
+			SetCoordinator("my_group", coordinator).
+			SetCoordinator("other_group", coordinator),
+	})
+	coordinator.SetHandlerByMap(map[string]MockResponse{
+		"DescribeGroupsRequest": newMockWrapper(&DescribeGroupsResponse{
+			Groups: []*GroupDescription{
+				{GroupID: "other_group", Err: ErrGroupAuthorizationFailed},
+				{GroupID: "my_group", State: "Empty", ProtocolType: "consumer"},
+			},
+			IVersion: 1,
+		}),
+	})
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, newClusterAdminTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	descriptions, err := admin.DescribeConsumerGroups([]string{"my_group", "other_group"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(descriptions) != 2 || descriptions[0].State != "Empty" || descriptions[1].Err != ErrGroupAuthorizationFailed {
+		t.Error("Unexpected descriptions", descriptions)
+	}
+
+	var requests int
+	for _, rr := range coordinator.History() {
+		if _, ok := rr.Request.(*DescribeGroupsRequest); ok {
+			requests++
+		}
+	}
+	if requests != 1 {
+		t.Error("Expected the groups of a coordinator to be described in one request, got", requests)
+	}
+
+	if err := admin.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminConsumerGroupLag(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+	leader := newMockBroker(t, 2)
+	defer leader.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()).
+			SetBroker(leader.Addr(), leader.BrokerID()).
+			SetLeader("my_topic", 0, seedBroker.BrokerID()).
+			SetLeader("my_topic", 1, leader.BrokerID()),
+		"ConsumerMetadataRequest": newMockConsumerMetadataResponse(t).
+			SetCoordinator("my_group", seedBroker),
+		"OffsetFetchRequest": newMockOffsetFetchResponse(t).
+			SetOffset("my_group", "my_topic", 0, 9, "md", ErrNoError).
+			SetOffset("my_group", "my_topic", 1, -1, "", ErrNoError),
+		"OffsetRequest": newMockOffsetResponse(t).
+			SetOffset("my_topic", 0, OffsetNewest, 20),
+	})
+	leader.SetHandlerByMap(map[string]MockResponse{
+		"OffsetRequest": newMockOffsetResponse(t).
+			SetOffset("my_topic", 1, OffsetNewest, 5),
+	})
+
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, newClusterAdminTestConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, topics := range [][]string{nil, {"my_topic"}} {
+		lags, err := admin.ConsumerGroupLag("my_group", topics...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		lag := lags["my_topic"][0]
+		if lag == nil || lag.Err != nil || lag.Committed != 9 || lag.Metadata != "md" || lag.End != 20 || lag.Lag != 11 {
+			t.Errorf("Unexpected lag of partition 0 with topics %v: %+v", topics, lag)
+		}
+		lag = lags["my_topic"][1]
+		if lag == nil || lag.Err != nil || lag.Committed != -1 || lag.End != 5 || lag.Lag != -1 {
+			t.Errorf("Unexpected lag of partition 1 with topics %v: %+v", topics, lag)
+		}
+	}
+
+	var requests int
+	for _, rr := range leader.History() {
+		if _, ok := rr.Request.(*OffsetRequest); ok {
+			requests++
+		}
+	}
+	if requests != 2 {
+		t.Error("Expected one OffsetRequest to the leader per call, got", requests)
+	}
+
+	if err := admin.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClusterAdminConsumerGroupLagRequiresTopics(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetController(seedBroker.BrokerID()).
+			SetBroker(seedBroker.Addr(), seedBroker.BrokerID()),
+		"ConsumerMetadataRequest": newMockConsumerMetadataResponse(t).
+			SetCoordinator("my_group", seedBroker),
+	})
+
+	config := NewConfig()
+	config.Version = V0_10_1_0
+	admin, err := NewClusterAdmin([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := admin.ConsumerGroupLag("my_group"); err == nil {
+		t.Error("Expected an error for the lag of every partition before Kafka 0.10.2")
+	}
+
+	if err := admin.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
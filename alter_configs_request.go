@@ -0,0 +1,132 @@
+package sarama
+
+import "encoding/json"
+
+// AlterConfigsResource holds the configuration entries to set on a topic or broker. Entries that are
+// left out revert to their defaults.
+type AlterConfigsResource struct {
+	Type          ConfigResourceType
+	Name          string
+	ConfigEntries map[string]*string
+}
+
+func (r *AlterConfigsResource) encode(pe packetEncoder) error {
+	pe.putInt8(int8(r.Type))
+	if err := pe.putString(r.Name); err != nil {
+		return err
+	}
+
+	if err := pe.putArrayLength(len(r.ConfigEntries)); err != nil {
+		return err
+	}
+	for name, value := range r.ConfigEntries {
+		if err := pe.putString(name); err != nil {
+			return err
+		}
+		if err := pe.putNullableString(value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *AlterConfigsResource) decode(pd packetDecoder) (err error) {
+	resourceType, err := pd.getInt8()
+	if err != nil {
+		return err
+	}
+	r.Type = ConfigResourceType(resourceType)
+
+	if r.Name, err = pd.getString(); err != nil {
+		return err
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.ConfigEntries = make(map[string]*string, n)
+	for i := 0; i < n; i++ {
+		name, err := pd.getString()
+		if err != nil {
+			return err
+		}
+		if r.ConfigEntries[name], err = pd.getNullableString(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSON gives the JSON view of the resource with only the names of its configuration entries,
+// whose values can be secrets.
+func (r AlterConfigsResource) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Type          ConfigResourceType
+		Name          string
+		ConfigEntries map[string]string
+	}{r.Type, r.Name, redactedConfigEntries(r.ConfigEntries)})
+}
+
+type AlterConfigsRequest struct {
+	Resources    []*AlterConfigsResource
+	ValidateOnly bool
+
+	// Version can be:
+	// - 0 (kafka 0.11 and later)
+	// - 1 (kafka 2.0 and later)
+	IVersion int16
+}
+
+func (r *AlterConfigsRequest) Encode(pe packetEncoder) error {
+	if r.IVersion < 0 || r.IVersion > 1 {
+		return PacketEncodingError{"invalid or unsupported AlterConfigsRequest version field"}
+	}
+
+	if err := pe.putArrayLength(len(r.Resources)); err != nil {
+		return err
+	}
+	for _, resource := range r.Resources {
+		if err := resource.encode(pe); err != nil {
+			return err
+		}
+	}
+
+	pe.putBool(r.ValidateOnly)
+
+	return nil
+}
+
+func (r *AlterConfigsRequest) Decode(pd packetDecoder) (err error) {
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.Resources = make([]*AlterConfigsResource, n)
+	for i := range r.Resources {
+		r.Resources[i] = new(AlterConfigsResource)
+		if err := r.Resources[i].decode(pd); err != nil {
+			return err
+		}
+	}
+
+	if r.ValidateOnly, err = pd.getBool(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *AlterConfigsRequest) Key() int16 {
+	return 33
+}
+
+func (r *AlterConfigsRequest) Version() int16 {
+	return r.IVersion
+}
+
+func (r *AlterConfigsRequest) String() string {
+	return dump(r)
+}
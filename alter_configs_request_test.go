@@ -0,0 +1,42 @@
+package sarama
+
+import "testing"
+
+var (
+	alterConfigsRequest = []byte{
+		0, 0, 0, 1,
+		2, // topic
+		0, 3, 'f', 'o', 'o',
+		0, 0, 0, 1,
+		0, 12, 'r', 'e', 't', 'e', 'n', 't', 'i', 'o', 'n', '.', 'm', 's',
+		0, 2, '-', '1',
+		0, // validate only
+	}
+
+	alterConfigsRequestValidateOnly = []byte{
+		0, 0, 0, 1,
+		2, // topic
+		0, 3, 'f', 'o', 'o',
+		0, 0, 0, 1,
+		0, 12, 'r', 'e', 't', 'e', 'n', 't', 'i', 'o', 'n', '.', 'm', 's',
+		0, 2, '-', '1',
+		1, // validate only
+	}
+)
+
+func TestAlterConfigsRequest(t *testing.T) {
+	retention := "-1"
+
+	request := &AlterConfigsRequest{
+		Resources: []*AlterConfigsResource{{
+			Type:          TopicResource,
+			Name:          "foo",
+			ConfigEntries: map[string]*string{"retention.ms": &retention},
+		}},
+	}
+	testRequest(t, "version 0", request, alterConfigsRequest)
+
+	request.IVersion = 1
+	request.ValidateOnly = true
+	testRequest(t, "version 1", request, alterConfigsRequestValidateOnly)
+}
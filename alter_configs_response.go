@@ -0,0 +1,87 @@
+package sarama
+
+import "time"
+
+// AlterConfigsResourceResponse is the outcome of altering the configuration of a topic or broker.
+type AlterConfigsResourceResponse struct {
+	Err    KError
+	ErrMsg *string
+	Type   ConfigResourceType
+	Name   string
+}
+
+func (r *AlterConfigsResourceResponse) encode(pe packetEncoder) error {
+	pe.putInt16(int16(r.Err))
+	if err := pe.putNullableString(r.ErrMsg); err != nil {
+		return err
+	}
+	pe.putInt8(int8(r.Type))
+	return pe.putString(r.Name)
+}
+
+func (r *AlterConfigsResourceResponse) decode(pd packetDecoder) (err error) {
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.Err = KError(kerr)
+
+	if r.ErrMsg, err = pd.getNullableString(); err != nil {
+		return err
+	}
+
+	resourceType, err := pd.getInt8()
+	if err != nil {
+		return err
+	}
+	r.Type = ConfigResourceType(resourceType)
+
+	r.Name, err = pd.getString()
+	return err
+}
+
+type AlterConfigsResponse struct {
+	ThrottleTime time.Duration
+	Resources    []*AlterConfigsResourceResponse
+}
+
+func (r *AlterConfigsResponse) Encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+
+	if err := pe.putArrayLength(len(r.Resources)); err != nil {
+		return err
+	}
+	for _, resource := range r.Resources {
+		if err := resource.encode(pe); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *AlterConfigsResponse) Decode(pd packetDecoder) (err error) {
+	millis, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(millis) * time.Millisecond
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.Resources = make([]*AlterConfigsResourceResponse, n)
+	for i := range r.Resources {
+		r.Resources[i] = new(AlterConfigsResourceResponse)
+		if err := r.Resources[i].decode(pd); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *AlterConfigsResponse) String() string {
+	return dump(r)
+}
@@ -0,0 +1,34 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var alterConfigsResponse = []byte{
+	0, 0, 0, 100,
+	0, 0, 0, 1,
+	0, 40,
+	0, 3, 'm', 's', 'g',
+	2, // topic
+	0, 3, 'f', 'o', 'o',
+}
+
+func TestAlterConfigsResponse(t *testing.T) {
+	response := new(AlterConfigsResponse)
+	testDecodable(t, "alter configs", response, alterConfigsResponse)
+	if response.ThrottleTime != 100*time.Millisecond {
+		t.Error("Decoding ThrottleTime failed, got", response.ThrottleTime)
+	}
+	if len(response.Resources) != 1 {
+		t.Fatal("Decoding resources failed, got", response.Resources)
+	}
+	resource := response.Resources[0]
+	if resource.Err != ErrInvalidConfig || resource.ErrMsg == nil || *resource.ErrMsg != "msg" {
+		t.Error("Decoding error failed, got", resource)
+	}
+	if resource.Type != TopicResource || resource.Name != "foo" {
+		t.Error("Decoding resource failed, got", resource)
+	}
+	testEncodable(t, "alter configs", response, alterConfigsResponse)
+}
@@ -0,0 +1,24 @@
+package sarama
+
+type ApiVersionsRequest struct {
+}
+
+func (r *ApiVersionsRequest) Encode(pe packetEncoder) error {
+	return nil
+}
+
+func (r *ApiVersionsRequest) Decode(pd packetDecoder) (err error) {
+	return nil
+}
+
+func (r *ApiVersionsRequest) Key() int16 {
+	return 18
+}
+
+func (r *ApiVersionsRequest) Version() int16 {
+	return 0
+}
+
+func (r *ApiVersionsRequest) String() string {
+	return dump(r)
+}
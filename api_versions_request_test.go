@@ -0,0 +1,12 @@
+package sarama
+
+import "testing"
+
+var (
+	apiVersionsRequest = []byte{}
+)
+
+func TestApiVersionsRequest(t *testing.T) {
+	request := new(ApiVersionsRequest)
+	testRequest(t, "basic", request, apiVersionsRequest)
+}
@@ -0,0 +1,27 @@
+package sarama
+
+import "testing"
+
+var (
+	apiVersionsResponseError = []byte{
+		0x00, 0x23,
+		0x00, 0x00, 0x00, 0x00}
+
+	apiVersionsResponse = []byte{
+		0x00, 0x00,
+		0x00, 0x00, 0x00, 0x02,
+		0x00, 0x03, 0x00, 0x00, 0x00, 0x02,
+		0x00, 0x08, 0x00, 0x00, 0x00, 0x02}
+)
+
+func TestApiVersionsResponseError(t *testing.T) {
+	response := ApiVersionsResponse{Err: ErrUnsupportedVersion, ApiVersions: []*ApiVersionsResponseBlock{}}
+	testResponse(t, "error", &response, apiVersionsResponseError)
+}
+
+func TestApiVersionsResponse(t *testing.T) {
+	response := ApiVersionsResponse{}
+	response.AddApiVersion(3, 0, 2)
+	response.AddApiVersion(8, 0, 2)
+	testResponse(t, "two apis", &response, apiVersionsResponse)
+}
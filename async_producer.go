@@ -1,6 +1,7 @@
 package sarama
 
 import (
+	"encoding/binary"
 	"fmt"
 	"sync"
 	"time"
@@ -45,12 +46,35 @@ type AsyncProducer interface {
 	// you can set Producer.Return.Errors in your config to false, which prevents
 	// errors to be returned.
 	Errors() <-chan *ProducerError
+
+	// BeginTxn starts a transaction. Messages are only accepted while a
+	// transaction is in progress, and become visible to consumers reading
+	// committed messages once it is committed. It requires
+	// Producer.Transaction.ID to be set.
+	BeginTxn() error
+
+	// CommitTxn waits for the messages of the transaction in progress to be
+	// acknowledged, or to fail, then commits it. No messages may be sent while
+	// it waits, and the Successes and Errors channels must still be read. If a
+	// message of the transaction failed, the transaction cannot be committed:
+	// the error is returned, and the transaction must be aborted.
+	CommitTxn() error
+
+	// AbortTxn waits for the messages of the transaction in progress to be
+	// acknowledged, or to fail, then aborts it, discarding them.
+	AbortTxn() error
+
+	// AddOffsetsToTxn commits the offsets of a consumer group as part of the
+	// transaction in progress, so that the messages consumed and the messages
+	// produced from them are committed, or aborted, together.
+	AddOffsetsToTxn(offsets map[string][]*PartitionOffsetMetadata, groupID string) error
 }
 
 type asyncProducer struct {
 	client    Client
 	conf      *Config
 	ownClient bool
+	txnmgr    *transactionManager
 
 	errors                    chan *ProducerError
 	input, successes, retries chan *ProducerMessage
@@ -84,9 +108,15 @@ func NewAsyncProducerFromClient(client Client) (AsyncProducer, error) {
 		return nil, ErrClosedClient
 	}
 
+	txnmgr, err := newTransactionManager(client.Config(), client)
+	if err != nil {
+		return nil, err
+	}
+
 	p := &asyncProducer{
 		client:     client,
 		conf:       client.Config(),
+		txnmgr:     txnmgr,
 		errors:     make(chan *ProducerError),
 		input:      make(chan *ProducerMessage),
 		successes:  make(chan *ProducerMessage),
@@ -107,6 +137,7 @@ type flagSet int8
 const (
 	chaser   flagSet = 1 << iota // message is last in a group that failed
 	shutdown                     // start the shutdown process
+	flush                        // every message sent before it is in flight
 )
 
 // ProducerMessage is the collection of elements passed to the Producer in order to send a message.
@@ -125,6 +156,17 @@ type ProducerMessage struct {
 	// pass-through data.
 	Metadata interface{}
 
+	// Timestamp is the time at which the message was created. It is only sent
+	// to the broker if Config.Version is at least V0_10_0_0, and defaults to
+	// the time at which the producer batched the message when left unset. If
+	// the topic uses `LogAppendTime`, it is replaced on success by the time at
+	// which the broker appended the message to its log.
+	Timestamp time.Time
+
+	// Headers are key-value pairs of metadata sent along with the message. They
+	// are only sent to the broker if Config.Version is at least V0_11_0_0.
+	Headers []RecordHeader
+
 	// Below this point are filled in by the producer as the message is processed
 
 	// Offset is the offset of the message stored on the broker. This is only
@@ -134,15 +176,39 @@ type ProducerMessage struct {
 	// Partition is the partition that the message was sent to. This is only
 	// guaranteed to be defined if the message was successfully delivered.
 	Partition int32
+	// ThrottleTime is how long the broker delayed its response to the request
+	// that delivered the message, because the client exceeded a quota. It is
+	// only provided if Config.Version is at least V0_9_0_0.
+	ThrottleTime time.Duration
 
 	retries int
 	flags   flagSet
+
+	// set once the message is numbered for an idempotent producer, see transactionManager
+	hasSequence    bool
+	sequenceNumber int32
+	producerEpoch  int16
 }
 
 const producerMessageOverhead = 26 // the metadata overhead of CRC, flags, etc.
 
-func (m *ProducerMessage) byteSize() int {
-	size := producerMessageOverhead
+// the most metadata a record in a RecordBatch can take: its length, deltas, and the lengths of
+// its key, value and header count as varints, plus its attributes
+const maximumRecordOverhead = 5*binary.MaxVarintLen32 + binary.MaxVarintLen64 + 1
+
+func (m *ProducerMessage) byteSize(conf *Config) int {
+	var size int
+	if conf.Version.IsAtLeast(V0_11_0_0) {
+		size = maximumRecordOverhead
+		for _, h := range m.Headers {
+			size += len(h.Key) + len(h.Value) + 2*binary.MaxVarintLen32
+		}
+	} else {
+		size = producerMessageOverhead
+		if conf.Version.IsAtLeast(V0_10_0_0) {
+			size += 8 // version 1 messages carry a timestamp
+		}
+	}
 	if m.Key != nil {
 		size += m.Key.Length()
 	}
@@ -155,6 +221,7 @@ func (m *ProducerMessage) byteSize() int {
 func (m *ProducerMessage) clear() {
 	m.flags = 0
 	m.retries = 0
+	m.hasSequence = false
 }
 
 // ProducerError is the type of error generated when the producer fails to deliver a message.
@@ -216,6 +283,36 @@ func (p *asyncProducer) AsyncClose() {
 	go withRecover(p.shutdown)
 }
 
+func (p *asyncProducer) BeginTxn() error {
+	return p.txnmgr.beginTxn()
+}
+
+func (p *asyncProducer) CommitTxn() error {
+	return p.endTxn(true)
+}
+
+func (p *asyncProducer) AbortTxn() error {
+	return p.endTxn(false)
+}
+
+func (p *asyncProducer) AddOffsetsToTxn(offsets map[string][]*PartitionOffsetMetadata, groupID string) error {
+	return p.txnmgr.addOffsetsToTxn(offsets, groupID)
+}
+
+func (p *asyncProducer) endTxn(commit bool) error {
+	if err := p.txnmgr.checkInTransaction(); err != nil {
+		return err
+	}
+
+	// once the dispatcher has seen the flush message, every message of the transaction is
+	// counted in inFlight
+	p.inFlight.Add(1)
+	p.input <- &ProducerMessage{flags: flush}
+	p.inFlight.Wait()
+
+	return p.txnmgr.endTxn(commit)
+}
+
 // singleton
 // dispatches messages by topic
 func (p *asyncProducer) dispatcher() {
@@ -232,6 +329,9 @@ func (p *asyncProducer) dispatcher() {
 			shuttingDown = true
 			p.inFlight.Done()
 			continue
+		} else if msg.flags&flush != 0 {
+			p.inFlight.Done()
+			continue
 		} else if msg.retries == 0 {
 			if shuttingDown {
 				// we can't just call returnError here because that decrements the wait group,
@@ -245,9 +345,16 @@ func (p *asyncProducer) dispatcher() {
 				continue
 			}
 			p.inFlight.Add(1)
+
+			if p.txnmgr.isTransactional() {
+				if err := p.txnmgr.checkInTransaction(); err != nil {
+					p.returnError(msg, err)
+					continue
+				}
+			}
 		}
 
-		if msg.byteSize() > p.conf.Producer.MaxMessageBytes {
+		if msg.byteSize(p.conf) > p.conf.Producer.MaxMessageBytes {
 			p.returnError(msg, ErrMessageSizeTooLarge)
 			continue
 		}
@@ -527,9 +634,20 @@ func (p *asyncProducer) newBrokerProducer(broker *Broker) chan<- *ProducerMessag
 	// minimal bridge to make the network response `select`able
 	go withRecover(func() {
 		for set := range bridge {
-			request := set.buildRequest()
-
-			response, err := broker.Produce(request)
+			var response *ProduceResponse
+			var min int16
+			if p.conf.Producer.Idempotent {
+				min = 3 // the first version to carry producer IDs
+			}
+			version, err := broker.requestVersion(0, min)
+			if err == nil {
+				if err = p.txnmgr.addPartitionsToTxn(set); err != nil {
+					err = addPartitionsError{err}
+				}
+			}
+			if err == nil {
+				response, err = broker.Produce(set.buildRequest(version))
+			}
 
 			responses <- &brokerProducerResponse{
 				set: set,
@@ -689,6 +807,11 @@ func (bp *brokerProducer) handleResponse(response *brokerProducerResponse) {
 }
 
 func (bp *brokerProducer) handleSuccess(sent *produceSet, response *ProduceResponse) {
+	if response != nil && response.ThrottleTime > 0 {
+		Logger.Printf("producer/broker/%d response was throttled by %dms because of a quota\n",
+			bp.broker.ID(), response.ThrottleTime/time.Millisecond)
+	}
+
 	// we iterate through the blocks in the request set, not the response, so that we notice
 	// if the response is missing a block completely
 	sent.eachPartition(func(topic string, partition int32, msgs []*ProducerMessage) {
@@ -709,8 +832,19 @@ func (bp *brokerProducer) handleSuccess(sent *produceSet, response *ProduceRespo
 		case ErrNoError:
 			for i, msg := range msgs {
 				msg.Offset = block.Offset + int64(i)
+				msg.ThrottleTime = response.ThrottleTime
+				if !block.Timestamp.IsZero() {
+					// the topic uses LogAppendTime
+					msg.Timestamp = block.Timestamp
+				}
 			}
 			bp.parent.returnSuccesses(msgs)
+		// The broker already has the messages of an idempotent producer, from an earlier
+		// attempt whose response was lost
+		case ErrDuplicateSequenceNumber:
+			Logger.Printf("producer/broker/%d discarded duplicate messages on %s/%d\n",
+				bp.broker.ID(), topic, partition)
+			bp.parent.returnSuccesses(msgs)
 		// Retriable errors
 		case ErrUnknownTopicOrPartition, ErrNotLeaderForPartition, ErrLeaderNotAvailable,
 			ErrRequestTimedOut, ErrNotEnoughReplicas, ErrNotEnoughReplicasAfterAppend:
@@ -730,11 +864,15 @@ func (bp *brokerProducer) handleSuccess(sent *produceSet, response *ProduceRespo
 }
 
 func (bp *brokerProducer) handleError(sent *produceSet, err error) {
-	switch err.(type) {
+	switch e := err.(type) {
 	case PacketEncodingError:
 		sent.eachPartition(func(topic string, partition int32, msgs []*ProducerMessage) {
 			bp.parent.returnErrors(msgs, err)
 		})
+	case addPartitionsError:
+		sent.eachPartition(func(topic string, partition int32, msgs []*ProducerMessage) {
+			bp.parent.returnErrors(msgs, e.err)
+		})
 	default:
 		Logger.Printf("producer/broker/%d state change to [closing] because %s\n", bp.broker.ID(), err)
 		bp.parent.abandonBrokerConnection(bp.broker)
@@ -828,10 +966,15 @@ func (ps *produceSet) add(msg *ProducerMessage) error {
 		partitions[msg.Partition] = set
 	}
 
+	timestamp := msg.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
 	set.msgs = append(set.msgs, msg)
-	set.setToSend.addMessage(&Message{Codec: CompressionNone, Key: key, Value: val})
+	set.setToSend.addMessage(&Message{Codec: CompressionNone, Key: key, Value: val, Timestamp: timestamp})
 
-	size := producerMessageOverhead + len(key) + len(val)
+	size := msg.byteSize(ps.parent.conf)
 	set.bufferBytes += size
 	ps.bufferBytes += size
 	ps.bufferCount++
@@ -839,14 +982,40 @@ func (ps *produceSet) add(msg *ProducerMessage) error {
 	return nil
 }
 
-func (ps *produceSet) buildRequest() *ProduceRequest {
+func (ps *produceSet) buildRequest(version int16) *ProduceRequest {
 	req := &ProduceRequest{
 		RequiredAcks: ps.parent.conf.Producer.RequiredAcks,
 		Timeout:      int32(ps.parent.conf.Producer.Timeout / time.Millisecond),
+		IVersion:     version,
+	}
+	if version >= 3 && ps.parent.txnmgr.isTransactional() {
+		req.TransactionalID = &ps.parent.txnmgr.transactionalID
 	}
 
 	for topic, partitionSet := range ps.msgs {
 		for partition, set := range partitionSet {
+			if version >= 3 {
+				batch := set.buildRecordBatch(ps.parent.conf.Producer.Compression, ps.parent.conf.Producer.CompressionLevel)
+				if ps.parent.conf.Producer.Idempotent {
+					ps.parent.txnmgr.assignSequenceNumbers(topic, partition, set.msgs)
+					batch.ProducerID = ps.parent.txnmgr.producerID
+					batch.ProducerEpoch = set.msgs[0].producerEpoch
+					batch.FirstSequence = set.msgs[0].sequenceNumber
+					batch.IsTransactional = ps.parent.txnmgr.isTransactional()
+				}
+				req.AddBatch(topic, partition, batch)
+				continue
+			}
+
+			if version >= 2 {
+				// version 2 requests carry version 1 messages, with timestamps; inside a
+				// compressed message their offsets are relative to the first of them
+				for i, msgBlock := range set.setToSend.Messages {
+					msgBlock.Offset = int64(i)
+					msgBlock.Msg.Version = 1
+				}
+			}
+
 			if ps.parent.conf.Producer.Compression == CompressionNone {
 				req.AddSet(topic, partition, set.setToSend)
 			} else {
@@ -859,11 +1028,21 @@ func (ps *produceSet) buildRequest() *ProduceRequest {
 					Logger.Println(err) // if this happens, it's basically our fault.
 					panic(err)
 				}
-				req.AddMessage(topic, partition, &Message{
-					Codec: ps.parent.conf.Producer.Compression,
-					Key:   nil,
-					Value: payload,
-				})
+				compressed := &Message{
+					Codec:            ps.parent.conf.Producer.Compression,
+					CompressionLevel: ps.parent.conf.Producer.CompressionLevel,
+					Key:              nil,
+					Value:            payload,
+				}
+				if version >= 2 {
+					compressed.Version = 1
+					for _, msgBlock := range set.setToSend.Messages {
+						if msgBlock.Msg.Timestamp.After(compressed.Timestamp) {
+							compressed.Timestamp = msgBlock.Msg.Timestamp
+						}
+					}
+				}
+				req.AddMessage(topic, partition, compressed)
 			}
 		}
 	}
@@ -871,6 +1050,22 @@ func (ps *produceSet) buildRequest() *ProduceRequest {
 	return req
 }
 
+// buildRecordBatch converts the messages of the set into the RecordBatch that version 3 and later
+// of ProduceRequest carry instead of a MessageSet.
+func (set *partitionSet) buildRecordBatch(codec CompressionCodec, level int) *RecordBatch {
+	batch := &RecordBatch{
+		Codec:            codec,
+		CompressionLevel: level,
+		ProducerID:       -1,
+		ProducerEpoch:    -1,
+		FirstSequence:    -1,
+	}
+	for i, msgBlock := range set.setToSend.Messages {
+		batch.AddRecord(int64(i), msgBlock.Msg.Timestamp, msgBlock.Msg.Key, msgBlock.Msg.Value, set.msgs[i].Headers)
+	}
+	return batch
+}
+
 func (ps *produceSet) eachPartition(cb func(topic string, partition int32, msgs []*ProducerMessage)) {
 	for topic, partitionSet := range ps.msgs {
 		for partition, set := range partitionSet {
@@ -896,12 +1091,12 @@ func (ps *produceSet) dropPartition(topic string, partition int32) []*ProducerMe
 func (ps *produceSet) wouldOverflow(msg *ProducerMessage) bool {
 	switch {
 	// Would we overflow our maximum possible size-on-the-wire? 10KiB is arbitrary overhead for safety.
-	case ps.bufferBytes+msg.byteSize() >= int(MaxRequestSize-(10*1024)):
+	case ps.bufferBytes+msg.byteSize(ps.parent.conf) >= int(MaxRequestSize-(10*1024)):
 		return true
 	// Would we overflow the size-limit of a compressed message-batch for this partition?
 	case ps.parent.conf.Producer.Compression != CompressionNone &&
 		ps.msgs[msg.Topic] != nil && ps.msgs[msg.Topic][msg.Partition] != nil &&
-		ps.msgs[msg.Topic][msg.Partition].bufferBytes+msg.byteSize() >= ps.parent.conf.Producer.MaxMessageBytes:
+		ps.msgs[msg.Topic][msg.Partition].bufferBytes+msg.byteSize(ps.parent.conf) >= ps.parent.conf.Producer.MaxMessageBytes:
 		return true
 	// Would we overflow simply in number of messages?
 	case ps.parent.conf.Producer.Flush.MaxMessages > 0 && ps.bufferCount >= ps.parent.conf.Producer.Flush.MaxMessages:
@@ -957,6 +1152,9 @@ func (p *asyncProducer) shutdown() {
 }
 
 func (p *asyncProducer) returnError(msg *ProducerMessage, err error) {
+	if msg.hasSequence || p.txnmgr.isTransactional() {
+		p.txnmgr.bumpEpoch(msg.producerEpoch, err)
+	}
 	msg.clear()
 	pErr := &ProducerError{Msg: msg, Err: err}
 	if p.conf.Producer.Return.Errors {
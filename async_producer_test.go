@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"reflect"
 	"sync"
 	"testing"
 	"time"
@@ -139,6 +140,194 @@ func TestAsyncProducer(t *testing.T) {
 	seedBroker.Close()
 }
 
+func TestAsyncProducerTimestamps(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	leader := newMockBroker(t, 2)
+
+	metadataResponse := &MetadataResponse{IVersion: 1}
+	metadataResponse.AddBroker(leader.Addr(), leader.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, leader.BrokerID(), nil, nil, ErrNoError)
+	seedBroker.Returns(metadataResponse)
+
+	prodSuccess := &ProduceResponse{IVersion: 2}
+	prodSuccess.AddTopicPartition("my_topic", 0, ErrNoError)
+	leader.Returns(prodSuccess)
+
+	config := NewConfig()
+	config.Version = V0_10_0_0
+	config.Producer.Flush.Messages = 2
+	config.Producer.Return.Successes = true
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timestamp := time.Unix(1479847795, 0)
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage), Timestamp: timestamp}
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	expectResults(t, producer, 2, 0)
+	closeProducer(t, producer)
+
+	request := leader.History()[0].Request.(*ProduceRequest)
+	if request.Version() != 2 {
+		t.Error("Expected version 2 of ProduceRequest, got", request.Version())
+	}
+	messages := request.MsgSets["my_topic"][0].Messages
+	if len(messages) != 2 {
+		t.Fatal("Expected 2 messages, got", len(messages))
+	}
+	if messages[0].Msg.Version != 1 || !messages[0].Msg.Timestamp.Equal(timestamp) {
+		t.Error("Expected a version 1 message with the given timestamp, got", messages[0].Msg.Version, messages[0].Msg.Timestamp)
+	}
+	if messages[1].Msg.Timestamp.IsZero() {
+		t.Error("Expected the producer to timestamp messages without one")
+	}
+
+	leader.Close()
+	seedBroker.Close()
+}
+
+func TestAsyncProducerLogAppendTimeAndThrottling(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	leader := newMockBroker(t, 2)
+
+	metadataResponse := &MetadataResponse{IVersion: 1}
+	metadataResponse.AddBroker(leader.Addr(), leader.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, leader.BrokerID(), nil, nil, ErrNoError)
+	seedBroker.Returns(metadataResponse)
+
+	appendTime := time.Unix(1479847800, 0)
+	prodSuccess := &ProduceResponse{IVersion: 2, ThrottleTime: 100 * time.Millisecond}
+	prodSuccess.AddTopicPartition("my_topic", 0, ErrNoError)
+	prodSuccess.Blocks["my_topic"][0].Offset = 10
+	prodSuccess.Blocks["my_topic"][0].Timestamp = appendTime
+	leader.Returns(prodSuccess)
+
+	config := NewConfig()
+	config.Version = V0_10_0_0
+	config.Producer.Flush.Messages = 2
+	config.Producer.Return.Successes = true
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	createTime := time.Unix(1479847795, 0)
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage), Timestamp: createTime}
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	for i := 0; i < 2; i++ {
+		select {
+		case msg := <-producer.Successes():
+			if !msg.Timestamp.Equal(appendTime) {
+				t.Error("Expected the broker's timestamp, got", msg.Timestamp)
+			}
+			if msg.ThrottleTime != 100*time.Millisecond {
+				t.Error("Expected a throttle time of 100ms, got", msg.ThrottleTime)
+			}
+		case pErr := <-producer.Errors():
+			t.Error(pErr)
+		}
+	}
+	closeProducer(t, producer)
+
+	leader.Close()
+	seedBroker.Close()
+}
+
+func TestAsyncProducerRecordBatches(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	leader := newMockBroker(t, 2)
+
+	metadataResponse := &MetadataResponse{IVersion: 1}
+	metadataResponse.AddBroker(leader.Addr(), leader.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, leader.BrokerID(), nil, nil, ErrNoError)
+	seedBroker.Returns(metadataResponse)
+
+	prodSuccess := &ProduceResponse{IVersion: 3}
+	prodSuccess.AddTopicPartition("my_topic", 0, ErrNoError)
+	leader.Returns(prodSuccess)
+
+	config := NewConfig()
+	config.Version = V0_11_0_0
+	config.Producer.Flush.Messages = 2
+	config.Producer.Return.Successes = true
+	config.Producer.Compression = CompressionGZIP
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headers := []RecordHeader{{Key: []byte("trace"), Value: []byte("abc")}}
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage), Headers: headers}
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	expectResults(t, producer, 2, 0)
+	closeProducer(t, producer)
+
+	request := leader.History()[0].Request.(*ProduceRequest)
+	if request.Version() != 3 {
+		t.Error("Expected version 3 of ProduceRequest, got", request.Version())
+	}
+	batch := request.RecordBatches["my_topic"][0]
+	if batch == nil || len(batch.Records) != 2 {
+		t.Fatal("Expected a record batch of 2 records")
+	}
+	if batch.Codec != CompressionGZIP || batch.ProducerID != -1 || batch.LastOffsetDelta != 1 {
+		t.Error("Incorrect record batch metadata")
+	}
+	if len(batch.Records[0].Headers) != 1 || string(batch.Records[0].Headers[0].Key) != "trace" {
+		t.Error("Expected the headers to be sent, got", batch.Records[0].Headers)
+	}
+
+	leader.Close()
+	seedBroker.Close()
+}
+
+func TestAsyncProducerZstd(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	leader := newMockBroker(t, 2)
+
+	metadataResponse := &MetadataResponse{IVersion: 1}
+	metadataResponse.AddBroker(leader.Addr(), leader.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, leader.BrokerID(), nil, nil, ErrNoError)
+	seedBroker.Returns(metadataResponse)
+
+	prodSuccess := &ProduceResponse{IVersion: 7}
+	prodSuccess.AddTopicPartition("my_topic", 0, ErrNoError)
+	leader.Returns(prodSuccess)
+
+	config := NewConfig()
+	config.Version = V2_1_0_0
+	config.Producer.Flush.Messages = 2
+	config.Producer.Return.Successes = true
+	config.Producer.Compression = CompressionZSTD
+	config.Producer.CompressionLevel = 3
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	}
+	expectResults(t, producer, 2, 0)
+	closeProducer(t, producer)
+
+	request := leader.History()[0].Request.(*ProduceRequest)
+	if request.Version() != 7 {
+		t.Error("Expected version 7 of ProduceRequest, got", request.Version())
+	}
+	batch := request.RecordBatches["my_topic"][0]
+	if batch == nil || batch.Codec != CompressionZSTD || len(batch.Records) != 2 {
+		t.Fatal("Expected a ZSTD record batch of 2 records")
+	}
+	if string(batch.Records[1].Value) != TestMessage {
+		t.Error("Decoding the ZSTD record batch produced", string(batch.Records[1].Value))
+	}
+
+	leader.Close()
+	seedBroker.Close()
+}
+
 func TestAsyncProducerMultipleFlushes(t *testing.T) {
 	seedBroker := newMockBroker(t, 1)
 	leader := newMockBroker(t, 2)
@@ -481,6 +670,303 @@ func TestAsyncProducerMultipleRetries(t *testing.T) {
 	closeProducer(t, producer)
 }
 
+func producedBatches(t *testing.T, leader *mockBroker) []*RecordBatch {
+	var batches []*RecordBatch
+	for _, rr := range leader.History() {
+		if request, ok := rr.Request.(*ProduceRequest); ok {
+			batches = append(batches, request.RecordBatches["my_topic"][0])
+		}
+	}
+	return batches
+}
+
+func TestAsyncProducerIdempotentRetry(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	leader := newMockBroker(t, 2)
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetBroker(leader.Addr(), leader.BrokerID()).
+			SetLeader("my_topic", 0, leader.BrokerID()),
+		"InitProducerIDRequest": newMockWrapper(&InitProducerIDResponse{ProducerID: 1000, ProducerEpoch: 1}),
+	})
+	leader.SetHandlerByMap(map[string]MockResponse{
+		"ProduceRequest": newMockSequence(
+			newMockProduceResponse(t).SetError("my_topic", 0, ErrRequestTimedOut),
+			newMockProduceResponse(t).SetError("my_topic", 0, ErrDuplicateSequenceNumber),
+			newMockProduceResponse(t)),
+	})
+
+	config := NewConfig()
+	config.Version = V0_11_0_0
+	config.Producer.Idempotent = true
+	config.Producer.RequiredAcks = WaitForAll
+	config.Producer.Flush.Messages = 10
+	config.Producer.Return.Successes = true
+	config.Producer.Retry.Backoff = 0
+	config.Net.MaxOpenRequests = 1
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	}
+	expectResults(t, producer, 10, 0)
+
+	for i := 0; i < 10; i++ {
+		producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	}
+	expectResults(t, producer, 10, 0)
+	closeProducer(t, producer)
+
+	batches := producedBatches(t, leader)
+	if len(batches) != 3 {
+		t.Fatal("Expected 3 produce requests, got", len(batches))
+	}
+	for i, firstSequence := range []int32{0, 0, 10} {
+		batch := batches[i]
+		if batch.ProducerID != 1000 || batch.ProducerEpoch != 1 || batch.FirstSequence != firstSequence {
+			t.Errorf("Batch %d: expected producer 1000, epoch 1 and first sequence %d, got %d, %d and %d",
+				i, firstSequence, batch.ProducerID, batch.ProducerEpoch, batch.FirstSequence)
+		}
+	}
+
+	leader.Close()
+	seedBroker.Close()
+}
+
+func TestAsyncProducerIdempotentEpochBump(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	leader := newMockBroker(t, 2)
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetBroker(leader.Addr(), leader.BrokerID()).
+			SetLeader("my_topic", 0, leader.BrokerID()),
+		"InitProducerIDRequest": newMockWrapper(&InitProducerIDResponse{ProducerID: 1000, ProducerEpoch: 1}),
+	})
+	leader.SetHandlerByMap(map[string]MockResponse{
+		"ProduceRequest": newMockSequence(
+			newMockProduceResponse(t).SetError("my_topic", 0, ErrOutOfOrderSequenceNumber),
+			newMockProduceResponse(t)),
+	})
+
+	config := NewConfig()
+	config.Version = V0_11_0_0
+	config.Producer.Idempotent = true
+	config.Producer.RequiredAcks = WaitForAll
+	config.Producer.Flush.Messages = 10
+	config.Producer.Return.Successes = true
+	config.Producer.Retry.Backoff = 0
+	config.Net.MaxOpenRequests = 1
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 10; i++ {
+		producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	}
+	expectResults(t, producer, 0, 10)
+
+	for i := 0; i < 10; i++ {
+		producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	}
+	expectResults(t, producer, 10, 0)
+	closeProducer(t, producer)
+
+	batches := producedBatches(t, leader)
+	if len(batches) != 2 {
+		t.Fatal("Expected 2 produce requests, got", len(batches))
+	}
+	if batches[1].ProducerEpoch != 2 || batches[1].FirstSequence != 0 {
+		t.Error("Expected the sequence to start again in epoch 2, got",
+			batches[1].ProducerEpoch, batches[1].FirstSequence)
+	}
+
+	leader.Close()
+	seedBroker.Close()
+}
+
+func TestAsyncProducerTransaction(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	leader := newMockBroker(t, 2)
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetBroker(leader.Addr(), leader.BrokerID()).
+			SetLeader("my_topic", 0, leader.BrokerID()),
+		"ConsumerMetadataRequest": newMockConsumerMetadataResponse(t).
+			SetCoordinator("txn", leader),
+	})
+	leader.SetHandlerByMap(map[string]MockResponse{
+		"InitProducerIDRequest": newMockSequence(
+			newMockWrapper(&InitProducerIDResponse{ProducerID: 1000, ProducerEpoch: 1}),
+			newMockWrapper(&InitProducerIDResponse{ProducerID: 1000, ProducerEpoch: 2})),
+		"AddPartitionsToTxnRequest": newMockWrapper(&AddPartitionsToTxnResponse{
+			Errors: map[string][]*PartitionError{"my_topic": {{Partition: 0, Err: ErrNoError}}},
+		}),
+		"EndTxnRequest":  newMockWrapper(&EndTxnResponse{}),
+		"ProduceRequest": newMockProduceResponse(t),
+	})
+
+	config := NewConfig()
+	config.Version = V0_11_0_0
+	config.Producer.Idempotent = true
+	config.Producer.RequiredAcks = WaitForAll
+	config.Producer.Flush.Messages = 10
+	config.Producer.Return.Successes = true
+	config.Producer.Retry.Backoff = 0
+	config.Net.MaxOpenRequests = 1
+	config.Producer.Transaction.ID = "txn"
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	if pErr := <-producer.Errors(); pErr.Err != ErrNotInTransaction {
+		t.Error("Expected ErrNotInTransaction outside of a transaction, got", pErr.Err)
+	}
+
+	if err := producer.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	if err := producer.BeginTxn(); err != ErrTransactionNotReady {
+		t.Error("Expected ErrTransactionNotReady, got", err)
+	}
+	for i := 0; i < 10; i++ {
+		producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	}
+	expectResults(t, producer, 10, 0)
+	if err := producer.CommitTxn(); err != nil {
+		t.Fatal(err)
+	}
+	if err := producer.CommitTxn(); err != ErrNotInTransaction {
+		t.Error("Expected ErrNotInTransaction, got", err)
+	}
+	closeProducer(t, producer)
+
+	var requests []string
+	for _, rr := range leader.History() {
+		switch request := rr.Request.(type) {
+		case *InitProducerIDRequest:
+			if request.TransactionalID == nil || *request.TransactionalID != "txn" || request.TransactionTimeout != time.Minute {
+				t.Error("Unexpected InitProducerIDRequest", request)
+			}
+		case *AddPartitionsToTxnRequest:
+			if request.ProducerID != 1000 || request.ProducerEpoch != 1 || len(request.TopicPartitions["my_topic"]) != 1 {
+				t.Error("Unexpected AddPartitionsToTxnRequest", request)
+			}
+		case *ProduceRequest:
+			batch := request.RecordBatches["my_topic"][0]
+			if request.TransactionalID == nil || *request.TransactionalID != "txn" || !batch.IsTransactional {
+				t.Error("Expected a transactional produce request")
+			}
+		case *EndTxnRequest:
+			if !request.Commit || request.ProducerEpoch != 1 {
+				t.Error("Unexpected EndTxnRequest", request)
+			}
+		}
+		requests = append(requests, reflect.TypeOf(rr.Request).Elem().Name())
+	}
+	expected := []string{"InitProducerIDRequest", "AddPartitionsToTxnRequest", "ProduceRequest", "EndTxnRequest"}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Expected requests %v, got %v", expected, requests)
+	}
+
+	leader.Close()
+	seedBroker.Close()
+}
+
+func TestAsyncProducerTransactionAbortAfterFailure(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	leader := newMockBroker(t, 2)
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetBroker(leader.Addr(), leader.BrokerID()).
+			SetLeader("my_topic", 0, leader.BrokerID()),
+		"ConsumerMetadataRequest": newMockConsumerMetadataResponse(t).
+			SetCoordinator("txn", leader),
+	})
+	leader.SetHandlerByMap(map[string]MockResponse{
+		"InitProducerIDRequest": newMockSequence(
+			newMockWrapper(&InitProducerIDResponse{ProducerID: 1000, ProducerEpoch: 1}),
+			newMockWrapper(&InitProducerIDResponse{ProducerID: 1000, ProducerEpoch: 2})),
+		"AddPartitionsToTxnRequest": newMockWrapper(&AddPartitionsToTxnResponse{
+			Errors: map[string][]*PartitionError{"my_topic": {{Partition: 0, Err: ErrNoError}}},
+		}),
+		"EndTxnRequest": newMockWrapper(&EndTxnResponse{}),
+		"ProduceRequest": newMockSequence(
+			newMockProduceResponse(t).SetError("my_topic", 0, ErrInvalidProducerEpoch),
+			newMockProduceResponse(t)),
+	})
+
+	config := NewConfig()
+	config.Version = V0_11_0_0
+	config.Producer.Idempotent = true
+	config.Producer.RequiredAcks = WaitForAll
+	config.Producer.Flush.Messages = 10
+	config.Producer.Return.Successes = true
+	config.Producer.Retry.Backoff = 0
+	config.Net.MaxOpenRequests = 1
+	config.Producer.Transaction.ID = "txn"
+	producer, err := NewAsyncProducer([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := producer.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	}
+	expectResults(t, producer, 0, 10)
+	if err := producer.CommitTxn(); err != ErrInvalidProducerEpoch {
+		t.Error("Expected the commit to fail with ErrInvalidProducerEpoch, got", err)
+	}
+	if err := producer.AbortTxn(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := producer.BeginTxn(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		producer.Input() <- &ProducerMessage{Topic: "my_topic", Value: StringEncoder(TestMessage)}
+	}
+	expectResults(t, producer, 10, 0)
+	if err := producer.CommitTxn(); err != nil {
+		t.Fatal(err)
+	}
+	closeProducer(t, producer)
+
+	var endTxns []bool
+	for _, rr := range leader.History() {
+		if request, ok := rr.Request.(*EndTxnRequest); ok {
+			endTxns = append(endTxns, request.Commit)
+		}
+	}
+	if !reflect.DeepEqual(endTxns, []bool{false, true}) {
+		t.Error("Expected an abort then a commit, got", endTxns)
+	}
+	batches := producedBatches(t, leader)
+	if len(batches) != 2 {
+		t.Fatal("Expected 2 produce requests, got", len(batches))
+	}
+	if batches[1].ProducerEpoch != 2 || batches[1].FirstSequence != 0 {
+		t.Error("Expected the sequence to start again in epoch 2, got",
+			batches[1].ProducerEpoch, batches[1].FirstSequence)
+	}
+
+	leader.Close()
+	seedBroker.Close()
+}
+
 func TestAsyncProducerOutOfRetries(t *testing.T) {
 	t.Skip("Enable once bug #294 is fixed.")
 
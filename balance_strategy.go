@@ -0,0 +1,365 @@
+package sarama
+
+import "sort"
+
+// BalanceStrategyPlan is the result of a BalanceStrategy, the partitions of each topic assigned to
+// each member of a consumer group, by member ID.
+type BalanceStrategyPlan map[string]map[string][]int32
+
+// Add assigns partitions of a topic to a member.
+func (p BalanceStrategyPlan) Add(memberID, topic string, partitions ...int32) {
+	if len(partitions) == 0 {
+		return
+	}
+	if _, ok := p[memberID]; !ok {
+		p[memberID] = make(map[string][]int32, 1)
+	}
+	p[memberID][topic] = append(p[memberID][topic], partitions...)
+}
+
+// BalanceStrategy is used by the member of a consumer group elected as the leader to assign the
+// partitions of the topics the members subscribe to among them. BalanceStrategyRange,
+// BalanceStrategyRoundRobin and BalanceStrategySticky are provided, and are compatible with the
+// assignors of the same name of the Java client.
+type BalanceStrategy interface {
+	// Name uniquely identifies the strategy, every member of a group must use the same one.
+	Name() string
+
+	// Plan takes the metadata of each member, by member ID, and the partitions of each topic
+	// they subscribe to, and returns the partitions assigned to each member.
+	Plan(members map[string]ConsumerGroupMemberMetadata, topics map[string][]int32) (BalanceStrategyPlan, error)
+
+	// AssignmentData returns the user data a member sends along with its metadata when it next
+	// joins the group, given the partitions it was assigned in a generation. It may return nil.
+	AssignmentData(memberID string, topics map[string][]int32, generationID int32) ([]byte, error)
+}
+
+var (
+	// BalanceStrategyRange assigns each member a contiguous range of the partitions of each topic
+	// it subscribes to, the first members (sorted by member ID) getting one more partition than the
+	// others when they do not divide evenly. Example with topic T of six partitions (0..5) and two
+	// members (M1, M2):
+	//   M1: {T: [0, 1, 2]}
+	//   M2: {T: [3, 4, 5]}
+	BalanceStrategyRange BalanceStrategy = &rangeBalanceStrategy{}
+
+	// BalanceStrategyRoundRobin deals the partitions of every topic (sorted by topic, then
+	// partition) to the members (sorted by member ID) in turn, skipping members not subscribed to
+	// the topic. Example with topics T1 and T2 of three partitions each and two members:
+	//   M1: {T1: [0, 2], T2: [1]}
+	//   M2: {T1: [1], T2: [0, 2]}
+	BalanceStrategyRoundRobin BalanceStrategy = &roundRobinBalanceStrategy{}
+
+	// BalanceStrategySticky assigns partitions as evenly as the subscriptions allow, while keeping
+	// as many partitions as possible with the members that were assigned them in the previous
+	// generation, which each member remembers in the user data of its metadata.
+	BalanceStrategySticky BalanceStrategy = &stickyBalanceStrategy{}
+)
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+type topicPartitionSlice []topicPartition
+
+func (s topicPartitionSlice) Len() int      { return len(s) }
+func (s topicPartitionSlice) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s topicPartitionSlice) Less(i, j int) bool {
+	if s[i].topic != s[j].topic {
+		return s[i].topic < s[j].topic
+	}
+	return s[i].partition < s[j].partition
+}
+
+// subscribers returns the members subscribed to each topic, sorted by member ID
+func subscribers(members map[string]ConsumerGroupMemberMetadata) map[string][]string {
+	subscribers := make(map[string][]string)
+	for memberID, meta := range members {
+		for _, topic := range meta.Topics {
+			subscribers[topic] = append(subscribers[topic], memberID)
+		}
+	}
+	for _, memberIDs := range subscribers {
+		sort.Strings(memberIDs)
+	}
+	return subscribers
+}
+
+// Range
+
+type rangeBalanceStrategy struct{}
+
+func (s *rangeBalanceStrategy) Name() string { return "range" }
+
+func (s *rangeBalanceStrategy) Plan(members map[string]ConsumerGroupMemberMetadata, topics map[string][]int32) (BalanceStrategyPlan, error) {
+	plan := make(BalanceStrategyPlan, len(members))
+	for topic, memberIDs := range subscribers(members) {
+		partitions := dupeAndSort(topics[topic])
+		n, extra := len(partitions)/len(memberIDs), len(partitions)%len(memberIDs)
+		start := 0
+		for i, memberID := range memberIDs {
+			length := n
+			if i < extra {
+				length++
+			}
+			plan.Add(memberID, topic, partitions[start:start+length]...)
+			start += length
+		}
+	}
+	return plan, nil
+}
+
+func (s *rangeBalanceStrategy) AssignmentData(memberID string, topics map[string][]int32, generationID int32) ([]byte, error) {
+	return nil, nil
+}
+
+// Round robin
+
+type roundRobinBalanceStrategy struct{}
+
+func (s *roundRobinBalanceStrategy) Name() string { return "roundrobin" }
+
+func (s *roundRobinBalanceStrategy) Plan(members map[string]ConsumerGroupMemberMetadata, topics map[string][]int32) (BalanceStrategyPlan, error) {
+	subscribed := subscribers(members)
+
+	var partitions topicPartitionSlice
+	for topic := range subscribed {
+		for _, partition := range topics[topic] {
+			partitions = append(partitions, topicPartition{topic, partition})
+		}
+	}
+	sort.Sort(partitions)
+
+	memberIDs := make([]string, 0, len(members))
+	for memberID := range members {
+		memberIDs = append(memberIDs, memberID)
+	}
+	sort.Strings(memberIDs)
+
+	plan := make(BalanceStrategyPlan, len(members))
+	next := 0
+	for _, tp := range partitions {
+		// every topic has at least one subscriber, so this always finds one
+		for !containsString(subscribed[tp.topic], memberIDs[next%len(memberIDs)]) {
+			next++
+		}
+		plan.Add(memberIDs[next%len(memberIDs)], tp.topic, tp.partition)
+		next++
+	}
+	return plan, nil
+}
+
+func (s *roundRobinBalanceStrategy) AssignmentData(memberID string, topics map[string][]int32, generationID int32) ([]byte, error) {
+	return nil, nil
+}
+
+// Sticky
+
+// StickyAssignorUserData is the user data of the "sticky" strategy, the partitions a member was
+// assigned in the previous generation. Generation is GroupGenerationUndefined when decoded from the
+// first version of the layout, which does not carry it.
+type StickyAssignorUserData struct {
+	Topics     map[string][]int32
+	Generation int32
+}
+
+func (m *StickyAssignorUserData) Encode(pe packetEncoder) error {
+	if err := pe.putArrayLength(len(m.Topics)); err != nil {
+		return err
+	}
+	for topic, partitions := range m.Topics {
+		if err := pe.putString(topic); err != nil {
+			return err
+		}
+		if err := pe.putInt32Array(partitions); err != nil {
+			return err
+		}
+	}
+
+	pe.putInt32(m.Generation)
+	return nil
+}
+
+func (m *StickyAssignorUserData) Decode(pd packetDecoder) (err error) {
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	m.Topics = make(map[string][]int32, n)
+	for i := 0; i < n; i++ {
+		topic, err := pd.getString()
+		if err != nil {
+			return err
+		}
+		if m.Topics[topic], err = pd.getInt32Array(); err != nil {
+			return err
+		}
+	}
+
+	if pd.remaining() == 0 {
+		m.Generation = GroupGenerationUndefined
+		return nil
+	}
+	m.Generation, err = pd.getInt32()
+	return err
+}
+
+type stickyBalanceStrategy struct{}
+
+func (s *stickyBalanceStrategy) Name() string { return "sticky" }
+
+func (s *stickyBalanceStrategy) Plan(members map[string]ConsumerGroupMemberMetadata, topics map[string][]int32) (BalanceStrategyPlan, error) {
+	subscribed := make(map[string]map[string]bool, len(members))
+	assignments := make(map[string]topicPartitionSlice, len(members))
+	for memberID, meta := range members {
+		subscribed[memberID] = make(map[string]bool, len(meta.Topics))
+		for _, topic := range meta.Topics {
+			subscribed[memberID][topic] = true
+		}
+		assignments[memberID] = nil
+	}
+
+	previous, err := s.previousOwners(members)
+	if err != nil {
+		return nil, err
+	}
+
+	var partitions topicPartitionSlice
+	for topic := range subscribers(members) {
+		for _, partition := range topics[topic] {
+			partitions = append(partitions, topicPartition{topic, partition})
+		}
+	}
+	sort.Sort(partitions)
+
+	// partitions stay with their previous owner when it still subscribes to them, the others go
+	// to the least loaded subscriber
+	var unassigned topicPartitionSlice
+	for _, tp := range partitions {
+		if owner, ok := previous[tp]; ok && subscribed[owner][tp.topic] {
+			assignments[owner] = append(assignments[owner], tp)
+		} else {
+			unassigned = append(unassigned, tp)
+		}
+	}
+	for _, tp := range unassigned {
+		if memberID := s.leastLoaded(assignments, subscribed, tp.topic, -1); memberID != "" {
+			assignments[memberID] = append(assignments[memberID], tp)
+		}
+	}
+
+	// then move partitions from the most to the least loaded members until no member has two
+	// partitions more than another member that could take one of them; each move makes the
+	// assignment strictly more even, so this terminates
+	for s.rebalanceOne(assignments, subscribed) {
+	}
+
+	plan := make(BalanceStrategyPlan, len(members))
+	for memberID, tps := range assignments {
+		sort.Sort(tps)
+		for _, tp := range tps {
+			plan.Add(memberID, tp.topic, tp.partition)
+		}
+	}
+	return plan, nil
+}
+
+func (s *stickyBalanceStrategy) AssignmentData(memberID string, topics map[string][]int32, generationID int32) ([]byte, error) {
+	return Encode(&StickyAssignorUserData{Topics: topics, Generation: generationID})
+}
+
+// previousOwners decodes the previous assignment of each member. A partition claimed by several
+// members goes to the one claiming it for the latest generation, or to the lowest member ID.
+func (s *stickyBalanceStrategy) previousOwners(members map[string]ConsumerGroupMemberMetadata) (map[topicPartition]string, error) {
+	owners := make(map[topicPartition]string)
+	generations := make(map[topicPartition]int32)
+	for memberID, meta := range members {
+		if len(meta.UserData) == 0 {
+			continue
+		}
+		data := new(StickyAssignorUserData)
+		if err := Decode(meta.UserData, data); err != nil {
+			return nil, err
+		}
+
+		for topic, partitions := range data.Topics {
+			for _, partition := range partitions {
+				tp := topicPartition{topic, partition}
+				if owner, ok := owners[tp]; ok {
+					if generations[tp] > data.Generation || generations[tp] == data.Generation && owner < memberID {
+						continue
+					}
+				}
+				owners[tp] = memberID
+				generations[tp] = data.Generation
+			}
+		}
+	}
+	return owners, nil
+}
+
+// leastLoaded returns the member subscribed to the topic with the fewest partitions, provided it has
+// fewer than max (unless max is negative), or "" if there is none
+func (s *stickyBalanceStrategy) leastLoaded(assignments map[string]topicPartitionSlice, subscribed map[string]map[string]bool, topic string, max int) string {
+	var found string
+	for memberID, tps := range assignments {
+		if !subscribed[memberID][topic] || max >= 0 && len(tps) >= max {
+			continue
+		}
+		if found == "" || len(tps) < len(assignments[found]) || len(tps) == len(assignments[found]) && memberID < found {
+			found = memberID
+		}
+	}
+	return found
+}
+
+// rebalanceOne moves a partition from the most loaded member that can give one away to a member
+// with at least two partitions fewer, and reports whether it found one to move.
+func (s *stickyBalanceStrategy) rebalanceOne(assignments map[string]topicPartitionSlice, subscribed map[string]map[string]bool) bool {
+	memberIDs := make([]string, 0, len(assignments))
+	for memberID := range assignments {
+		memberIDs = append(memberIDs, memberID)
+	}
+	sort.Sort(byLoad{memberIDs, assignments})
+
+	for _, from := range memberIDs {
+		tps := assignments[from]
+		// give away the partitions most recently added first
+		for i := len(tps) - 1; i >= 0; i-- {
+			to := s.leastLoaded(assignments, subscribed, tps[i].topic, len(tps)-1)
+			if to == "" {
+				continue
+			}
+			assignments[to] = append(assignments[to], tps[i])
+			assignments[from] = append(tps[:i:i], tps[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// byLoad sorts member IDs by decreasing number of partitions, then by member ID
+type byLoad struct {
+	memberIDs   []string
+	assignments map[string]topicPartitionSlice
+}
+
+func (s byLoad) Len() int      { return len(s.memberIDs) }
+func (s byLoad) Swap(i, j int) { s.memberIDs[i], s.memberIDs[j] = s.memberIDs[j], s.memberIDs[i] }
+func (s byLoad) Less(i, j int) bool {
+	li, lj := len(s.assignments[s.memberIDs[i]]), len(s.assignments[s.memberIDs[j]])
+	if li != lj {
+		return li > lj
+	}
+	return s.memberIDs[i] < s.memberIDs[j]
+}
+
+func containsString(s []string, str string) bool {
+	for _, v := range s {
+		if v == str {
+			return true
+		}
+	}
+	return false
+}
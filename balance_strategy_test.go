@@ -0,0 +1,253 @@
+package sarama
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBalanceStrategyRange(t *testing.T) {
+	tests := []struct {
+		members  map[string][]string
+		topics   map[string][]int32
+		expected BalanceStrategyPlan
+	}{
+		{
+			members: map[string][]string{"M1": {"T1", "T2"}, "M2": {"T1", "T2"}},
+			topics:  map[string][]int32{"T1": {0, 1, 2, 3}, "T2": {0, 1, 2, 3}},
+			expected: BalanceStrategyPlan{
+				"M1": map[string][]int32{"T1": {0, 1}, "T2": {0, 1}},
+				"M2": map[string][]int32{"T1": {2, 3}, "T2": {2, 3}},
+			},
+		},
+		{
+			members: map[string][]string{"M1": {"T1", "T2"}, "M2": {"T1"}},
+			topics:  map[string][]int32{"T1": {2, 1, 0}, "T2": {0, 1}},
+			expected: BalanceStrategyPlan{
+				"M1": map[string][]int32{"T1": {0, 1}, "T2": {0, 1}},
+				"M2": map[string][]int32{"T1": {2}},
+			},
+		},
+		{
+			members: map[string][]string{"M1": {"T1"}, "M2": {"T1"}, "M3": {"T1"}},
+			topics:  map[string][]int32{"T1": {0}},
+			expected: BalanceStrategyPlan{
+				"M1": map[string][]int32{"T1": {0}},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		plan, err := BalanceStrategyRange.Plan(newBalanceStrategyTestMembers(test.members, nil), test.topics)
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+		} else if !reflect.DeepEqual(plan, test.expected) {
+			t.Errorf("#%d: expected %v, got %v", i, test.expected, plan)
+		}
+	}
+}
+
+func TestBalanceStrategyRoundRobin(t *testing.T) {
+	tests := []struct {
+		members  map[string][]string
+		topics   map[string][]int32
+		expected BalanceStrategyPlan
+	}{
+		{
+			members: map[string][]string{"M1": {"T1", "T2"}, "M2": {"T1", "T2"}},
+			topics:  map[string][]int32{"T1": {0, 1, 2}, "T2": {0, 1, 2}},
+			expected: BalanceStrategyPlan{
+				"M1": map[string][]int32{"T1": {0, 2}, "T2": {1}},
+				"M2": map[string][]int32{"T1": {1}, "T2": {0, 2}},
+			},
+		},
+		{
+			members: map[string][]string{"M1": {"T1"}, "M2": {"T1", "T2"}, "M3": {"T1"}},
+			topics:  map[string][]int32{"T1": {0, 1, 2}, "T2": {0, 1}},
+			expected: BalanceStrategyPlan{
+				"M1": map[string][]int32{"T1": {0}},
+				"M2": map[string][]int32{"T1": {1}, "T2": {0, 1}},
+				"M3": map[string][]int32{"T1": {2}},
+			},
+		},
+	}
+
+	for i, test := range tests {
+		plan, err := BalanceStrategyRoundRobin.Plan(newBalanceStrategyTestMembers(test.members, nil), test.topics)
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+		} else if !reflect.DeepEqual(plan, test.expected) {
+			t.Errorf("#%d: expected %v, got %v", i, test.expected, plan)
+		}
+	}
+}
+
+func TestBalanceStrategySticky(t *testing.T) {
+	topics := map[string][]int32{"T1": {0, 1, 2, 3, 4, 5}}
+
+	// no previous assignment: balanced
+	plan, err := BalanceStrategySticky.Plan(newBalanceStrategyTestMembers(map[string][]string{
+		"M1": {"T1"}, "M2": {"T1"},
+	}, nil), topics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := BalanceStrategyPlan{
+		"M1": map[string][]int32{"T1": {0, 2, 4}},
+		"M2": map[string][]int32{"T1": {1, 3, 5}},
+	}
+	if !reflect.DeepEqual(plan, expected) {
+		t.Fatalf("Expected %v, got %v", expected, plan)
+	}
+
+	// a member joins: it only takes over partitions until it is balanced, the others keep theirs
+	plan, err = BalanceStrategySticky.Plan(newBalanceStrategyTestMembers(map[string][]string{
+		"M1": {"T1"}, "M2": {"T1"}, "M3": {"T1"},
+	}, map[string]*StickyAssignorUserData{
+		"M1": {Topics: map[string][]int32{"T1": {0, 2, 4}}, Generation: 1},
+		"M2": {Topics: map[string][]int32{"T1": {1, 3, 5}}, Generation: 1},
+	}), topics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertBalanceStrategyStickyPlan(t, plan, topics, map[string]int{"M1": 2, "M2": 2, "M3": 2})
+	for _, memberID := range []string{"M1", "M2"} {
+		for _, partition := range plan[memberID]["T1"] {
+			if partition%2 != map[string]int32{"M1": 0, "M2": 1}[memberID] {
+				t.Errorf("%s was given partition %d it did not own before", memberID, partition)
+			}
+		}
+	}
+
+	// a member leaves: only its partitions move
+	plan, err = BalanceStrategySticky.Plan(newBalanceStrategyTestMembers(map[string][]string{
+		"M1": {"T1"}, "M3": {"T1"},
+	}, map[string]*StickyAssignorUserData{
+		"M1": {Topics: map[string][]int32{"T1": {0, 2}}, Generation: 2},
+		"M3": {Topics: map[string][]int32{"T1": {4, 5}}, Generation: 2},
+	}), topics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertBalanceStrategyStickyPlan(t, plan, topics, map[string]int{"M1": 3, "M3": 3})
+	if !containsInt32s(plan["M1"]["T1"], 0, 2) || !containsInt32s(plan["M3"]["T1"], 4, 5) {
+		t.Error("Partitions moved between remaining members", plan)
+	}
+
+	// a partition claimed twice stays with the member claiming it for the latest generation
+	plan, err = BalanceStrategySticky.Plan(newBalanceStrategyTestMembers(map[string][]string{
+		"M1": {"T1"}, "M2": {"T1"},
+	}, map[string]*StickyAssignorUserData{
+		"M1": {Topics: map[string][]int32{"T1": {0, 1, 2}}, Generation: 3},
+		"M2": {Topics: map[string][]int32{"T1": {2, 3, 4, 5}}, Generation: 2},
+	}), topics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = BalanceStrategyPlan{
+		"M1": map[string][]int32{"T1": {0, 1, 2}},
+		"M2": map[string][]int32{"T1": {3, 4, 5}},
+	}
+	if !reflect.DeepEqual(plan, expected) {
+		t.Errorf("Expected %v, got %v", expected, plan)
+	}
+}
+
+func TestBalanceStrategyStickyUnevenSubscriptions(t *testing.T) {
+	topics := map[string][]int32{"T1": {0, 1, 2}, "T2": {0, 1, 2}}
+	plan, err := BalanceStrategySticky.Plan(newBalanceStrategyTestMembers(map[string][]string{
+		"M1": {"T1", "T2"}, "M2": {"T2"},
+	}, map[string]*StickyAssignorUserData{
+		"M1": {Topics: map[string][]int32{"T1": {0, 1, 2}, "T2": {0, 1, 2}}, Generation: 1},
+	}), topics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertBalanceStrategyStickyPlan(t, plan, topics, map[string]int{"M1": 3, "M2": 3})
+	if len(plan["M1"]["T1"]) != 3 {
+		t.Error("Expected M1 to keep all of T1, the only member subscribed to it", plan)
+	}
+}
+
+func TestStickyAssignorUserData(t *testing.T) {
+	data := &StickyAssignorUserData{Topics: map[string][]int32{"t": {0, 1}}, Generation: 3}
+	encoded, err := BalanceStrategySticky.AssignmentData("m", data.Topics, data.Generation)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{
+		0x00, 0x00, 0x00, 0x01, // 1 topic
+		0x00, 0x01, 't',
+		0x00, 0x00, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x00, 0x03} // generation
+	if !reflect.DeepEqual(encoded, expected) {
+		t.Errorf("Encoding failed\ngot  %v\nwant %v", encoded, expected)
+	}
+
+	decoded := new(StickyAssignorUserData)
+	testDecodable(t, "v1", decoded, encoded)
+	if !reflect.DeepEqual(decoded, data) {
+		t.Error("Decoding failed, got", decoded)
+	}
+
+	// the first version of the layout has no generation
+	decoded = new(StickyAssignorUserData)
+	testDecodable(t, "v0", decoded, encoded[:len(encoded)-4])
+	if decoded.Generation != GroupGenerationUndefined || !reflect.DeepEqual(decoded.Topics, data.Topics) {
+		t.Error("Decoding failed, got", decoded)
+	}
+
+	if data, err := BalanceStrategyRange.AssignmentData("m", data.Topics, 3); data != nil || err != nil {
+		t.Error("Expected no user data for the range strategy, got", data, err)
+	}
+}
+
+func newBalanceStrategyTestMembers(subscriptions map[string][]string, userData map[string]*StickyAssignorUserData) map[string]ConsumerGroupMemberMetadata {
+	members := make(map[string]ConsumerGroupMemberMetadata, len(subscriptions))
+	for memberID, topics := range subscriptions {
+		meta := ConsumerGroupMemberMetadata{Topics: topics}
+		if data := userData[memberID]; data != nil {
+			meta.UserData, _ = Encode(data)
+		}
+		members[memberID] = meta
+	}
+	return members
+}
+
+// assertBalanceStrategyStickyPlan checks that every partition is assigned exactly once, and the
+// number of partitions of each member
+func assertBalanceStrategyStickyPlan(t *testing.T, plan BalanceStrategyPlan, topics map[string][]int32, counts map[string]int) {
+	seen := make(map[topicPartition]bool)
+	for memberID, assignment := range plan {
+		n := 0
+		for topic, partitions := range assignment {
+			for _, partition := range partitions {
+				tp := topicPartition{topic, partition}
+				if seen[tp] {
+					t.Error("Partition assigned twice", tp)
+				}
+				seen[tp] = true
+				n++
+			}
+		}
+		if n != counts[memberID] {
+			t.Errorf("Expected %s to have %d partitions, got %d", memberID, counts[memberID], n)
+		}
+	}
+	for topic, partitions := range topics {
+		for _, partition := range partitions {
+			if !seen[topicPartition{topic, partition}] {
+				t.Errorf("Partition %s/%d not assigned", topic, partition)
+			}
+		}
+	}
+}
+
+func containsInt32s(partitions []int32, want ...int32) bool {
+	for _, w := range want {
+		if !containsPartition(partitions, w) {
+			return false
+		}
+	}
+	return true
+}
@@ -2,9 +2,13 @@ package sarama
 
 import (
 	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand"
 	"net"
+	"sort"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -13,8 +17,9 @@ import (
 
 // Broker represents a single Kafka broker connection. All operations on this object are entirely concurrency-safe.
 type Broker struct {
-	id   int32
+	id    int32
 	IAddr string
+	rack  *string
 
 	conf          *Config
 	correlationID int32
@@ -22,6 +27,8 @@ type Broker struct {
 	connErr       error
 	lock          sync.Mutex
 	opened        int32
+	apiVersions   map[int16]*ApiVersionsResponseBlock // nil when the broker has not told us
+	reauthAt      time.Time                           // zero unless the SASL session expires
 
 	responses chan ResponsePromise
 	done      chan bool
@@ -31,6 +38,44 @@ type ResponsePromise struct {
 	CorrelationID int32
 	Packets       chan []byte
 	Errors        chan error
+
+	timeout time.Duration // on top of Net.ReadTimeout, see blockingRequest
+}
+
+// SASLMechanism is the name of a SASL mechanism, as sent in the SASL handshake.
+type SASLMechanism string
+
+const (
+	// SASLTypePlaintext sends the user and password in the clear (use TLS as well to protect them).
+	SASLTypePlaintext SASLMechanism = "PLAIN"
+	// SASLTypeSCRAMSHA256 proves knowledge of the password without sending it, and has the broker
+	// prove the same in return.
+	SASLTypeSCRAMSHA256 SASLMechanism = "SCRAM-SHA-256"
+	// SASLTypeSCRAMSHA512 is SASLTypeSCRAMSHA256 with SHA-512 as the hash function.
+	SASLTypeSCRAMSHA512 SASLMechanism = "SCRAM-SHA-512"
+	// SASLTypeOAuth sends a bearer token from Net.SASL.TokenProvider instead of a user and password.
+	SASLTypeOAuth SASLMechanism = "OAUTHBEARER"
+)
+
+// AccessToken is a bearer token for SASL/OAUTHBEARER authentication, such as a JWT, along with any
+// SASL extensions the broker expects alongside it.
+type AccessToken struct {
+	Token      string
+	Extensions map[string]string
+}
+
+// AccessTokenProvider supplies the tokens for SASL/OAUTHBEARER authentication. Token is called each
+// time a connection authenticates, including when it re-authenticates because its session is about
+// to expire, so it should return a token that is still valid for a while, refreshing it if needed.
+// It may be called concurrently for different brokers.
+type AccessTokenProvider interface {
+	Token() (*AccessToken, error)
+}
+
+// blockingRequest is implemented by requests that the broker deliberately holds on to before
+// responding, for up to the returned duration, so that waiting for their response does not time out.
+type blockingRequest interface {
+	responseTimeout() time.Duration
 }
 
 // NewBroker creates and returns a Broker targetting the given host:port address.
@@ -69,16 +114,7 @@ func (b *Broker) Open(conf *Config) error {
 	go withRecover(func() {
 		defer b.lock.Unlock()
 
-		dialer := net.Dialer{
-			Timeout:   conf.Net.DialTimeout,
-			KeepAlive: conf.Net.KeepAlive,
-		}
-
-		if conf.Net.TLS.Enable {
-			b.conn, b.connErr = tls.DialWithDialer(&dialer, "tcp", b.IAddr, conf.Net.TLS.Config)
-		} else {
-			b.conn, b.connErr = dialer.Dial("tcp", b.IAddr)
-		}
+		b.conn, b.connErr = b.dial(conf)
 		if b.connErr != nil {
 			b.conn = nil
 			atomic.StoreInt32(&b.opened, 0)
@@ -87,6 +123,30 @@ func (b *Broker) Open(conf *Config) error {
 		}
 
 		b.conf = conf
+		b.apiVersions = nil
+		b.reauthAt = time.Time{}
+
+		if conf.ApiVersionsRequest {
+			b.connErr = b.requestApiVersions()
+			if b.connErr != nil {
+				b.conn = nil
+				atomic.StoreInt32(&b.opened, 0)
+				Logger.Printf("Failed to connect to broker %s: %s\n", b.IAddr, b.connErr)
+				return
+			}
+		}
+
+		if conf.Net.SASL.Enable {
+			b.connErr = b.authenticateSASL()
+			if b.connErr != nil {
+				_ = b.conn.Close() // the broker may well have closed it already
+				b.conn = nil
+				atomic.StoreInt32(&b.opened, 0)
+				Logger.Printf("Failed to authenticate with broker %s: %s\n", b.IAddr, b.connErr)
+				return
+			}
+		}
+
 		b.done = make(chan bool)
 		b.responses = make(chan ResponsePromise, b.conf.Net.MaxOpenRequests-1)
 
@@ -118,24 +178,32 @@ func (b *Broker) Close() error {
 		return ErrNotConnected
 	}
 
+	err := b.closeConnection()
+	b.connErr = nil
+
+	if err == nil {
+		Logger.Printf("Closed connection to broker %s\n", b.IAddr)
+	} else {
+		Logger.Printf("Error while closing connection to broker %s: %s\n", b.IAddr, err)
+	}
+
+	return err
+}
+
+// closeConnection stops the response receiver and closes the connection of an opened broker, so
+// that it may be opened again. It is called with b.lock held.
+func (b *Broker) closeConnection() error {
 	close(b.responses)
 	<-b.done
 
 	err := b.conn.Close()
 
 	b.conn = nil
-	b.connErr = nil
 	b.done = nil
 	b.responses = nil
 
 	atomic.StoreInt32(&b.opened, 0)
 
-	if err == nil {
-		Logger.Printf("Closed connection to broker %s\n", b.IAddr)
-	} else {
-		Logger.Printf("Error while closing connection to broker %s: %s\n", b.IAddr, err)
-	}
-
 	return err
 }
 
@@ -153,8 +221,363 @@ func (b *Broker) Addr() string {
 	return b.IAddr
 }
 
+// Rack returns the rack of the broker as retrieved from Kafka's metadata, or the empty string if
+// the broker has no rack or the metadata did not include it.
+func (b *Broker) Rack() string {
+	if b.rack == nil {
+		return ""
+	}
+	return *b.rack
+}
+
+// MarshalJSON gives the JSON view of a broker in protocol messages such as MetadataResponse, since
+// its ID and rack are not exported fields.
+func (b *Broker) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		ID   int32
+		Addr string
+		Rack string
+	}{b.ID(), b.Addr(), b.Rack()})
+}
+
+func (b *Broker) dial(conf *Config) (net.Conn, error) {
+	dialer := net.Dialer{
+		Timeout:   conf.Net.DialTimeout,
+		KeepAlive: conf.Net.KeepAlive,
+	}
+
+	if conf.Net.TLS.Enable {
+		return tls.DialWithDialer(&dialer, "tcp", b.IAddr, conf.Net.TLS.Config)
+	}
+	return dialer.Dial("tcp", b.IAddr)
+}
+
+// requestApiVersions asks a freshly connected broker which versions of each request it supports.
+// Brokers older than Kafka 0.10 do not know the request and drop the connection, in which case we
+// reconnect and carry on without any version information.
+func (b *Broker) requestApiVersions() error {
+	response := new(ApiVersionsResponse)
+	err := b.syncSendAndReceive(&ApiVersionsRequest{}, response)
+
+	if err == nil {
+		if response.Err != ErrNoError {
+			Logger.Printf("Broker %s rejected ApiVersionsRequest: %s\n", b.IAddr, response.Err)
+			return nil
+		}
+		b.apiVersions = make(map[int16]*ApiVersionsResponseBlock, len(response.ApiVersions))
+		for _, block := range response.ApiVersions {
+			b.apiVersions[block.ApiKey] = block
+		}
+		return nil
+	}
+
+	Logger.Printf("Broker %s did not answer ApiVersionsRequest (%s), reconnecting\n", b.IAddr, err)
+	_ = b.conn.Close()
+	b.conn, err = b.dial(b.conf)
+	return err
+}
+
+// authenticateSASL authenticates the connection with the mechanism of Net.SASL. It is called with
+// b.lock held, from Open for a new connection and from send to re-authenticate (see reauthenticate).
+func (b *Broker) authenticateSASL() error {
+	mechanism := b.conf.Net.SASL.Mechanism
+	b.reauthAt = time.Time{}
+	handshakeVersion := int16(0)
+	if b.conf.Net.SASL.Handshake {
+		handshakeVersion = b.saslVersion(17)
+		if err := b.sendAndReceiveSASLHandshake(mechanism, handshakeVersion); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	switch mechanism {
+	case SASLTypeSCRAMSHA256, SASLTypeSCRAMSHA512:
+		err = b.sendAndReceiveSASLSCRAMAuth(handshakeVersion)
+	case SASLTypeOAuth:
+		err = b.sendAndReceiveSASLOAuth(handshakeVersion)
+	default:
+		err = b.sendAndReceiveSASLPlainAuth(handshakeVersion)
+	}
+	if err != nil {
+		return err
+	}
+
+	Logger.Printf("SASL authentication successful with broker %s\n", b.IAddr)
+	return nil
+}
+
+// saslVersion returns the version of the SASL request with the given key to use while opening the
+// connection, like requestVersion but without taking b.lock. Brokers are still sent version 0 if
+// Config.Version predates the request, since it is often left at its default when enabling SASL.
+func (b *Broker) saslVersion(key int16) int16 {
+	max := maxRequestVersion(key, b.conf.Version)
+	if max <= 0 {
+		return 0
+	}
+	if !b.conf.ApiVersionsRequest {
+		return max
+	}
+	version, err := b.supportedVersion(key, 0, max)
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+func (b *Broker) sendAndReceiveSASLPlainAuth(handshakeVersion int16) error {
+	_, err := b.sendAndReceiveSASLToken([]byte("\x00"+b.conf.Net.SASL.User+"\x00"+b.conf.Net.SASL.Password), handshakeVersion)
+	return err
+}
+
+// sendAndReceiveSASLSCRAMAuth steps a SCRAMClient through the exchange with the broker. The client
+// fails the exchange if the broker cannot prove in its final message that it knows the password.
+func (b *Broker) sendAndReceiveSASLSCRAMAuth(handshakeVersion int16) error {
+	var scramClient SCRAMClient
+	if b.conf.Net.SASL.SCRAMClientGeneratorFunc != nil {
+		scramClient = b.conf.Net.SASL.SCRAMClientGeneratorFunc()
+	} else {
+		scramClient = newSCRAMClient(b.conf.Net.SASL.Mechanism)
+	}
+
+	if err := scramClient.Begin(b.conf.Net.SASL.User, b.conf.Net.SASL.Password, ""); err != nil {
+		return err
+	}
+
+	msg, err := scramClient.Step("")
+	if err != nil {
+		return err
+	}
+	for !scramClient.Done() {
+		challenge, err := b.sendAndReceiveSASLToken([]byte(msg), handshakeVersion)
+		if err != nil {
+			return err
+		}
+		if msg, err = scramClient.Step(string(challenge)); err != nil {
+			Logger.Printf("SCRAM exchange with broker %s failed: %s\n", b.IAddr, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sendAndReceiveSASLOAuth sends the token of Net.SASL.TokenProvider. Brokers accept it with an empty
+// answer; otherwise they answer with a JSON error description, to which the client must reply with
+// a single 0x01 byte before the broker fails the authentication.
+func (b *Broker) sendAndReceiveSASLOAuth(handshakeVersion int16) error {
+	token, err := b.conf.Net.SASL.TokenProvider.Token()
+	if err != nil {
+		Logger.Printf("Failed to get a SASL/OAUTHBEARER token for broker %s: %s\n", b.IAddr, err)
+		return err
+	}
+
+	msg, err := oauthBearerMessage(token)
+	if err != nil {
+		return err
+	}
+
+	challenge, err := b.sendAndReceiveSASLToken(msg, handshakeVersion)
+	if err != nil {
+		return err
+	}
+	if len(challenge) == 0 {
+		return nil
+	}
+
+	Logger.Printf("Broker %s rejected SASL/OAUTHBEARER token: %s\n", b.IAddr, challenge)
+	if _, err := b.sendAndReceiveSASLToken([]byte{0x01}, handshakeVersion); err != nil {
+		return err
+	}
+	return ErrSASLAuthenticationFailed
+}
+
+// oauthBearerMessage builds the initial client response of RFC 7628, with the extensions sorted by
+// key.
+func oauthBearerMessage(token *AccessToken) ([]byte, error) {
+	keys := make([]string, 0, len(token.Extensions))
+	for key := range token.Extensions {
+		if key == "auth" {
+			return nil, ConfigurationError("the SASL/OAUTHBEARER extension name \"auth\" is reserved")
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	msg := "n,,\x01auth=Bearer " + token.Token
+	for _, key := range keys {
+		msg += "\x01" + key + "=" + token.Extensions[key]
+	}
+	return []byte(msg + "\x01\x01"), nil
+}
+
+// sendAndReceiveSASLToken sends one step of authentication bytes and returns the broker's answer.
+// After a version 1 handshake they are wrapped in SaslAuthenticateRequests. After a version 0
+// handshake, or with no handshake at all, brokers expect them on their own with only a length
+// prefix, and answer them the same way; if authentication fails they close the connection instead.
+func (b *Broker) sendAndReceiveSASLToken(token []byte, handshakeVersion int16) ([]byte, error) {
+	if handshakeVersion >= 1 {
+		request := &SaslAuthenticateRequest{SaslAuthBytes: token, IVersion: b.saslVersion(36)}
+		response := &SaslAuthenticateResponse{IVersion: request.IVersion}
+		if err := b.saslSendAndReceive(request, response); err != nil {
+			Logger.Printf("Failed to send SASL authentication bytes to broker %s: %s\n", b.IAddr, err)
+			return nil, err
+		}
+		if response.Err != ErrNoError {
+			if response.ErrMsg != nil {
+				Logger.Printf("Broker %s rejected SASL authentication: %s\n", b.IAddr, *response.ErrMsg)
+			}
+			return nil, response.Err
+		}
+		if response.SessionLifetime > 0 {
+			// like the Java client, re-authenticate at a random point between 85% and 95% of the
+			// session lifetime, so that connections opened together do not all do so at once
+			b.reauthAt = time.Now().Add(time.Duration(float64(response.SessionLifetime) * (0.85 + 0.1*rand.Float64())))
+		}
+		return response.SaslAuthBytes, nil
+	}
+
+	authBytes := make([]byte, 4+len(token))
+	binary.BigEndian.PutUint32(authBytes, uint32(len(token)))
+	copy(authBytes[4:], token)
+
+	if err := b.conn.SetWriteDeadline(time.Now().Add(b.conf.Net.WriteTimeout)); err != nil {
+		return nil, err
+	}
+	if _, err := b.conn.Write(authBytes); err != nil {
+		Logger.Printf("Failed to write SASL auth header to broker %s: %s\n", b.IAddr, err)
+		return nil, err
+	}
+
+	if err := b.conn.SetReadDeadline(time.Now().Add(b.conf.Net.ReadTimeout)); err != nil {
+		return nil, err
+	}
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(b.conn, header); err != nil {
+		Logger.Printf("Failed to read SASL auth header from broker %s: %s\n", b.IAddr, err)
+		return nil, err
+	}
+	length := binary.BigEndian.Uint32(header)
+	if length > uint32(MaxResponseSize) {
+		// most likely not a Kafka broker, or one expecting TLS; don't allocate whatever it claims
+		return nil, PacketDecodingError{fmt.Sprintf("SASL auth response of length %d too large", length)}
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(b.conn, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+func (b *Broker) sendAndReceiveSASLHandshake(mechanism SASLMechanism, version int16) error {
+	request := &SaslHandshakeRequest{Mechanism: string(mechanism), IVersion: version}
+	response := new(SaslHandshakeResponse)
+	if err := b.saslSendAndReceive(request, response); err != nil {
+		Logger.Printf("Failed SASL handshake with broker %s: %s\n", b.IAddr, err)
+		return err
+	}
+	if response.Err != ErrNoError {
+		Logger.Printf("Broker %s rejected SASL handshake for %s (enabled mechanisms: %v): %s\n",
+			b.IAddr, mechanism, response.EnabledMechanisms, response.Err)
+		return response.Err
+	}
+
+	Logger.Printf("Successful SASL handshake with broker %s\n", b.IAddr)
+	return nil
+}
+
+// saslSendAndReceive sends a request of the SASL exchange and waits for its response. While Open
+// authenticates a new connection nothing else reads from it, but re-authentication happens on a live
+// connection, where the response arrives through responseReceiver after those already in flight.
+func (b *Broker) saslSendAndReceive(rb RequestBody, res Decoder) error {
+	if b.responses == nil {
+		return b.syncSendAndReceive(rb, res)
+	}
+
+	promise, err := b.sendInternal(rb, true)
+	if err != nil {
+		return err
+	}
+	select {
+	case buf := <-promise.Packets:
+		return Decode(buf, res)
+	case err = <-promise.Errors:
+		return err
+	}
+}
+
+// reauthenticate authenticates the connection again before its SASL session expires, as brokers
+// with connections.max.reauth.ms set (Kafka 2.2 and later) close connections that do not. It is
+// called with b.lock held before sending each request.
+func (b *Broker) reauthenticate() error {
+	if b.reauthAt.IsZero() || time.Now().Before(b.reauthAt) {
+		return nil
+	}
+
+	Logger.Printf("Re-authenticating with broker %s\n", b.IAddr)
+	if err := b.authenticateSASL(); err != nil {
+		Logger.Printf("Failed to re-authenticate with broker %s: %s\n", b.IAddr, err)
+		// the broker closes unauthenticated connections, so, as in Open, drop it for a fresh one
+		_ = b.closeConnection()
+		b.connErr = err
+		return err
+	}
+	return nil
+}
+
+// negotiateVersion returns the highest version of the request with the given key, between min and
+// max inclusive, that the broker reported supporting. When the broker's supported versions are not
+// known (Config.ApiVersionsRequest is disabled, or the broker predates Kafka 0.10) it returns min,
+// the most conservative version the caller is willing to use. It waits for any pending Open to
+// complete.
+func (b *Broker) negotiateVersion(key, min, max int16) (int16, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.supportedVersion(key, min, max)
+}
+
+// supportedVersion is negotiateVersion for callers already holding b.lock.
+func (b *Broker) supportedVersion(key, min, max int16) (int16, error) {
+	if b.apiVersions == nil {
+		return min, nil
+	}
+
+	supported := b.apiVersions[key]
+	if supported == nil || supported.MaxVersion < min || supported.MinVersion > max {
+		return -1, ErrUnsupportedVersion
+	}
+
+	if supported.MaxVersion < max {
+		return supported.MaxVersion, nil
+	}
+	return max, nil
+}
+
+// requestVersion returns the version of the request with the given key to send to this broker: the
+// highest that brokers running Config.Version understand, clamped to what the broker itself reported
+// supporting if Config.ApiVersionsRequest is enabled (see negotiateVersion). The caller will not
+// accept anything lower than min.
+func (b *Broker) requestVersion(key, min int16) (int16, error) {
+	b.lock.Lock()
+	kafkaVersion, negotiate := minVersion, false
+	if b.conf != nil {
+		kafkaVersion, negotiate = b.conf.Version, b.conf.ApiVersionsRequest
+	}
+	b.lock.Unlock()
+
+	max := maxRequestVersion(key, kafkaVersion)
+	if max < min {
+		return -1, ErrUnsupportedVersion
+	}
+	if !negotiate {
+		return max, nil
+	}
+	return b.negotiateVersion(key, min, max)
+}
+
 func (b *Broker) GetMetadata(request *MetadataRequest) (*MetadataResponse, error) {
-	response := new(MetadataResponse)
+	response := &MetadataResponse{IVersion: request.IVersion}
 
 	err := b.sendAndReceive(request, response)
 
@@ -166,7 +589,7 @@ func (b *Broker) GetMetadata(request *MetadataRequest) (*MetadataResponse, error
 }
 
 func (b *Broker) GetConsumerMetadata(request *ConsumerMetadataRequest) (*ConsumerMetadataResponse, error) {
-	response := new(ConsumerMetadataResponse)
+	response := &ConsumerMetadataResponse{IVersion: request.IVersion}
 
 	err := b.sendAndReceive(request, response)
 
@@ -178,7 +601,7 @@ func (b *Broker) GetConsumerMetadata(request *ConsumerMetadataRequest) (*Consume
 }
 
 func (b *Broker) GetAvailableOffsets(request *OffsetRequest) (*OffsetResponse, error) {
-	response := new(OffsetResponse)
+	response := &OffsetResponse{IVersion: request.IVersion}
 
 	err := b.sendAndReceive(request, response)
 
@@ -196,7 +619,7 @@ func (b *Broker) Produce(request *ProduceRequest) (*ProduceResponse, error) {
 	if request.RequiredAcks == NoResponse {
 		err = b.sendAndReceive(request, nil)
 	} else {
-		response = new(ProduceResponse)
+		response = &ProduceResponse{IVersion: request.IVersion}
 		err = b.sendAndReceive(request, response)
 	}
 
@@ -208,7 +631,7 @@ func (b *Broker) Produce(request *ProduceRequest) (*ProduceResponse, error) {
 }
 
 func (b *Broker) Fetch(request *FetchRequest) (*FetchResponse, error) {
-	response := new(FetchResponse)
+	response := &FetchResponse{IVersion: request.IVersion}
 
 	err := b.sendAndReceive(request, response)
 
@@ -232,7 +655,223 @@ func (b *Broker) CommitOffset(request *OffsetCommitRequest) (*OffsetCommitRespon
 }
 
 func (b *Broker) FetchOffset(request *OffsetFetchRequest) (*OffsetFetchResponse, error) {
-	response := new(OffsetFetchResponse)
+	response := &OffsetFetchResponse{IVersion: request.IVersion}
+
+	err := b.sendAndReceive(request, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (b *Broker) JoinGroup(request *JoinGroupRequest) (*JoinGroupResponse, error) {
+	response := &JoinGroupResponse{IVersion: request.IVersion}
+
+	err := b.sendAndReceive(request, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (b *Broker) SyncGroup(request *SyncGroupRequest) (*SyncGroupResponse, error) {
+	response := &SyncGroupResponse{IVersion: request.IVersion}
+
+	err := b.sendAndReceive(request, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (b *Broker) Heartbeat(request *HeartbeatRequest) (*HeartbeatResponse, error) {
+	response := &HeartbeatResponse{IVersion: request.IVersion}
+
+	err := b.sendAndReceive(request, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (b *Broker) LeaveGroup(request *LeaveGroupRequest) (*LeaveGroupResponse, error) {
+	response := &LeaveGroupResponse{IVersion: request.IVersion}
+
+	err := b.sendAndReceive(request, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (b *Broker) DescribeGroups(request *DescribeGroupsRequest) (*DescribeGroupsResponse, error) {
+	response := &DescribeGroupsResponse{IVersion: request.IVersion}
+
+	err := b.sendAndReceive(request, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (b *Broker) ListGroups(request *ListGroupsRequest) (*ListGroupsResponse, error) {
+	response := &ListGroupsResponse{IVersion: request.IVersion}
+
+	err := b.sendAndReceive(request, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (b *Broker) CreateTopics(request *CreateTopicsRequest) (*CreateTopicsResponse, error) {
+	response := &CreateTopicsResponse{IVersion: request.IVersion}
+
+	err := b.sendAndReceive(request, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (b *Broker) DeleteTopics(request *DeleteTopicsRequest) (*DeleteTopicsResponse, error) {
+	response := &DeleteTopicsResponse{IVersion: request.IVersion}
+
+	err := b.sendAndReceive(request, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (b *Broker) CreatePartitions(request *CreatePartitionsRequest) (*CreatePartitionsResponse, error) {
+	response := &CreatePartitionsResponse{IVersion: request.IVersion}
+
+	err := b.sendAndReceive(request, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (b *Broker) DescribeConfigs(request *DescribeConfigsRequest) (*DescribeConfigsResponse, error) {
+	response := &DescribeConfigsResponse{IVersion: request.IVersion}
+
+	err := b.sendAndReceive(request, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (b *Broker) AlterConfigs(request *AlterConfigsRequest) (*AlterConfigsResponse, error) {
+	response := new(AlterConfigsResponse)
+
+	err := b.sendAndReceive(request, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (b *Broker) IncrementalAlterConfigs(request *IncrementalAlterConfigsRequest) (*IncrementalAlterConfigsResponse, error) {
+	response := new(IncrementalAlterConfigsResponse)
+
+	err := b.sendAndReceive(request, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (b *Broker) InitProducerID(request *InitProducerIDRequest) (*InitProducerIDResponse, error) {
+	response := new(InitProducerIDResponse)
+
+	err := b.sendAndReceive(request, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (b *Broker) AddPartitionsToTxn(request *AddPartitionsToTxnRequest) (*AddPartitionsToTxnResponse, error) {
+	response := new(AddPartitionsToTxnResponse)
+
+	err := b.sendAndReceive(request, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (b *Broker) AddOffsetsToTxn(request *AddOffsetsToTxnRequest) (*AddOffsetsToTxnResponse, error) {
+	response := new(AddOffsetsToTxnResponse)
+
+	err := b.sendAndReceive(request, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (b *Broker) EndTxn(request *EndTxnRequest) (*EndTxnResponse, error) {
+	response := new(EndTxnResponse)
+
+	err := b.sendAndReceive(request, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (b *Broker) TxnOffsetCommit(request *TxnOffsetCommitRequest) (*TxnOffsetCommitResponse, error) {
+	response := new(TxnOffsetCommitResponse)
+
+	err := b.sendAndReceive(request, response)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (b *Broker) ApiVersions(request *ApiVersionsRequest) (*ApiVersionsResponse, error) {
+	response := new(ApiVersionsResponse)
 
 	err := b.sendAndReceive(request, response)
 
@@ -254,6 +893,15 @@ func (b *Broker) send(rb RequestBody, promiseResponse bool) (*ResponsePromise, e
 		return nil, ErrNotConnected
 	}
 
+	if err := b.reauthenticate(); err != nil {
+		return nil, err
+	}
+
+	return b.sendInternal(rb, promiseResponse)
+}
+
+// sendInternal is send for callers already holding b.lock on an open connection.
+func (b *Broker) sendInternal(rb RequestBody, promiseResponse bool) (*ResponsePromise, error) {
 	req := &Request{CorrelationID: b.correlationID, ClientID: b.conf.ClientID, Body: rb}
 	buf, err := Encode(req)
 	if err != nil {
@@ -275,13 +923,35 @@ func (b *Broker) send(rb RequestBody, promiseResponse bool) (*ResponsePromise, e
 		return nil, nil
 	}
 
-	promise := ResponsePromise{req.CorrelationID, make(chan []byte), make(chan error)}
+	promise := ResponsePromise{CorrelationID: req.CorrelationID, Packets: make(chan []byte), Errors: make(chan error)}
+	if blocking, ok := rb.(blockingRequest); ok {
+		promise.timeout = blocking.responseTimeout()
+	}
 	b.responses <- promise
 
 	return &promise, nil
 }
 
+// maxLoggedRequestSize is how much of a failed request is logged with Config.Net.LogFailedRequests.
+const maxLoggedRequestSize = 4096
+
 func (b *Broker) sendAndReceive(req RequestBody, res Decoder) error {
+	err := b.trySendAndReceive(req, res)
+	if err == nil {
+		return nil
+	}
+
+	Logger.Printf("%T (key %d, version %d) to broker %s failed: %s\n", req, req.Key(), req.Version(), b.IAddr, err)
+	b.lock.Lock()
+	logContents := b.conf != nil && b.conf.Net.LogFailedRequests
+	b.lock.Unlock()
+	if logContents {
+		Logger.Printf("failed request: %s\n", dumpTruncated(req, maxLoggedRequestSize))
+	}
+	return err
+}
+
+func (b *Broker) trySendAndReceive(req RequestBody, res Decoder) error {
 	promise, err := b.send(req, res != nil)
 
 	if err != nil {
@@ -300,7 +970,53 @@ func (b *Broker) sendAndReceive(req RequestBody, res Decoder) error {
 	}
 }
 
+// syncSendAndReceive performs a request/response round-trip directly on the connection. It may only
+// be used by Open, which holds the lock and has not yet started the responseReceiver goroutine.
+func (b *Broker) syncSendAndReceive(rb RequestBody, res Decoder) error {
+	req := &Request{CorrelationID: b.correlationID, ClientID: b.conf.ClientID, Body: rb}
+	buf, err := Encode(req)
+	if err != nil {
+		return err
+	}
+
+	if err = b.conn.SetWriteDeadline(time.Now().Add(b.conf.Net.WriteTimeout)); err != nil {
+		return err
+	}
+	if _, err = b.conn.Write(buf); err != nil {
+		return err
+	}
+	b.correlationID++
+
+	if err = b.conn.SetReadDeadline(time.Now().Add(b.conf.Net.ReadTimeout)); err != nil {
+		return err
+	}
+	header := make([]byte, 8)
+	if _, err = io.ReadFull(b.conn, header); err != nil {
+		return err
+	}
+
+	decodedHeader := ResponseHeader{}
+	if err = Decode(header, &decodedHeader); err != nil {
+		return err
+	}
+	if decodedHeader.CorrelationID != req.CorrelationID {
+		return PacketDecodingError{fmt.Sprintf("correlation ID didn't match, wanted %d, got %d", req.CorrelationID, decodedHeader.CorrelationID)}
+	}
+
+	payload := make([]byte, decodedHeader.Length-4)
+	if _, err = io.ReadFull(b.conn, payload); err != nil {
+		return err
+	}
+
+	return Decode(payload, res)
+}
+
 func (b *Broker) Decode(pd packetDecoder) (err error) {
+	return b.decode(pd, 0)
+}
+
+// decode reads the broker as laid out in version 'version' of a MetadataResponse
+func (b *Broker) decode(pd packetDecoder, version int16) (err error) {
 	b.id, err = pd.getInt32()
 	if err != nil {
 		return err
@@ -316,6 +1032,12 @@ func (b *Broker) Decode(pd packetDecoder) (err error) {
 		return err
 	}
 
+	if version >= 1 {
+		if b.rack, err = pd.getNullableString(); err != nil {
+			return err
+		}
+	}
+
 	b.IAddr = net.JoinHostPort(host, fmt.Sprint(port))
 	if _, _, err := net.SplitHostPort(b.IAddr); err != nil {
 		return err
@@ -325,7 +1047,10 @@ func (b *Broker) Decode(pd packetDecoder) (err error) {
 }
 
 func (b *Broker) Encode(pe packetEncoder) (err error) {
+	return b.encode(pe, 0)
+}
 
+func (b *Broker) encode(pe packetEncoder, version int16) (err error) {
 	host, portstr, err := net.SplitHostPort(b.IAddr)
 	if err != nil {
 		return err
@@ -344,6 +1069,12 @@ func (b *Broker) Encode(pe packetEncoder) (err error) {
 
 	pe.putInt32(int32(port))
 
+	if version >= 1 {
+		if err = pe.putNullableString(b.rack); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -356,7 +1087,7 @@ func (b *Broker) responseReceiver() {
 			continue
 		}
 
-		err := b.conn.SetReadDeadline(time.Now().Add(b.conf.Net.ReadTimeout))
+		err := b.conn.SetReadDeadline(time.Now().Add(b.conf.Net.ReadTimeout + response.timeout))
 		if err != nil {
 			dead = err
 			response.Errors <- err
@@ -1,8 +1,15 @@
 package sarama
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
 	"testing"
+	"time"
 )
 
 func ExampleBroker() {
@@ -74,6 +81,468 @@ func TestSimpleBrokerCommunication(t *testing.T) {
 	}
 }
 
+func TestBrokerNegotiatesVersions(t *testing.T) {
+	mb := newMockBroker(t, 0)
+	defer mb.Close()
+
+	apiVersions := new(ApiVersionsResponse)
+	apiVersions.AddApiVersion(8, 0, 5)
+	apiVersions.AddApiVersion(9, 0, 0)
+	mb.Returns(apiVersions)
+
+	conf := NewConfig()
+	conf.Version = V0_10_0_0
+	conf.ApiVersionsRequest = true
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	if version, err := broker.negotiateVersion(8, 1, 2); err != nil || version != 2 {
+		t.Error("Expected version 2 of OffsetCommitRequest, got", version, err)
+	}
+	if _, err := broker.negotiateVersion(9, 1, 1); err != ErrUnsupportedVersion {
+		t.Error("Expected ErrUnsupportedVersion for OffsetFetchRequest, got", err)
+	}
+	if _, err := broker.negotiateVersion(3, 0, 0); err != ErrUnsupportedVersion {
+		t.Error("Expected ErrUnsupportedVersion for unlisted MetadataRequest, got", err)
+	}
+
+	// the configured Version caps what is negotiated
+	if version, err := broker.requestVersion(8, 1); err != nil || version != 2 {
+		t.Error("Expected version 2 of OffsetCommitRequest for Kafka 0.10, got", version, err)
+	}
+	conf.Version = V0_8_2_0
+	if version, err := broker.requestVersion(8, 1); err != nil || version != 1 {
+		t.Error("Expected version 1 of OffsetCommitRequest for Kafka 0.8.2, got", version, err)
+	}
+
+	safeClose(t, broker)
+}
+
+func TestBrokerApiVersionsFallback(t *testing.T) {
+	mb := newMockBroker(t, 0)
+	defer mb.Close()
+
+	// the ApiVersionsRequest goes unanswered, as with brokers older than 0.10
+	mb.Returns(&mockEncoder{})
+	mb.Returns(new(MetadataResponse))
+
+	conf := NewConfig()
+	conf.Version = V0_10_0_0
+	conf.ApiVersionsRequest = true
+	conf.Net.ReadTimeout = 100 * time.Millisecond
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	if version, err := broker.negotiateVersion(8, 1, 2); err != nil || version != 1 {
+		t.Error("Expected fallback to version 1 of OffsetCommitRequest, got", version, err)
+	}
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Error(err)
+	}
+
+	safeClose(t, broker)
+}
+
+func TestBrokerSASLPlain(t *testing.T) {
+	mb := newMockBroker(t, 0)
+	defer mb.Close()
+
+	mb.Returns(&SaslHandshakeResponse{EnabledMechanisms: []string{"PLAIN"}})
+	mb.Returns(new(MetadataResponse))
+
+	conf := NewConfig()
+	conf.Net.SASL.Enable = true
+	conf.Net.SASL.User = "user"
+	conf.Net.SASL.Password = "secret"
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Error(err)
+	}
+
+	history := mb.History()
+	if handshake, ok := history[0].Request.(*SaslHandshakeRequest); !ok || handshake.Mechanism != "PLAIN" {
+		t.Error("Expected a SASL handshake for PLAIN first, got", history[0].Request)
+	}
+	tokens := mb.SASLTokens()
+	if len(tokens) != 1 || string(tokens[0]) != "\x00user\x00secret" {
+		t.Errorf("Unexpected SASL tokens %q", tokens)
+	}
+
+	safeClose(t, broker)
+}
+
+func TestBrokerSASLHandshakeRejected(t *testing.T) {
+	mb := newMockBroker(t, 0)
+	defer mb.Close()
+
+	mb.Returns(&SaslHandshakeResponse{Err: ErrUnsupportedSASLMechanism, EnabledMechanisms: []string{"GSSAPI"}})
+
+	conf := NewConfig()
+	conf.Net.SASL.Enable = true
+	conf.Net.SASL.User = "user"
+	conf.Net.SASL.Password = "secret"
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	if connected, err := broker.Connected(); connected || err != ErrUnsupportedSASLMechanism {
+		t.Error("Expected the connection to fail with ErrUnsupportedSASLMechanism, got", connected, err)
+	}
+	if len(mb.SASLTokens()) != 0 {
+		t.Error("Expected no credentials to be sent after a rejected handshake")
+	}
+}
+
+// newRawTestListener accepts a single connection and hands it to serve, for tests of how brokers
+// cope with answers no Kafka broker would give.
+func newRawTestListener(t *testing.T, serve func(conn net.Conn)) net.Listener {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		serve(conn)
+		_, _ = ioutil.ReadAll(conn) // until the broker hangs up
+	}()
+	return ln
+}
+
+func TestBrokerSASLTokenResponseTooLarge(t *testing.T) {
+	ln := newRawTestListener(t, func(conn net.Conn) {
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		if _, err := io.ReadFull(conn, make([]byte, binary.BigEndian.Uint32(header))); err != nil {
+			return
+		}
+		_, _ = conn.Write([]byte{0xFF, 0xFF, 0xFF, 0xFF})
+	})
+	defer ln.Close()
+
+	conf := NewConfig()
+	conf.Net.SASL.Enable = true
+	conf.Net.SASL.Handshake = false
+	conf.Net.SASL.User = "user"
+	conf.Net.SASL.Password = "secret"
+	broker := NewBroker(ln.Addr().String())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	connected, err := broker.Connected()
+	if _, ok := err.(PacketDecodingError); connected || !ok {
+		t.Error("Expected the connection to fail with a PacketDecodingError, got", connected, err)
+	}
+}
+
+func TestBrokerApiVersionsResponseLength(t *testing.T) {
+	for _, length := range [][]byte{{0x00, 0x00, 0x00, 0x02}, {0x7F, 0xFF, 0xFF, 0xFF}} {
+		length := length
+		ln := newRawTestListener(t, func(conn net.Conn) {
+			if _, err := DecodeRequest(conn); err != nil {
+				return
+			}
+			_, _ = conn.Write(append(length, 0x00, 0x00, 0x00, 0x00))
+		})
+
+		// the response is rejected like that of a broker too old for the request, and the
+		// broker reconnects without any version information
+		conf := NewConfig()
+		conf.Version = V0_10_0_0
+		conf.ApiVersionsRequest = true
+		broker := NewBroker(ln.Addr().String())
+		if err := broker.Open(conf); err != nil {
+			t.Fatal(err)
+		}
+		if connected, err := broker.Connected(); !connected || err != nil {
+			t.Error("Expected the broker to reconnect, got", connected, err)
+		}
+		if version, err := broker.negotiateVersion(8, 1, 2); err != nil || version != 1 {
+			t.Error("Expected fallback to version 1 of OffsetCommitRequest, got", version, err)
+		}
+
+		safeClose(t, broker)
+		ln.Close()
+	}
+}
+
+func TestBrokerSASLSCRAM(t *testing.T) {
+	mb := newMockBroker(t, 0)
+	defer mb.Close()
+
+	mb.Returns(&SaslHandshakeResponse{EnabledMechanisms: []string{"SCRAM-SHA-256"}})
+	mb.Returns(&SaslAuthenticateResponse{SaslAuthBytes: []byte(scramServerFirst)})
+	mb.Returns(&SaslAuthenticateResponse{SaslAuthBytes: []byte(scramServerFinal)})
+	mb.Returns(new(MetadataResponse))
+
+	conf := NewConfig()
+	conf.Version = V1_0_0_0
+	conf.Net.SASL.Enable = true
+	conf.Net.SASL.Mechanism = SASLTypeSCRAMSHA256
+	conf.Net.SASL.User = "user"
+	conf.Net.SASL.Password = "pencil"
+	conf.Net.SASL.SCRAMClientGeneratorFunc = func() SCRAMClient {
+		// the client nonce of the exchange in scram_client_test.go
+		return &scramClient{hashFn: sha256.New, clientNonce: "rOprNGfwEbeRWgbNEkqO"}
+	}
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Error(err)
+	}
+
+	history := mb.History()
+	if handshake, ok := history[0].Request.(*SaslHandshakeRequest); !ok || handshake.Mechanism != "SCRAM-SHA-256" || handshake.IVersion != 1 {
+		t.Error("Expected a version 1 SASL handshake for SCRAM-SHA-256 first, got", history[0].Request)
+	}
+	for i, expected := range []string{scramClientFirst, scramClientFinal} {
+		if auth, ok := history[i+1].Request.(*SaslAuthenticateRequest); !ok || string(auth.SaslAuthBytes) != expected {
+			t.Errorf("Expected SCRAM message %q, got %v", expected, history[i+1].Request)
+		}
+	}
+
+	safeClose(t, broker)
+}
+
+func TestBrokerSASLSCRAMServerSignatureMismatch(t *testing.T) {
+	mb := newMockBroker(t, 0)
+	defer mb.Close()
+
+	mb.Returns(&SaslHandshakeResponse{EnabledMechanisms: []string{"SCRAM-SHA-256"}})
+	mb.Returns(&SaslAuthenticateResponse{SaslAuthBytes: []byte(scramServerFirst)})
+	mb.Returns(&SaslAuthenticateResponse{SaslAuthBytes: []byte("v=AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=")})
+
+	conf := NewConfig()
+	conf.Version = V1_0_0_0
+	conf.Net.SASL.Enable = true
+	conf.Net.SASL.Mechanism = SASLTypeSCRAMSHA256
+	conf.Net.SASL.User = "user"
+	conf.Net.SASL.Password = "pencil"
+	conf.Net.SASL.SCRAMClientGeneratorFunc = func() SCRAMClient {
+		// the client nonce of the exchange in scram_client_test.go
+		return &scramClient{hashFn: sha256.New, clientNonce: "rOprNGfwEbeRWgbNEkqO"}
+	}
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	if connected, err := broker.Connected(); connected || err != ErrSCRAMServerSignatureMismatch {
+		t.Error("Expected the connection to fail with ErrSCRAMServerSignatureMismatch, got", connected, err)
+	}
+}
+
+func TestBrokerSASLAuthenticateRejected(t *testing.T) {
+	mb := newMockBroker(t, 0)
+	defer mb.Close()
+
+	msg := "Authentication failed: Invalid username or password"
+	mb.Returns(&SaslHandshakeResponse{EnabledMechanisms: []string{"SCRAM-SHA-256"}})
+	mb.Returns(&SaslAuthenticateResponse{Err: ErrSASLAuthenticationFailed, ErrMsg: &msg})
+
+	conf := NewConfig()
+	conf.Version = V1_0_0_0
+	conf.Net.SASL.Enable = true
+	conf.Net.SASL.Mechanism = SASLTypeSCRAMSHA256
+	conf.Net.SASL.User = "user"
+	conf.Net.SASL.Password = "pencil"
+	conf.Net.SASL.SCRAMClientGeneratorFunc = func() SCRAMClient {
+		// the client nonce of the exchange in scram_client_test.go
+		return &scramClient{hashFn: sha256.New, clientNonce: "rOprNGfwEbeRWgbNEkqO"}
+	}
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	if connected, err := broker.Connected(); connected || err != ErrSASLAuthenticationFailed {
+		t.Error("Expected the connection to fail with ErrSASLAuthenticationFailed, got", connected, err)
+	}
+}
+
+type testTokenProvider struct {
+	tokens []*AccessToken
+}
+
+func (p *testTokenProvider) Token() (*AccessToken, error) {
+	token := p.tokens[0]
+	p.tokens = p.tokens[1:]
+	return token, nil
+}
+
+func TestBrokerSASLOAuth(t *testing.T) {
+	mb := newMockBroker(t, 0)
+	defer mb.Close()
+
+	mb.Returns(&SaslHandshakeResponse{EnabledMechanisms: []string{"OAUTHBEARER"}})
+	mb.Returns(&SaslAuthenticateResponse{IVersion: 1})
+	mb.Returns(new(MetadataResponse))
+
+	token := &AccessToken{Token: "jwt", Extensions: map[string]string{"traceid": "1", "logicalCluster": "lc"}}
+	conf := NewConfig()
+	conf.Version = V2_0_0_0
+	conf.Net.SASL.Enable = true
+	conf.Net.SASL.Mechanism = SASLTypeOAuth
+	conf.Net.SASL.TokenProvider = &testTokenProvider{tokens: []*AccessToken{token}}
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Error(err)
+	}
+
+	history := mb.History()
+	if handshake, ok := history[0].Request.(*SaslHandshakeRequest); !ok || handshake.Mechanism != "OAUTHBEARER" {
+		t.Error("Expected a SASL handshake for OAUTHBEARER first, got", history[0].Request)
+	}
+	expected := "n,,\x01auth=Bearer jwt\x01logicalCluster=lc\x01traceid=1\x01\x01"
+	if auth, ok := history[1].Request.(*SaslAuthenticateRequest); !ok || string(auth.SaslAuthBytes) != expected {
+		t.Errorf("Expected OAUTHBEARER message %q, got %v", expected, history[1].Request)
+	}
+
+	safeClose(t, broker)
+}
+
+func TestBrokerSASLOAuthRejected(t *testing.T) {
+	mb := newMockBroker(t, 0)
+	defer mb.Close()
+
+	mb.Returns(&SaslHandshakeResponse{EnabledMechanisms: []string{"OAUTHBEARER"}})
+	mb.Returns(&SaslAuthenticateResponse{SaslAuthBytes: []byte(`{"status":"invalid_token"}`), IVersion: 1})
+	mb.Returns(&SaslAuthenticateResponse{Err: ErrSASLAuthenticationFailed, IVersion: 1})
+
+	conf := NewConfig()
+	conf.Version = V2_0_0_0
+	conf.Net.SASL.Enable = true
+	conf.Net.SASL.Mechanism = SASLTypeOAuth
+	conf.Net.SASL.TokenProvider = &testTokenProvider{tokens: []*AccessToken{{Token: "expired"}}}
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	if connected, err := broker.Connected(); connected || err != ErrSASLAuthenticationFailed {
+		t.Error("Expected the connection to fail with ErrSASLAuthenticationFailed, got", connected, err)
+	}
+	history := mb.History()
+	if auth, ok := history[2].Request.(*SaslAuthenticateRequest); !ok || string(auth.SaslAuthBytes) != "\x01" {
+		t.Error("Expected the error challenge to be acknowledged, got", history[2].Request)
+	}
+}
+
+func TestBrokerSASLReauthentication(t *testing.T) {
+	mb := newMockBroker(t, 0)
+	defer mb.Close()
+
+	mb.Returns(&SaslHandshakeResponse{EnabledMechanisms: []string{"OAUTHBEARER"}})
+	mb.Returns(&SaslAuthenticateResponse{SessionLifetime: time.Millisecond, IVersion: 1})
+	mb.Returns(&SaslHandshakeResponse{EnabledMechanisms: []string{"OAUTHBEARER"}})
+	mb.Returns(&SaslAuthenticateResponse{IVersion: 1})
+	mb.Returns(new(MetadataResponse))
+	mb.Returns(new(MetadataResponse))
+
+	conf := NewConfig()
+	conf.Version = V2_0_0_0
+	conf.Net.SASL.Enable = true
+	conf.Net.SASL.Mechanism = SASLTypeOAuth
+	conf.Net.SASL.TokenProvider = &testTokenProvider{tokens: []*AccessToken{{Token: "first"}, {Token: "second"}}}
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	if connected, err := broker.Connected(); !connected {
+		t.Fatal(err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+			t.Error(err)
+		}
+	}
+
+	history := mb.History()
+	if len(history) != 6 {
+		t.Fatal("Expected 6 requests, got", len(history))
+	}
+	if _, ok := history[2].Request.(*SaslHandshakeRequest); !ok {
+		t.Error("Expected the expired session to be re-authenticated first, got", history[2].Request)
+	}
+	if auth, ok := history[3].Request.(*SaslAuthenticateRequest); !ok || !strings.Contains(string(auth.SaslAuthBytes), "Bearer second") {
+		t.Error("Expected re-authentication with a new token, got", history[3].Request)
+	}
+	for _, rr := range history[4:] {
+		if _, ok := rr.Request.(*MetadataRequest); !ok {
+			t.Error("Expected no further re-authentication, got", rr.Request)
+		}
+	}
+
+	safeClose(t, broker)
+}
+
+func TestBrokerSASLReauthenticationFailure(t *testing.T) {
+	mb := newMockBroker(t, 0)
+	defer mb.Close()
+
+	mb.Returns(&SaslHandshakeResponse{EnabledMechanisms: []string{"OAUTHBEARER"}})
+	mb.Returns(&SaslAuthenticateResponse{SessionLifetime: time.Millisecond, IVersion: 1})
+	mb.Returns(&SaslHandshakeResponse{EnabledMechanisms: []string{"OAUTHBEARER"}})
+	mb.Returns(&SaslAuthenticateResponse{Err: ErrSASLAuthenticationFailed, IVersion: 1})
+	mb.Returns(&SaslHandshakeResponse{EnabledMechanisms: []string{"OAUTHBEARER"}})
+	mb.Returns(&SaslAuthenticateResponse{IVersion: 1})
+	mb.Returns(new(MetadataResponse))
+
+	conf := NewConfig()
+	conf.Version = V2_0_0_0
+	conf.Net.SASL.Enable = true
+	conf.Net.SASL.Mechanism = SASLTypeOAuth
+	conf.Net.SASL.TokenProvider = &testTokenProvider{tokens: []*AccessToken{{Token: "first"}, {Token: "second"}, {Token: "third"}}}
+	broker := NewBroker(mb.Addr())
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	if connected, err := broker.Connected(); !connected {
+		t.Fatal(err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != ErrSASLAuthenticationFailed {
+		t.Fatal("Expected the failed re-authentication to be returned, got", err)
+	}
+	if connected, err := broker.Connected(); connected || err != ErrSASLAuthenticationFailed {
+		t.Fatal("Expected the connection to be dropped after a failed re-authentication, got", connected, err)
+	}
+
+	if err := broker.Open(conf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := broker.GetMetadata(&MetadataRequest{}); err != nil {
+		t.Error(err)
+	}
+
+	safeClose(t, broker)
+}
+
 // We're not testing encoding/decoding here, so most of the requests/responses will be empty for simplicity's sake
 var brokerTestTable = []struct {
 	response []byte
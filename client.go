@@ -19,6 +19,11 @@ type Client interface {
 	// Topics returns the set of available topics as retrieved from cluster metadata.
 	Topics() ([]string, error)
 
+	// InternalTopics returns those of Topics that Kafka uses for its own purposes,
+	// such as __consumer_offsets. Metadata only marks them from V0_10_0_0, so the
+	// result is always empty with earlier versions.
+	InternalTopics() ([]string, error)
+
 	// Partitions returns the sorted list of all partition IDs for the given topic.
 	Partitions(topic string) ([]int32, error)
 
@@ -33,6 +38,20 @@ type Client interface {
 
 	Any() *Broker
 
+	// Brokers returns the brokers of the cluster, as retrieved from cluster metadata.
+	Brokers() []*Broker
+
+	// Controller returns the controller broker of the cluster, as retrieved from
+	// cluster metadata. It will return a locally cached value if it's available.
+	// You can call RefreshController to update the cached value. This function
+	// requires Version to be at least V0_10_0_0.
+	Controller() (*Broker, error)
+
+	// RefreshController refreshes the cluster metadata and returns the
+	// controller broker it names. This function requires Version to be at least
+	// V0_10_0_0.
+	RefreshController() (*Broker, error)
+
 	// Replicas returns the set of all replica IDs for the given partition.
 	Replicas(topic string, partitionID int32) ([]int32, error)
 
@@ -44,9 +63,17 @@ type Client interface {
 	// GetOffset queries the cluster to get the most recent available offset at the
 	// given time on the topic/partition combination. Time should be OffsetOldest for
 	// the earliest available offset, OffsetNewest for the offset of the message that
-	// will be produced next, or a time.
+	// will be produced next, or a time in milliseconds since the epoch. From
+	// V0_10_1_0, a time returns the offset of the earliest message whose timestamp
+	// is at or after it, or ErrOffsetOutOfRange if there is none; before that, the
+	// offset of the latest log segment starting before it.
 	GetOffset(topic string, partitionID int32, time int64) (int64, error)
 
+	// GetOffsets is GetOffset for several partitions of a topic at once, sending a
+	// single request to each of their leaders. It returns the offsets by partition,
+	// or the first error any of the partitions got.
+	GetOffsets(topic string, partitionIDs []int32, time int64) (map[int32]int64, error)
+
 	// Coordinator returns the coordinating broker for a consumer group. It will
 	// return a locally cached value if it's available. You can call
 	// RefreshCoordinator to update the cached value. This function only works on
@@ -57,6 +84,16 @@ type Client interface {
 	// in local cache. This function only works on Kafka 0.8.2 and higher.
 	RefreshCoordinator(consumerGroup string) error
 
+	// TransactionCoordinator returns the coordinating broker for a transactional ID. It will
+	// return a locally cached value if it's available. You can call
+	// RefreshTransactionCoordinator to update the cached value. This function only works on
+	// Kafka 0.11 and higher.
+	TransactionCoordinator(transactionalID string) (*Broker, error)
+
+	// RefreshTransactionCoordinator retrieves the coordinator for a transactional ID and stores
+	// it in local cache. This function only works on Kafka 0.11 and higher.
+	RefreshTransactionCoordinator(transactionalID string) error
+
 	// Close shuts down all broker connections managed by this client. It is required
 	// to call this function before a client object passes out of scope, as it will
 	// otherwise leak memory. You must close any Producers or Consumers using a client
@@ -94,6 +131,10 @@ type client struct {
 	metadata     map[string]map[int32]*PartitionMetadata // maps topics to partition ids to metadata
 	coordinators map[string]int32                        // Maps consumer group names to coordinating broker IDs
 
+	transactionCoordinators map[string]int32 // Maps transactional IDs to coordinating broker IDs
+	controllerID            int32            // the ID of the controller broker, -1 if unknown
+	internalTopics          map[string]bool  // the topics marked internal in the metadata
+
 	// If the number of partitions is large, we can get some churn calling cachedPartitions,
 	// so the result is cached.  It is important to update this value whenever metadata is changed
 	cachedPartitionsResults map[string][maxPartitionIndex][]int32
@@ -125,8 +166,11 @@ func NewClient(addrs []string, conf *Config) (Client, error) {
 		closed:                  make(chan none),
 		brokers:                 make(map[int32]*Broker),
 		metadata:                make(map[string]map[int32]*PartitionMetadata),
+		internalTopics:          make(map[string]bool),
 		cachedPartitionsResults: make(map[string][maxPartitionIndex][]int32),
 		coordinators:            make(map[string]int32),
+		transactionCoordinators: make(map[string]int32),
+		controllerID:            -1,
 	}
 
 	random := rand.New(rand.NewSource(time.Now().UnixNano()))
@@ -208,6 +252,24 @@ func (client *client) Topics() ([]string, error) {
 	return ret, nil
 }
 
+func (client *client) InternalTopics() ([]string, error) {
+	if client.Closed() {
+		return nil, ErrClosedClient
+	}
+
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+
+	ret := make([]string, 0, len(client.internalTopics))
+	for topic := range client.internalTopics {
+		if _, ok := client.metadata[topic]; ok {
+			ret = append(ret, topic)
+		}
+	}
+
+	return ret, nil
+}
+
 func (client *client) Partitions(topic string) ([]int32, error) {
 	if client.Closed() {
 		return nil, ErrClosedClient
@@ -287,6 +349,58 @@ func (client *client) Any() *Broker {
 	return client.any()
 }
 
+func (client *client) Brokers() []*Broker {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+
+	brokers := make([]*Broker, 0, len(client.brokers))
+	for _, broker := range client.brokers {
+		_ = broker.Open(client.conf)
+		brokers = append(brokers, broker)
+	}
+	return brokers
+}
+
+func (client *client) Controller() (*Broker, error) {
+	if client.Closed() {
+		return nil, ErrClosedClient
+	}
+
+	if !client.conf.Version.IsAtLeast(V0_10_0_0) {
+		return nil, ErrUnsupportedVersion
+	}
+
+	controller := client.cachedController()
+	if controller == nil {
+		return client.RefreshController()
+	}
+
+	_ = controller.Open(client.conf)
+	return controller, nil
+}
+
+func (client *client) RefreshController() (*Broker, error) {
+	if client.Closed() {
+		return nil, ErrClosedClient
+	}
+
+	if !client.conf.Version.IsAtLeast(V0_10_0_0) {
+		return nil, ErrUnsupportedVersion
+	}
+
+	if err := client.RefreshMetadata(); err != nil {
+		return nil, err
+	}
+
+	controller := client.cachedController()
+	if controller == nil {
+		return nil, ErrControllerNotAvailable
+	}
+
+	_ = controller.Open(client.conf)
+	return controller, nil
+}
+
 func (client *client) Leader(topic string, partitionID int32) (*Broker, error) {
 	if client.Closed() {
 		return nil, ErrClosedClient
@@ -339,18 +453,51 @@ func (client *client) GetOffset(topic string, partitionID int32, time int64) (in
 	return offset, err
 }
 
+func (client *client) GetOffsets(topic string, partitionIDs []int32, time int64) (map[int32]int64, error) {
+	if client.Closed() {
+		return nil, ErrClosedClient
+	}
+
+	offsets, err := client.getOffsets(topic, partitionIDs, time)
+
+	if err != nil {
+		if err := client.RefreshMetadata(topic); err != nil {
+			return nil, err
+		}
+		return client.getOffsets(topic, partitionIDs, time)
+	}
+
+	return offsets, err
+}
+
 func (client *client) Coordinator(consumerGroup string) (*Broker, error) {
+	return client.coordinator(consumerGroup, CoordinatorGroup)
+}
+
+func (client *client) RefreshCoordinator(consumerGroup string) error {
+	return client.refreshCoordinator(consumerGroup, CoordinatorGroup)
+}
+
+func (client *client) TransactionCoordinator(transactionalID string) (*Broker, error) {
+	return client.coordinator(transactionalID, CoordinatorTransaction)
+}
+
+func (client *client) RefreshTransactionCoordinator(transactionalID string) error {
+	return client.refreshCoordinator(transactionalID, CoordinatorTransaction)
+}
+
+func (client *client) coordinator(key string, coordinatorType CoordinatorType) (*Broker, error) {
 	if client.Closed() {
 		return nil, ErrClosedClient
 	}
 
-	coordinator := client.cachedCoordinator(consumerGroup)
+	coordinator := client.cachedCoordinator(key, coordinatorType)
 
 	if coordinator == nil {
-		if err := client.RefreshCoordinator(consumerGroup); err != nil {
+		if err := client.refreshCoordinator(key, coordinatorType); err != nil {
 			return nil, err
 		}
-		coordinator = client.cachedCoordinator(consumerGroup)
+		coordinator = client.cachedCoordinator(key, coordinatorType)
 	}
 
 	if coordinator == nil {
@@ -361,12 +508,12 @@ func (client *client) Coordinator(consumerGroup string) (*Broker, error) {
 	return coordinator, nil
 }
 
-func (client *client) RefreshCoordinator(consumerGroup string) error {
+func (client *client) refreshCoordinator(key string, coordinatorType CoordinatorType) error {
 	if client.Closed() {
 		return ErrClosedClient
 	}
 
-	response, err := client.getConsumerMetadata(consumerGroup, client.conf.Metadata.Retry.Max)
+	response, err := client.getConsumerMetadata(key, coordinatorType, client.conf.Metadata.Retry.Max)
 	if err != nil {
 		return err
 	}
@@ -374,7 +521,7 @@ func (client *client) RefreshCoordinator(consumerGroup string) error {
 	client.lock.Lock()
 	defer client.lock.Unlock()
 	client.registerBroker(response.Coordinator)
-	client.coordinators[consumerGroup] = response.Coordinator.ID()
+	client.coordinatorsFor(coordinatorType)[key] = response.Coordinator.ID()
 	return nil
 }
 
@@ -520,33 +667,59 @@ func (client *client) cachedLeader(topic string, partitionID int32) (*Broker, er
 }
 
 func (client *client) getOffset(topic string, partitionID int32, time int64) (int64, error) {
-	broker, err := client.Leader(topic, partitionID)
+	offsets, err := client.getOffsets(topic, []int32{partitionID}, time)
 	if err != nil {
 		return -1, err
 	}
+	return offsets[partitionID], nil
+}
 
-	request := &OffsetRequest{}
-	request.AddBlock(topic, partitionID, time, 1)
+func (client *client) getOffsets(topic string, partitionIDs []int32, time int64) (map[int32]int64, error) {
+	requests := make(map[*Broker]*OffsetRequest)
+	for _, partitionID := range partitionIDs {
+		broker, err := client.Leader(topic, partitionID)
+		if err != nil {
+			return nil, err
+		}
 
-	response, err := broker.GetAvailableOffsets(request)
-	if err != nil {
-		_ = broker.Close()
-		return -1, err
+		request := requests[broker]
+		if request == nil {
+			version, err := broker.requestVersion(2, 0)
+			if err != nil {
+				return nil, err
+			}
+			request = &OffsetRequest{IVersion: version}
+			requests[broker] = request
+		}
+		request.AddBlock(topic, partitionID, time, 1)
 	}
 
-	block := response.GetBlock(topic, partitionID)
-	if block == nil {
-		_ = broker.Close()
-		return -1, ErrIncompleteResponse
-	}
-	if block.Err != ErrNoError {
-		return -1, block.Err
-	}
-	if len(block.Offsets) != 1 {
-		return -1, ErrOffsetOutOfRange
+	offsets := make(map[int32]int64, len(partitionIDs))
+	for broker, request := range requests {
+		response, err := broker.GetAvailableOffsets(request)
+		if err != nil {
+			_ = broker.Close()
+			return nil, err
+		}
+
+		for partitionID := range request.Blocks[topic] {
+			block := response.GetBlock(topic, partitionID)
+			if block == nil {
+				_ = broker.Close()
+				return nil, ErrIncompleteResponse
+			}
+			if block.Err != ErrNoError {
+				return nil, block.Err
+			}
+			offset, err := block.offset(response.IVersion)
+			if err != nil {
+				return nil, err
+			}
+			offsets[partitionID] = offset
+		}
 	}
 
-	return block.Offsets[0], nil
+	return offsets, nil
 }
 
 // core metadata update logic
@@ -589,7 +762,11 @@ func (client *client) tryRefreshMetadata(topics []string, attemptsRemaining int)
 		} else {
 			Logger.Printf("client/metadata fetching metadata for all topics from broker %s\n", broker.IAddr)
 		}
-		response, err := broker.GetMetadata(&MetadataRequest{Topics: topics})
+		var response *MetadataResponse
+		version, err := broker.requestVersion(3, 0)
+		if err == nil {
+			response, err = broker.GetMetadata(&MetadataRequest{Topics: topics, IVersion: version})
+		}
 
 		switch err.(type) {
 		case nil:
@@ -630,9 +807,18 @@ func (client *client) updateMetadata(data *MetadataResponse) (retry bool, err er
 		client.registerBroker(broker)
 	}
 
+	if data.IVersion >= 1 {
+		client.controllerID = data.ControllerID
+	}
+
 	for _, topic := range data.Topics {
 		delete(client.metadata, topic.Name)
 		delete(client.cachedPartitionsResults, topic.Name)
+		if topic.IsInternal {
+			client.internalTopics[topic.Name] = true
+		} else {
+			delete(client.internalTopics, topic.Name)
+		}
 
 		switch topic.Err {
 		case ErrNoError:
@@ -670,31 +856,56 @@ func (client *client) updateMetadata(data *MetadataResponse) (retry bool, err er
 	return
 }
 
-func (client *client) cachedCoordinator(consumerGroup string) *Broker {
+// coordinatorsFor returns the cache of coordinators of the given type. You must hold the lock before
+// calling this function.
+func (client *client) coordinatorsFor(coordinatorType CoordinatorType) map[string]int32 {
+	if coordinatorType == CoordinatorTransaction {
+		return client.transactionCoordinators
+	}
+	return client.coordinators
+}
+
+func (client *client) cachedController() *Broker {
+	client.lock.RLock()
+	defer client.lock.RUnlock()
+
+	return client.brokers[client.controllerID]
+}
+
+func (client *client) cachedCoordinator(key string, coordinatorType CoordinatorType) *Broker {
 	client.lock.RLock()
 	defer client.lock.RUnlock()
-	if coordinatorID, ok := client.coordinators[consumerGroup]; !ok {
+	if coordinatorID, ok := client.coordinatorsFor(coordinatorType)[key]; !ok {
 		return nil
 	} else {
 		return client.brokers[coordinatorID]
 	}
 }
 
-func (client *client) getConsumerMetadata(consumerGroup string, attemptsRemaining int) (*ConsumerMetadataResponse, error) {
+func (client *client) getConsumerMetadata(key string, coordinatorType CoordinatorType, attemptsRemaining int) (*ConsumerMetadataResponse, error) {
 	retry := func(err error) (*ConsumerMetadataResponse, error) {
 		if attemptsRemaining > 0 {
 			Logger.Printf("client/coordinator retrying after %dms... (%d attempts remaining)\n", client.conf.Metadata.Retry.Backoff/time.Millisecond, attemptsRemaining)
 			time.Sleep(client.conf.Metadata.Retry.Backoff)
-			return client.getConsumerMetadata(consumerGroup, attemptsRemaining-1)
+			return client.getConsumerMetadata(key, coordinatorType, attemptsRemaining-1)
 		}
 		return nil, err
 	}
 
 	for broker := client.any(); broker != nil; broker = client.any() {
-		Logger.Printf("client/coordinator requesting coordinator for consumergoup %s from %s\n", consumerGroup, broker.Addr())
+		Logger.Printf("client/coordinator requesting coordinator for %s %s from %s\n", coordinatorType, key, broker.Addr())
 
 		request := new(ConsumerMetadataRequest)
-		request.ConsumerGroup = consumerGroup
+		request.ConsumerGroup = key
+		if coordinatorType != CoordinatorGroup {
+			// only version 1 and later can look up transaction coordinators
+			version, err := broker.requestVersion(10, 1)
+			if err != nil {
+				return nil, err
+			}
+			request.CoordinatorType = coordinatorType
+			request.IVersion = version
+		}
 
 		response, err := broker.GetConsumerMetadata(request)
 
@@ -713,11 +924,11 @@ func (client *client) getConsumerMetadata(consumerGroup string, attemptsRemainin
 
 		switch response.Err {
 		case ErrNoError:
-			Logger.Printf("client/coordinator coordinator for consumergoup %s is #%d (%s)\n", consumerGroup, response.Coordinator.ID(), response.Coordinator.Addr())
+			Logger.Printf("client/coordinator coordinator for %s %s is #%d (%s)\n", coordinatorType, key, response.Coordinator.ID(), response.Coordinator.Addr())
 			return response, nil
 
 		case ErrConsumerCoordinatorNotAvailable:
-			Logger.Printf("client/coordinator coordinator for consumer group %s is not available\n", consumerGroup)
+			Logger.Printf("client/coordinator coordinator for %s %s is not available\n", coordinatorType, key)
 
 			// This is very ugly, but this scenario will only happen once per cluster.
 			// The __consumer_offsets topic only has to be created one time.
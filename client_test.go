@@ -196,11 +196,118 @@ func TestClientMetadata(t *testing.T) {
 		t.Error("Incorrect (or unsorted) replica")
 	}
 
+	brokers := client.Brokers()
+	if len(brokers) != 1 || brokers[0].ID() != 5 {
+		t.Error("Client returned incorrect brokers:", brokers)
+	}
+
 	leader.Close()
 	seedBroker.Close()
 	safeClose(t, client)
 }
 
+func TestClientController(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+	controller := newMockBroker(t, 2)
+	defer controller.Close()
+
+	rack := "rack-b"
+	controllerBroker := NewBroker(controller.Addr())
+	controllerBroker.id = controller.BrokerID()
+	controllerBroker.rack = &rack
+
+	metadataResponse := &MetadataResponse{IVersion: 1, ControllerID: controller.BrokerID()}
+	metadataResponse.AddBroker(seedBroker.Addr(), seedBroker.BrokerID())
+	metadataResponse.Brokers = append(metadataResponse.Brokers, controllerBroker)
+	seedBroker.Returns(metadataResponse)
+
+	config := NewConfig()
+	config.Version = V0_10_0_0
+	config.Metadata.Retry.Max = 0
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	if request := seedBroker.History()[0].Request.(*MetadataRequest); request.Version() != 1 {
+		t.Error("Expected version 1 of MetadataRequest, got", request.Version())
+	}
+
+	broker, err := client.Controller()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if broker.ID() != controller.BrokerID() {
+		t.Error("Expected the controller to be broker", controller.BrokerID(), "got", broker.ID())
+	}
+	if broker.Rack() != rack {
+		t.Error("Expected the controller to be in rack", rack, "got", broker.Rack())
+	}
+
+	for _, b := range client.Brokers() {
+		if b.ID() == seedBroker.BrokerID() && b.Rack() != "" {
+			t.Error("Expected the seed broker to have no rack, got", b.Rack())
+		}
+	}
+}
+
+func TestClientInternalTopics(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+
+	metadataResponse := &MetadataResponse{IVersion: 1, ControllerID: seedBroker.BrokerID()}
+	metadataResponse.AddBroker(seedBroker.Addr(), seedBroker.BrokerID())
+	metadataResponse.AddTopicPartition("my_topic", 0, seedBroker.BrokerID(), nil, nil, ErrNoError)
+	metadataResponse.AddTopicPartition("__consumer_offsets", 0, seedBroker.BrokerID(), nil, nil, ErrNoError)
+	metadataResponse.AddTopic("__consumer_offsets", ErrNoError).IsInternal = true
+	seedBroker.Returns(metadataResponse)
+
+	config := NewConfig()
+	config.Version = V0_10_0_0
+	config.Metadata.Retry.Max = 0
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	topics, err := client.Topics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(topics) != 2 {
+		t.Error("Expected both topics, got", topics)
+	}
+
+	internal, err := client.InternalTopics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(internal) != 1 || internal[0] != "__consumer_offsets" {
+		t.Error("Expected only __consumer_offsets to be internal, got", internal)
+	}
+}
+
+func TestClientControllerUnsupportedVersion(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+	seedBroker.Returns(new(MetadataResponse))
+
+	config := NewConfig()
+	config.Version = V0_9_0_0
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	if _, err := client.Controller(); err != ErrUnsupportedVersion {
+		t.Error("Expected ErrUnsupportedVersion, got", err)
+	}
+}
+
 func TestClientGetOffset(t *testing.T) {
 	seedBroker := newMockBroker(t, 1)
 	leader := newMockBroker(t, 2)
@@ -249,6 +356,50 @@ func TestClientGetOffset(t *testing.T) {
 	safeClose(t, client)
 }
 
+func TestClientGetOffsetsForTime(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	defer seedBroker.Close()
+	leader := newMockBroker(t, 2)
+	defer leader.Close()
+
+	metadata := &MetadataResponse{IVersion: 1}
+	metadata.AddTopicPartition("foo", 0, leader.BrokerID(), nil, nil, ErrNoError)
+	metadata.AddTopicPartition("foo", 1, leader.BrokerID(), nil, nil, ErrNoError)
+	metadata.AddBroker(leader.Addr(), leader.BrokerID())
+	seedBroker.Returns(metadata)
+
+	const time = 1478000000000
+	leader.SetHandlerByMap(map[string]MockResponse{
+		"OffsetRequest": newMockOffsetResponse(t).
+			SetOffset("foo", 0, time, 123).
+			SetOffset("foo", 1, time, 456),
+	})
+
+	config := NewConfig()
+	config.Version = V0_10_1_0
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, client)
+
+	offsets, err := client.GetOffsets("foo", []int32{0, 1}, time)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(offsets) != 2 || offsets[0] != 123 || offsets[1] != 456 {
+		t.Error("Unexpected offsets, got", offsets)
+	}
+
+	history := leader.History()
+	if len(history) != 1 {
+		t.Fatal("Expected a single OffsetRequest for both partitions, got", len(history))
+	}
+	if request := history[0].Request.(*OffsetRequest); request.Version() != 1 {
+		t.Error("Expected version 1 of OffsetRequest, got", request.Version())
+	}
+}
+
 func TestClientReceivingUnknownTopic(t *testing.T) {
 	seedBroker := newMockBroker(t, 1)
 
@@ -512,6 +663,63 @@ func TestClientCoordinatorWithConsumerOffsetsTopic(t *testing.T) {
 	safeClose(t, client)
 }
 
+func TestClientTransactionCoordinator(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	coordinator := newMockBroker(t, 2)
+
+	seedBroker.SetHandlerByMap(map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetBroker(coordinator.Addr(), coordinator.BrokerID()),
+		"ConsumerMetadataRequest": newMockConsumerMetadataResponse(t).
+			SetCoordinator("txn", coordinator),
+	})
+
+	config := NewConfig()
+	config.Version = V0_11_0_0
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	broker, err := client.TransactionCoordinator("txn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if broker.ID() != coordinator.BrokerID() {
+		t.Errorf("Expected coordinator to have ID %d, found %d", coordinator.BrokerID(), broker.ID())
+	}
+
+	// Grab the cached value
+	if _, err := client.TransactionCoordinator("txn"); err != nil {
+		t.Error(err)
+	}
+
+	// Transactional IDs and consumer groups are cached separately
+	if _, err := client.Coordinator("txn"); err != nil {
+		t.Error(err)
+	}
+
+	var requests []*ConsumerMetadataRequest
+	for _, rr := range seedBroker.History() {
+		if request, ok := rr.Request.(*ConsumerMetadataRequest); ok {
+			requests = append(requests, request)
+		}
+	}
+	if len(requests) != 2 {
+		t.Fatal("Expected two coordinator lookups, got", len(requests))
+	}
+	if requests[0].CoordinatorType != CoordinatorTransaction || requests[0].IVersion != 1 {
+		t.Error("Expected a version 1 lookup of a transaction coordinator, got", requests[0])
+	}
+	if requests[1].CoordinatorType != CoordinatorGroup || requests[1].IVersion != 0 {
+		t.Error("Expected a version 0 lookup of a group coordinator, got", requests[1])
+	}
+
+	coordinator.Close()
+	seedBroker.Close()
+	safeClose(t, client)
+}
+
 func TestClientCoordinatorWithoutConsumerOffsetsTopic(t *testing.T) {
 	seedBroker := newMockBroker(t, 1)
 	coordinator := newMockBroker(t, 2)
@@ -585,7 +793,7 @@ func TestClientAutorefreshShutdownRace(t *testing.T) {
 	go func() {
 		// Close the client
 		if err := client.Close(); err != nil {
-			t.Fatal(err)
+			t.Error(err)
 		}
 		close(done)
 	}()
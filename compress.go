@@ -0,0 +1,83 @@
+package sarama
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+)
+
+// compress returns data compressed with the given codec, as found in the value of a compressed
+// message or the records of a compressed record batch. The level only applies to GZIP and ZSTD.
+func compress(cc CompressionCodec, level int, data []byte) ([]byte, error) {
+	switch cc {
+	case CompressionNone:
+		return data, nil
+	case CompressionGZIP:
+		var (
+			buf    bytes.Buffer
+			writer *gzip.Writer
+			err    error
+		)
+		switch level {
+		case CompressionLevelDefault:
+			writer = gzip.NewWriter(&buf)
+		case CompressionLevelZero:
+			writer, err = gzip.NewWriterLevel(&buf, gzip.NoCompression)
+		default:
+			writer, err = gzip.NewWriterLevel(&buf, level)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, err := writer.Write(data); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case CompressionSnappy:
+		return snappyEncode(data), nil
+	case CompressionLZ4:
+		return lz4Encode(data), nil
+	case CompressionZSTD:
+		return zstdEncode(level, data)
+	default:
+		return nil, PacketEncodingError{fmt.Sprintf("unsupported compression codec (%d)", cc)}
+	}
+}
+
+// decompress is the inverse of compress.
+func decompress(cc CompressionCodec, data []byte) ([]byte, error) {
+	switch cc {
+	case CompressionNone:
+		return data, nil
+	case CompressionGZIP:
+		if data == nil {
+			return nil, PacketDecodingError{"GZIP compression specified, but no data to uncompress"}
+		}
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(reader)
+	case CompressionSnappy:
+		if data == nil {
+			return nil, PacketDecodingError{"Snappy compression specified, but no data to uncompress"}
+		}
+		return snappyDecode(data)
+	case CompressionLZ4:
+		if data == nil {
+			return nil, PacketDecodingError{"LZ4 compression specified, but no data to uncompress"}
+		}
+		return lz4Decode(data)
+	case CompressionZSTD:
+		if data == nil {
+			return nil, PacketDecodingError{"ZSTD compression specified, but no data to uncompress"}
+		}
+		return zstdDecode(data)
+	default:
+		return nil, PacketDecodingError{fmt.Sprintf("invalid compression specified (%d)", cc)}
+	}
+}
@@ -1,6 +1,7 @@
 package sarama
 
 import (
+	"compress/gzip"
 	"crypto/tls"
 	"time"
 )
@@ -32,9 +33,47 @@ type Config struct {
 			Config *tls.Config
 		}
 
+		// SASL based authentication with broker. While there are multiple SASL
+		// authentication methods the current implementation is limited to
+		// plaintext (SASL/PLAIN), SCRAM (SASL/SCRAM-SHA-256 and
+		// SASL/SCRAM-SHA-512) and bearer token (SASL/OAUTHBEARER)
+		// authentication. Connections re-authenticate before their session
+		// expires if the broker limits its lifetime (Kafka 2.2 and later, with
+		// Version at least V2_0_0_0).
+		SASL struct {
+			// Whether or not to use SASL authentication when connecting to the
+			// broker (defaults to false).
+			Enable bool
+			// The SASL mechanism to authenticate with, SASLTypePlaintext,
+			// SASLTypeSCRAMSHA256, SASLTypeSCRAMSHA512 or SASLTypeOAuth
+			// (defaults to SASLTypePlaintext).
+			Mechanism SASLMechanism
+			// Whether or not to send the Kafka SASL handshake first if enabled
+			// (defaults to true). You should only set this to false if you're
+			// using a non-Kafka SASL proxy.
+			Handshake bool
+			// User and Password are the credentials for SASL authentication,
+			// except with SASLTypeOAuth.
+			User     string
+			Password string
+			// Creates the client for each SCRAM exchange, for example to use
+			// an external SCRAM implementation (defaults to nil, in which case
+			// Sarama's own client is used).
+			SCRAMClientGeneratorFunc func() SCRAMClient
+			// Supplies the bearer tokens for SASLTypeOAuth (defaults to nil,
+			// and must be set with that mechanism).
+			TokenProvider AccessTokenProvider
+		}
+
 		// KeepAlive specifies the keep-alive period for an active network connection.
 		// If zero, keep-alives are disabled. (default is 0: disabled).
 		KeepAlive time.Duration
+
+		// Whether to log the contents of requests that fail, besides their type,
+		// key and version (defaults to false). The contents are cut short after
+		// 4KiB and configuration values are redacted, but they may still include
+		// the keys and values of produced messages.
+		LogFailedRequests bool
 	}
 
 	// Metadata is the namespace for metadata management properties used by the
@@ -54,6 +93,15 @@ type Config struct {
 		RefreshFrequency time.Duration
 	}
 
+	// Admin is the namespace for configuration related to cluster administration,
+	// used by the ClusterAdmin.
+	Admin struct {
+		// How long the controller may take to carry out an operation, such as
+		// creating a topic, before giving up on it (default 3s). Sarama waits this
+		// long for the response on top of Net.ReadTimeout.
+		Timeout time.Duration
+	}
+
 	// Producer is the namespace for configuration related to producing messages,
 	// used by the Producer.
 	Producer struct {
@@ -71,12 +119,43 @@ type Config struct {
 		// the JVM producer's `request.timeout.ms` setting.
 		Timeout time.Duration
 		// The type of compression to use on messages (defaults to no compression).
-		// Similar to `compression.codec` setting of the JVM producer.
+		// Similar to `compression.codec` setting of the JVM producer. CompressionLZ4
+		// requires Version to be at least V0_10_0_0, and CompressionZSTD at least
+		// V2_1_0_0.
 		Compression CompressionCodec
+		// The level of compression to use, for the codecs that have one (GZIP and
+		// ZSTD). Defaults to CompressionLevelDefault, which uses the codec's own
+		// default level; higher levels trade speed for a better ratio. Use
+		// CompressionLevelZero for level 0.
+		CompressionLevel int
 		// Generates partitioners for choosing the partition to send messages to
 		// (defaults to hashing the message key). Similar to the `partitioner.class`
 		// setting for the JVM producer.
 		Partitioner PartitionerConstructor
+		// If enabled, the producer obtains a producer ID from the cluster and
+		// numbers the messages of each partition, so that brokers discard the
+		// duplicates that retries would otherwise write, for example after a
+		// timeout (defaults to false). Equivalent to the `enable.idempotence`
+		// setting of the JVM producer. Requires Version to be at least
+		// V0_11_0_0, RequiredAcks to be WaitForAll, Retry.Max to be at least 1
+		// and Net.MaxOpenRequests to be 1.
+		Idempotent bool
+
+		// Transaction configures the transactional API of the producer
+		// (BeginTxn, CommitTxn, AbortTxn and AddOffsetsToTxn).
+		Transaction struct {
+			// The transactional ID of the producer, which must stay the same
+			// across restarts so that the cluster can fence off earlier
+			// instances and complete or abort their transactions (defaults to
+			// empty, which disables transactions). Equivalent to the
+			// `transactional.id` setting of the JVM producer. Requires
+			// Idempotent to be enabled.
+			ID string
+			// The longest the transaction coordinator waits for a transaction
+			// to complete before aborting it (defaults to 1 minute). Equivalent
+			// to the `transaction.timeout.ms` setting of the JVM producer.
+			Timeout time.Duration
+		}
 
 		// Return specifies what channels will be populated. If they are set to true,
 		// you must read from the respective channels to prevent deadlock.
@@ -167,6 +246,13 @@ type Config struct {
 		// (MaxProcessingTime * ChanneBufferSize). Defaults to 100ms.
 		MaxProcessingTime time.Duration
 
+		// Whether to return the messages of transactions that were aborted or are
+		// still in progress (ReadUncommitted, the default), or only those of
+		// committed transactions (ReadCommitted). Equivalent to the JVM's
+		// `isolation.level`. ReadCommitted requires Version to be at least
+		// V0_11_0_0.
+		IsolationLevel IsolationLevel
+
 		// Return specifies what channels will be populated. If they are set to true,
 		// you must read from them to prevent deadlock.
 		Return struct {
@@ -186,6 +272,47 @@ type Config struct {
 			// Should be OffsetNewest or OffsetOldest. Defaults to OffsetNewest.
 			Initial int64
 		}
+
+		// Group specifies configuration for consumer groups coordinated by Kafka,
+		// see ConsumerGroup. These require Version to be at least V0_9_0_0.
+		Group struct {
+			Session struct {
+				// How long the coordinator waits without hearing from a member
+				// before removing it from the group and rebalancing (default 10s).
+				// Must be within the broker's `group.min.session.timeout.ms` and
+				// `group.max.session.timeout.ms`. Equivalent to the JVM's
+				// `session.timeout.ms`.
+				Timeout time.Duration
+			}
+			Heartbeat struct {
+				// How often to let the coordinator know the member is alive (default
+				// 3s). Must be lower than Session.Timeout, and is typically no more
+				// than a third of it. Equivalent to the JVM's `heartbeat.interval.ms`.
+				Interval time.Duration
+			}
+			Rebalance struct {
+				// How long the coordinator waits for every member to rejoin once a
+				// rebalance starts, which is also how long a member has to release its
+				// partitions and commit their offsets (default 60s). Brokers older
+				// than Kafka 0.10.1 use Session.Timeout instead.
+				Timeout time.Duration
+				// The strategy the leader of the group uses to assign partitions to
+				// members (default BalanceStrategyRange). Every member of a group
+				// must use the same one.
+				Strategy BalanceStrategy
+				Retry    struct {
+					// How long to wait after failing to join the group before trying
+					// again (default 2s).
+					Backoff time.Duration
+				}
+			}
+			Return struct {
+				// If enabled, a ConsumerGroupNotification is returned on the
+				// Notifications channel whenever the partitions claimed by the member
+				// change (default disabled).
+				Notifications bool
+			}
+		}
 	}
 
 	// A user-provided string sent with every request to the brokers for logging,
@@ -197,6 +324,24 @@ type Config struct {
 	// in the background while user code is working, greatly improving throughput.
 	// Defaults to 256.
 	ChannelBufferSize int
+	// Whether to ask each broker which versions of each request it supports
+	// when connecting, so that the highest version understood by both sides
+	// can be used. Brokers older than Kafka 0.10 do not understand this and
+	// drop the connection, in which case Sarama reconnects and falls back to
+	// the oldest versions it knows how to use. Requires Version to be at least
+	// V0_10_0_0. Defaults to false.
+	ApiVersionsRequest bool
+	// The version of Kafka that Sarama will assume it is running against.
+	// Each request is sent using the newest protocol version that brokers of
+	// this version understand, so features such as message timestamps are
+	// only used once it is high enough. Since Kafka provides backwards
+	// compatibility, setting it to a version older than you have will not
+	// break anything, although it may prevent you from using the latest
+	// features. Setting it to a version greater than you are actually running
+	// may lead to random breakage, unless ApiVersionsRequest is also enabled,
+	// in which case it is only an upper bound. Defaults to V0_8_2_0, the
+	// oldest supported version.
+	Version KafkaVersion
 }
 
 // NewConfig returns a new configuration instance with sane defaults.
@@ -207,17 +352,22 @@ func NewConfig() *Config {
 	c.Net.DialTimeout = 30 * time.Second
 	c.Net.ReadTimeout = 30 * time.Second
 	c.Net.WriteTimeout = 30 * time.Second
+	c.Net.SASL.Mechanism = SASLTypePlaintext
+	c.Net.SASL.Handshake = true
 
 	c.Metadata.Retry.Max = 3
 	c.Metadata.Retry.Backoff = 250 * time.Millisecond
 	c.Metadata.RefreshFrequency = 10 * time.Minute
 
+	c.Admin.Timeout = 3 * time.Second
+
 	c.Producer.MaxMessageBytes = 1000000
 	c.Producer.RequiredAcks = WaitForLocal
 	c.Producer.Timeout = 10 * time.Second
 	c.Producer.Partitioner = NewHashPartitioner
 	c.Producer.Retry.Max = 3
 	c.Producer.Retry.Backoff = 100 * time.Millisecond
+	c.Producer.Transaction.Timeout = 1 * time.Minute
 	c.Producer.Return.Errors = true
 
 	c.Consumer.Fetch.Min = 1
@@ -226,10 +376,17 @@ func NewConfig() *Config {
 	c.Consumer.MaxWaitTime = 250 * time.Millisecond
 	c.Consumer.MaxProcessingTime = 100 * time.Millisecond
 	c.Consumer.Return.Errors = false
+	c.Consumer.IsolationLevel = ReadUncommitted
 	c.Consumer.Offsets.CommitInterval = 1 * time.Second
 	c.Consumer.Offsets.Initial = OffsetNewest
+	c.Consumer.Group.Session.Timeout = 10 * time.Second
+	c.Consumer.Group.Heartbeat.Interval = 3 * time.Second
+	c.Consumer.Group.Rebalance.Timeout = 60 * time.Second
+	c.Consumer.Group.Rebalance.Strategy = BalanceStrategyRange
+	c.Consumer.Group.Rebalance.Retry.Backoff = 2 * time.Second
 
 	c.ChannelBufferSize = 256
+	c.Version = minVersion
 
 	return c
 }
@@ -241,6 +398,14 @@ func (c *Config) Validate() error {
 	if c.Net.TLS.Enable == false && c.Net.TLS.Config != nil {
 		Logger.Println("Net.TLS is disabled but a non-nil configuration was provided.")
 	}
+	if c.Net.SASL.Enable == false {
+		if c.Net.SASL.User != "" {
+			Logger.Println("Net.SASL is disabled but a non-empty username was provided.")
+		}
+		if c.Net.SASL.Password != "" {
+			Logger.Println("Net.SASL is disabled but a non-empty password was provided.")
+		}
+	}
 	if c.Producer.RequiredAcks > 1 {
 		Logger.Println("Producer.RequiredAcks > 1 is deprecated and will raise an exception with kafka >= 0.8.2.0.")
 	}
@@ -275,6 +440,16 @@ func (c *Config) Validate() error {
 		return ConfigurationError("Net.WriteTimeout must be > 0")
 	case c.Net.KeepAlive < 0:
 		return ConfigurationError("Net.KeepAlive must be >= 0")
+	case c.Net.SASL.Enable == true && c.Net.SASL.Mechanism != SASLTypePlaintext &&
+		c.Net.SASL.Mechanism != SASLTypeSCRAMSHA256 && c.Net.SASL.Mechanism != SASLTypeSCRAMSHA512 &&
+		c.Net.SASL.Mechanism != SASLTypeOAuth:
+		return ConfigurationError("Net.SASL.Mechanism must be SASLTypePlaintext, SASLTypeSCRAMSHA256, SASLTypeSCRAMSHA512 or SASLTypeOAuth")
+	case c.Net.SASL.Enable == true && c.Net.SASL.Mechanism == SASLTypeOAuth && c.Net.SASL.TokenProvider == nil:
+		return ConfigurationError("Net.SASL.TokenProvider must not be nil when the SASL mechanism is SASLTypeOAuth")
+	case c.Net.SASL.Enable == true && c.Net.SASL.Mechanism != SASLTypeOAuth && c.Net.SASL.User == "":
+		return ConfigurationError("Net.SASL.User must not be empty when SASL is enabled")
+	case c.Net.SASL.Enable == true && c.Net.SASL.Mechanism != SASLTypeOAuth && c.Net.SASL.Password == "":
+		return ConfigurationError("Net.SASL.Password must not be empty when SASL is enabled")
 	}
 
 	// validate the Metadata values
@@ -287,6 +462,12 @@ func (c *Config) Validate() error {
 		return ConfigurationError("Metadata.RefreshFrequency must be >= 0")
 	}
 
+	// validate the Admin values
+	switch {
+	case c.Admin.Timeout <= 0:
+		return ConfigurationError("Admin.Timeout must be > 0")
+	}
+
 	// validate the Producer values
 	switch {
 	case c.Producer.MaxMessageBytes <= 0:
@@ -311,6 +492,27 @@ func (c *Config) Validate() error {
 		return ConfigurationError("Producer.Retry.Max must be >= 0")
 	case c.Producer.Retry.Backoff < 0:
 		return ConfigurationError("Producer.Retry.Backoff must be >= 0")
+	case c.Producer.Compression < CompressionNone || c.Producer.Compression > CompressionZSTD:
+		return ConfigurationError("Producer.Compression is not a known compression codec")
+	case c.Producer.Idempotent && !c.Version.IsAtLeast(V0_11_0_0):
+		return ConfigurationError("Producer.Idempotent requires Version >= " + V0_11_0_0.String())
+	case c.Producer.Idempotent && c.Producer.RequiredAcks != WaitForAll:
+		return ConfigurationError("Producer.Idempotent requires Producer.RequiredAcks to be WaitForAll")
+	case c.Producer.Idempotent && c.Producer.Retry.Max == 0:
+		return ConfigurationError("Producer.Idempotent requires Producer.Retry.Max >= 1")
+	case c.Producer.Idempotent && c.Net.MaxOpenRequests > 1:
+		return ConfigurationError("Producer.Idempotent requires Net.MaxOpenRequests to be 1")
+	case c.Producer.Transaction.ID != "" && !c.Producer.Idempotent:
+		return ConfigurationError("Producer.Transaction.ID requires Producer.Idempotent to be enabled")
+	case c.Producer.Transaction.ID != "" && c.Producer.Transaction.Timeout < time.Millisecond:
+		return ConfigurationError("Producer.Transaction.Timeout must be >= 1ms")
+	case c.Producer.Compression == CompressionLZ4 && !c.Version.IsAtLeast(V0_10_0_0):
+		return ConfigurationError("Producer.Compression LZ4 requires Version >= " + V0_10_0_0.String())
+	case c.Producer.Compression == CompressionZSTD && !c.Version.IsAtLeast(V2_1_0_0):
+		return ConfigurationError("Producer.Compression ZSTD requires Version >= " + V2_1_0_0.String())
+	case c.Producer.Compression == CompressionGZIP && c.Producer.CompressionLevel != CompressionLevelZero &&
+		(c.Producer.CompressionLevel < gzip.DefaultCompression || c.Producer.CompressionLevel > gzip.BestCompression):
+		return ConfigurationError("Producer.CompressionLevel must be between -1 and 9 for GZIP")
 	}
 
 	// validate the Consumer values
@@ -327,17 +529,36 @@ func (c *Config) Validate() error {
 		return ConfigurationError("Consumer.MaxProcessingTime must be > 0")
 	case c.Consumer.Retry.Backoff < 0:
 		return ConfigurationError("Consumer.Retry.Backoff must be >= 0")
+	case c.Consumer.IsolationLevel != ReadUncommitted && c.Consumer.IsolationLevel != ReadCommitted:
+		return ConfigurationError("Consumer.IsolationLevel must be ReadUncommitted or ReadCommitted")
+	case c.Consumer.IsolationLevel == ReadCommitted && !c.Version.IsAtLeast(V0_11_0_0):
+		return ConfigurationError("Consumer.IsolationLevel ReadCommitted requires Version >= " + V0_11_0_0.String())
 	case c.Consumer.Offsets.CommitInterval <= 0:
 		return ConfigurationError("Consumer.Offsets.CommitInterval must be > 0")
 	case c.Consumer.Offsets.Initial != OffsetOldest && c.Consumer.Offsets.Initial != OffsetNewest:
 		return ConfigurationError("Consumer.Offsets.Initial must be OffsetOldest or OffsetNewest")
-
+	case c.Consumer.Group.Session.Timeout < 2*time.Millisecond:
+		return ConfigurationError("Consumer.Group.Session.Timeout must be >= 2ms")
+	case c.Consumer.Group.Heartbeat.Interval < 1*time.Millisecond:
+		return ConfigurationError("Consumer.Group.Heartbeat.Interval must be >= 1ms")
+	case c.Consumer.Group.Heartbeat.Interval >= c.Consumer.Group.Session.Timeout:
+		return ConfigurationError("Consumer.Group.Heartbeat.Interval must be < Consumer.Group.Session.Timeout")
+	case c.Consumer.Group.Rebalance.Timeout < 2*time.Millisecond:
+		return ConfigurationError("Consumer.Group.Rebalance.Timeout must be >= 2ms")
+	case c.Consumer.Group.Rebalance.Strategy == nil:
+		return ConfigurationError("Consumer.Group.Rebalance.Strategy must not be nil")
+	case c.Consumer.Group.Rebalance.Retry.Backoff < 0:
+		return ConfigurationError("Consumer.Group.Rebalance.Retry.Backoff must be >= 0")
 	}
 
 	// validate misc shared values
 	switch {
 	case c.ChannelBufferSize < 0:
 		return ConfigurationError("ChannelBufferSize must be >= 0")
+	case !c.Version.IsAtLeast(minVersion):
+		return ConfigurationError("Version must be at least " + minVersion.String())
+	case c.ApiVersionsRequest && !c.Version.IsAtLeast(V0_10_0_0):
+		return ConfigurationError("ApiVersionsRequest requires Version >= " + V0_10_0_0.String())
 	}
 
 	return nil
@@ -1,6 +1,9 @@
 package sarama
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 func TestDefaultConfigValidates(t *testing.T) {
 	config := NewConfig()
@@ -8,3 +11,169 @@ func TestDefaultConfigValidates(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestVersionConfigValidation(t *testing.T) {
+	config := NewConfig()
+	config.Version = KafkaVersion{}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected a zero Version to be rejected")
+	}
+
+	config = NewConfig()
+	config.ApiVersionsRequest = true
+	if err := config.Validate(); err == nil {
+		t.Error("Expected ApiVersionsRequest to be rejected with the default Version")
+	}
+
+	config.Version = V0_10_0_0
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCompressionConfigValidation(t *testing.T) {
+	config := NewConfig()
+	config.Producer.Compression = CompressionLZ4
+	if err := config.Validate(); err == nil {
+		t.Error("Expected LZ4 to be rejected with the default Version")
+	}
+	config.Version = V0_10_0_0
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	config.Producer.Compression = CompressionZSTD
+	if err := config.Validate(); err == nil {
+		t.Error("Expected ZSTD to be rejected with Version " + config.Version.String())
+	}
+	config.Version = V2_1_0_0
+	config.Producer.CompressionLevel = 19
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	config.Producer.Compression = CompressionGZIP
+	if err := config.Validate(); err == nil {
+		t.Error("Expected GZIP to reject compression level 19")
+	}
+	config.Producer.CompressionLevel = 9
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+	config.Producer.CompressionLevel = CompressionLevelDefault
+	if err := config.Validate(); err != nil {
+		t.Error("Expected GZIP to accept the default compression level, got", err)
+	}
+	config.Producer.CompressionLevel = CompressionLevelZero
+	if err := config.Validate(); err != nil {
+		t.Error("Expected GZIP to accept compression level 0, got", err)
+	}
+}
+
+func TestConsumerGroupConfigValidation(t *testing.T) {
+	config := NewConfig()
+	config.Consumer.Group.Heartbeat.Interval = config.Consumer.Group.Session.Timeout
+	if err := config.Validate(); err == nil {
+		t.Error("Expected a heartbeat interval as long as the session timeout to be rejected")
+	}
+	config.Consumer.Group.Heartbeat.Interval = time.Second
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	config.Consumer.Group.Rebalance.Timeout = time.Millisecond
+	if err := config.Validate(); err == nil {
+		t.Error("Expected a 1ms rebalance timeout to be rejected")
+	}
+}
+
+func TestSASLConfigValidation(t *testing.T) {
+	config := NewConfig()
+	config.Net.SASL.Enable = true
+	if err := config.Validate(); err == nil {
+		t.Error("Expected SASL without credentials to be rejected")
+	}
+
+	config.Net.SASL.User = "user"
+	if err := config.Validate(); err == nil {
+		t.Error("Expected SASL without a password to be rejected")
+	}
+
+	config.Net.SASL.Password = "secret"
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	config.Net.SASL.Mechanism = "GSSAPI"
+	if err := config.Validate(); err == nil {
+		t.Error("Expected an unsupported SASL mechanism to be rejected")
+	}
+
+	config.Net.SASL.Mechanism = SASLTypeSCRAMSHA512
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	config.Net.SASL.Mechanism = SASLTypeOAuth
+	config.Net.SASL.User, config.Net.SASL.Password = "", ""
+	if err := config.Validate(); err == nil {
+		t.Error("Expected OAUTHBEARER without a token provider to be rejected")
+	}
+}
+
+func TestIdempotentProducerConfigValidation(t *testing.T) {
+	config := NewConfig()
+	config.Producer.Idempotent = true
+	if err := config.Validate(); err == nil {
+		t.Error("Expected an idempotent producer to require Version >= 0.11")
+	}
+
+	config.Version = V0_11_0_0
+	if err := config.Validate(); err == nil {
+		t.Error("Expected an idempotent producer to require WaitForAll")
+	}
+
+	config.Producer.RequiredAcks = WaitForAll
+	if err := config.Validate(); err == nil {
+		t.Error("Expected an idempotent producer to require a single open request")
+	}
+
+	config.Net.MaxOpenRequests = 1
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTransactionalProducerConfigValidation(t *testing.T) {
+	config := NewConfig()
+	config.Producer.Transaction.ID = "txn"
+	if err := config.Validate(); err == nil {
+		t.Error("Expected a transactional producer to require an idempotent producer")
+	}
+
+	config.Version = V0_11_0_0
+	config.Producer.Idempotent = true
+	config.Producer.RequiredAcks = WaitForAll
+	config.Net.MaxOpenRequests = 1
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	config.Producer.Transaction.Timeout = 0
+	if err := config.Validate(); err == nil {
+		t.Error("Expected a transactional producer to require a timeout")
+	}
+}
+
+func TestReadCommittedConfigValidation(t *testing.T) {
+	config := NewConfig()
+	config.Consumer.IsolationLevel = ReadCommitted
+	if err := config.Validate(); err == nil {
+		t.Error("Expected reading committed messages to require Version >= 0.11")
+	}
+
+	config.Version = V0_11_0_0
+	if err := config.Validate(); err != nil {
+		t.Error(err)
+	}
+}
@@ -14,6 +14,8 @@ type ConsumerMessage struct {
 	Topic      string
 	Partition  int32
 	Offset     int64
+	Timestamp  time.Time      // only set if Kafka is version 0.10+
+	Headers    []RecordHeader // only set if Kafka is version 0.11+
 }
 
 // ConsumerError is what is provided to the user when an error occurs.
@@ -41,9 +43,9 @@ func (ce ConsumerErrors) Error() string {
 // on a consumer to avoid leaks, it will not be garbage-collected automatically when it passes out of
 // scope.
 //
-// Sarama's Consumer type does not currently support automatic consumer group rebalancing and offset tracking,
-// however the https://github.com/wvanbergen/kafka library builds on Sarama to add this support. We plan
-// to properly integrate this functionality at a later date.
+// Sarama's Consumer type does not do consumer group rebalancing and offset tracking by itself; for that,
+// see ConsumerGroup, which builds on a Consumer and an OffsetManager to share the partitions of a set of
+// topics among the members of a group coordinated by Kafka 0.9 or later.
 type Consumer interface {
 
 	// Topics returns the set of available topics as retrieved from the cluster
@@ -447,10 +449,10 @@ func (child *partitionConsumer) parseResponse(response *FetchResponse) ([]*Consu
 		return nil, block.Err
 	}
 
-	if len(block.MsgSet.Messages) == 0 {
+	if len(block.MsgSet.Messages) == 0 && len(block.RecordBatches) == 0 {
 		// We got no messages. If we got a trailing one then we need to ask for more data.
 		// Otherwise we just poll again and wait for one to be produced...
-		if block.MsgSet.PartialTrailingMessage {
+		if block.isPartial() {
 			if child.conf.Consumer.Fetch.Max > 0 && child.fetchSize == child.conf.Consumer.Fetch.Max {
 				// we can't ask for more data, we've hit the configured limit
 				child.sendError(ErrMessageTooLarge)
@@ -470,26 +472,42 @@ func (child *partitionConsumer) parseResponse(response *FetchResponse) ([]*Consu
 	child.fetchSize = child.conf.Consumer.Fetch.Default
 	atomic.StoreInt64(&child.highWaterMarkOffset, block.HighWaterMarkOffset)
 
+	startOffset := child.offset
 	incomplete := false
 	prelude := true
 	var messages []*ConsumerMessage
 	for _, msgBlock := range block.MsgSet.Messages {
+		inner := msgBlock.Messages()
 
-		for _, msg := range msgBlock.Messages() {
-			if prelude && msg.Offset < child.offset {
+		// The messages inside a compressed version 1 message have offsets relative to the first
+		// of them; the wrapper itself has the absolute offset of the last one.
+		var baseOffset int64
+		if msgBlock.Msg.Version >= 1 && msgBlock.Msg.Set != nil && len(inner) > 0 {
+			baseOffset = msgBlock.Offset - inner[len(inner)-1].Offset
+		}
+
+		for _, msg := range inner {
+			offset := baseOffset + msg.Offset
+			if prelude && offset < child.offset {
 				continue
 			}
 			prelude = false
 
-			if msg.Offset >= child.offset {
+			timestamp := msg.Msg.Timestamp
+			if msgBlock.Msg.LogAppendTime {
+				timestamp = msgBlock.Msg.Timestamp
+			}
+
+			if offset >= child.offset {
 				messages = append(messages, &ConsumerMessage{
 					Topic:     child.topic,
 					Partition: child.partition,
 					Key:       msg.Msg.Key,
 					Value:     msg.Msg.Value,
-					Offset:    msg.Offset,
+					Offset:    offset,
+					Timestamp: timestamp,
 				})
-				child.offset = msg.Offset + 1
+				child.offset = offset + 1
 			} else {
 				incomplete = true
 			}
@@ -497,7 +515,67 @@ func (child *partitionConsumer) parseResponse(response *FetchResponse) ([]*Consu
 
 	}
 
-	if incomplete || len(messages) == 0 {
+	// When reading committed messages, the broker returns the transactions aborted within the
+	// fetched range: from its first offset, the batches of the producer are to be skipped until
+	// the marker that ends the transaction.
+	var abortedTransactions []*AbortedTransaction
+	abortedProducerIDs := make(map[int64]none)
+	if child.conf.Consumer.IsolationLevel == ReadCommitted {
+		abortedTransactions = block.sortedAbortedTransactions()
+	}
+
+	for _, batch := range block.RecordBatches {
+		lastOffset := batch.FirstOffset + int64(batch.LastOffsetDelta)
+		for len(abortedTransactions) > 0 && abortedTransactions[0].FirstOffset <= lastOffset {
+			abortedProducerIDs[abortedTransactions[0].ProducerID] = none{}
+			abortedTransactions = abortedTransactions[1:]
+		}
+
+		skip := batch.Control
+		if batch.Control {
+			// control records (such as transaction markers) are not meant for the user, but they
+			// still take up offsets which we must skip over
+			delete(abortedProducerIDs, batch.ProducerID)
+		} else if _, aborted := abortedProducerIDs[batch.ProducerID]; aborted && batch.IsTransactional {
+			skip = true
+		}
+		if skip {
+			if lastOffset >= child.offset {
+				child.offset = lastOffset + 1
+			}
+			continue
+		}
+
+		for _, record := range batch.Records {
+			offset := batch.FirstOffset + record.OffsetDelta
+			if prelude && offset < child.offset {
+				continue
+			}
+			prelude = false
+
+			timestamp := batch.FirstTimestamp.Add(record.TimestampDelta)
+			if batch.LogAppendTime {
+				timestamp = batch.MaxTimestamp
+			}
+
+			if offset >= child.offset {
+				messages = append(messages, &ConsumerMessage{
+					Topic:     child.topic,
+					Partition: child.partition,
+					Key:       record.Key,
+					Value:     record.Value,
+					Offset:    offset,
+					Timestamp: timestamp,
+					Headers:   record.Headers,
+				})
+				child.offset = offset + 1
+			} else {
+				incomplete = true
+			}
+		}
+	}
+
+	if incomplete || child.offset == startOffset {
 		return nil, ErrIncompleteResponse
 	}
 	return messages, nil
@@ -677,14 +755,31 @@ func (bc *brokerConsumer) abort(err error) {
 }
 
 func (bc *brokerConsumer) fetchNewMessages() (*FetchResponse, error) {
+	var min int16
+	if bc.consumer.conf.Consumer.IsolationLevel == ReadCommitted {
+		min = 4 // the first version to carry the isolation level
+	}
+	version, err := bc.broker.requestVersion(1, min)
+	if err != nil {
+		return nil, err
+	}
+
 	request := &FetchRequest{
 		MinBytes:    bc.consumer.conf.Consumer.Fetch.Min,
 		MaxWaitTime: int32(bc.consumer.conf.Consumer.MaxWaitTime / time.Millisecond),
+		MaxBytes:    MaxResponseSize,
+		Isolation:   bc.consumer.conf.Consumer.IsolationLevel,
+		IVersion:    version,
 	}
 
 	for child := range bc.subscriptions {
 		request.AddBlock(child.topic, child.partition, child.offset, child.fetchSize)
 	}
 
-	return bc.broker.Fetch(request)
+	response, err := bc.broker.Fetch(request)
+	if err == nil && response.Err != ErrNoError {
+		// only possible with fetch sessions, which we never open
+		return nil, response.Err
+	}
+	return response, err
 }
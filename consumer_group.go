@@ -0,0 +1,659 @@
+package sarama
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConsumerGroupNotification describes how the partitions claimed by a member of a consumer group
+// changed in a rebalance.
+type ConsumerGroupNotification struct {
+	// the partitions claimed in this generation that were not claimed in the previous one
+	Claimed map[string][]int32
+	// the partitions claimed in the previous generation that are not claimed in this one
+	Released map[string][]int32
+	// every partition claimed in this generation
+	Current map[string][]int32
+}
+
+// ConsumerGroup consumes the partitions of a set of topics as a member of a consumer group coordinated
+// by Kafka, which shares the partitions out among the members of the group and rebalances them as
+// members come and go. This requires Kafka 0.9 or later. You MUST call Close() on a consumer group to
+// avoid leaks, it will not be garbage-collected automatically when it passes out of scope; closing also
+// lets the remaining members take over its partitions without waiting for its session to time out.
+//
+// Messages of every partition claimed by the member are returned on a single Messages channel. Offsets
+// marked as processed are committed in the background, like with an OffsetManager, and always before
+// their partitions are released in a rebalance, so that the next member to claim a partition resumes
+// where the previous one left off.
+type ConsumerGroup interface {
+	// Messages returns the read channel for the messages of every partition claimed
+	// by this member.
+	Messages() <-chan *ConsumerMessage
+
+	// Errors returns a read channel of errors that occurred while consuming or
+	// while managing membership of the group, if enabled. By default, errors are
+	// logged and not returned over this channel. If you want to implement any
+	// custom error handling, set your config's Consumer.Return.Errors setting to
+	// true, and read from this channel.
+	Errors() <-chan error
+
+	// Notifications returns a read channel of the changes in the partitions claimed
+	// by this member, if enabled. To enable them, set your config's
+	// Consumer.Group.Return.Notifications setting to true, and read from this
+	// channel.
+	Notifications() <-chan *ConsumerGroupNotification
+
+	// MarkOffset marks the provided message as processed, alongside a metadata
+	// string, see PartitionOffsetManager.MarkOffset. Messages of partitions that
+	// are no longer claimed by this member are ignored.
+	MarkOffset(msg *ConsumerMessage, metadata string)
+
+	// MarkPartitionOffset marks the provided offset of a topic/partition as
+	// processed, alongside a metadata string, see MarkOffset.
+	MarkPartitionOffset(topic string, partition int32, offset int64, metadata string)
+
+	// Close releases the partitions claimed by this member, commits their marked
+	// offsets and leaves the group. It is required to call this function before a
+	// consumer group object passes out of scope, as it will otherwise leak memory.
+	// You must call this before calling Close on the underlying client. Calling it
+	// again returns ErrClosedConsumerGroup.
+	Close() error
+}
+
+// the protocol type of consumer groups whose members exchange ConsumerGroupMemberMetadata and
+// ConsumerGroupMemberAssignment
+const consumerGroupProtocolType = "consumer"
+
+type consumerGroup struct {
+	client    Client
+	conf      *Config
+	ownClient bool
+	consumer  Consumer
+	group     string
+	topics    []string
+
+	memberID     string
+	generationID int32
+	userData     []byte // the strategy's user data for the next time we join
+
+	lock       sync.Mutex
+	claims     map[string]map[int32]*consumerGroupClaim
+	forwarders sync.WaitGroup
+	released   map[string][]int32 // the partitions claimed in the previous generation
+
+	messages      chan *ConsumerMessage
+	errors        chan error
+	notifications chan *ConsumerGroupNotification
+	closed        int32
+	dying, dead   chan none
+}
+
+// NewConsumerGroup creates a new consumer group member consuming the given topics, using a new client
+// with the given broker addresses and configuration.
+func NewConsumerGroup(addrs []string, group string, topics []string, config *Config) (ConsumerGroup, error) {
+	client, err := NewClient(addrs, config)
+	if err != nil {
+		return nil, err
+	}
+
+	cg, err := NewConsumerGroupFromClient(group, topics, client)
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	cg.(*consumerGroup).ownClient = true
+	return cg, nil
+}
+
+// NewConsumerGroupFromClient creates a new consumer group member consuming the given topics, using the
+// given client. It is still necessary to call Close() on the underlying client when shutting down this
+// consumer group.
+func NewConsumerGroupFromClient(group string, topics []string, client Client) (ConsumerGroup, error) {
+	// Check that we are not dealing with a closed Client before processing any other arguments
+	if client.Closed() {
+		return nil, ErrClosedClient
+	}
+
+	conf := client.Config()
+	switch {
+	case !conf.Version.IsAtLeast(V0_9_0_0):
+		return nil, ConfigurationError("consumer groups require Version >= " + V0_9_0_0.String())
+	case group == "":
+		return nil, ConfigurationError("the consumer group must have a name")
+	case len(topics) == 0:
+		return nil, ConfigurationError("the consumer group must consume at least one topic")
+	}
+
+	consumer, err := NewConsumerFromClient(client)
+	if err != nil {
+		return nil, err
+	}
+
+	cg := &consumerGroup{
+		client:        client,
+		conf:          conf,
+		consumer:      consumer,
+		group:         group,
+		topics:        topics,
+		generationID:  GroupGenerationUndefined,
+		claims:        make(map[string]map[int32]*consumerGroupClaim),
+		messages:      make(chan *ConsumerMessage, conf.ChannelBufferSize),
+		errors:        make(chan error, conf.ChannelBufferSize),
+		notifications: make(chan *ConsumerGroupNotification, 1),
+		dying:         make(chan none),
+		dead:          make(chan none),
+	}
+
+	go withRecover(cg.mainLoop)
+
+	return cg, nil
+}
+
+func (cg *consumerGroup) Messages() <-chan *ConsumerMessage {
+	return cg.messages
+}
+
+func (cg *consumerGroup) Errors() <-chan error {
+	return cg.errors
+}
+
+func (cg *consumerGroup) Notifications() <-chan *ConsumerGroupNotification {
+	return cg.notifications
+}
+
+func (cg *consumerGroup) MarkOffset(msg *ConsumerMessage, metadata string) {
+	cg.MarkPartitionOffset(msg.Topic, msg.Partition, msg.Offset, metadata)
+}
+
+func (cg *consumerGroup) MarkPartitionOffset(topic string, partition int32, offset int64, metadata string) {
+	cg.lock.Lock()
+	defer cg.lock.Unlock()
+
+	if claim := cg.claims[topic][partition]; claim != nil {
+		claim.offsets.MarkOffset(offset, metadata)
+	}
+}
+
+func (cg *consumerGroup) Close() error {
+	if !atomic.CompareAndSwapInt32(&cg.closed, 0, 1) {
+		return ErrClosedConsumerGroup
+	}
+	close(cg.dying)
+
+	go withRecover(func() {
+		for _ = range cg.messages {
+			// drain
+		}
+	})
+
+	var errors ConsumerErrors
+	for err := range cg.errors {
+		if cErr, ok := err.(*ConsumerError); ok {
+			errors = append(errors, cErr)
+		} else {
+			errors = append(errors, &ConsumerError{Topic: "", Partition: -1, Err: err})
+		}
+	}
+	<-cg.dead
+
+	if cg.ownClient {
+		if err := cg.client.Close(); err != nil {
+			return err
+		}
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+	return nil
+}
+
+func (cg *consumerGroup) handleError(err error) {
+	if cg.conf.Consumer.Return.Errors {
+		cg.errors <- err
+	} else {
+		Logger.Printf("consumer-group/%s %s\n", cg.group, err)
+	}
+}
+
+func (cg *consumerGroup) mainLoop() {
+	defer close(cg.dead)
+
+	for {
+		select {
+		case <-cg.dying:
+			cg.leave()
+			close(cg.messages)
+			close(cg.errors)
+			close(cg.notifications)
+			return
+		default:
+		}
+
+		switch err := cg.rebalance(); err {
+		case nil:
+			cg.heartbeatLoop()
+			cg.release()
+		case ErrRebalanceInProgress, ErrUnknownMemberID, ErrIllegalGeneration:
+			// part of the normal course of a rebalance, rejoin right away
+			Logger.Printf("consumer-group/%s rejoining: %s\n", cg.group, err)
+		default:
+			cg.handleError(err)
+			select {
+			case <-cg.dying:
+			case <-time.After(cg.conf.Consumer.Group.Rebalance.Retry.Backoff):
+			}
+		}
+	}
+}
+
+// rebalance joins the group, agrees on an assignment of partitions to members (computing it if this
+// member is chosen as the leader), and starts consuming the partitions assigned to this member.
+func (cg *consumerGroup) rebalance() error {
+	coordinator, err := cg.client.Coordinator(cg.group)
+	if err != nil {
+		return err
+	}
+
+	join, err := cg.joinGroup(coordinator)
+	if err != nil {
+		cg.abandonCoordinator(coordinator)
+		return err
+	}
+	if err = cg.checkGroupError(join.Err); err != nil {
+		return err
+	}
+	cg.memberID, cg.generationID = join.MemberID, join.GenerationID
+	Logger.Printf("consumer-group/%s joined generation %d as %s\n", cg.group, cg.generationID, cg.memberID)
+
+	var plan BalanceStrategyPlan
+	if join.LeaderID == join.MemberID {
+		if plan, err = cg.plan(join); err != nil {
+			return err
+		}
+	}
+
+	sync, err := cg.syncGroup(coordinator, plan)
+	if err != nil {
+		cg.abandonCoordinator(coordinator)
+		return err
+	}
+	if err = cg.checkGroupError(sync.Err); err != nil {
+		return err
+	}
+
+	assignment, err := sync.GetMemberAssignment()
+	if err != nil {
+		return err
+	}
+	cg.userData, err = cg.conf.Consumer.Group.Rebalance.Strategy.AssignmentData(cg.memberID, assignment.Topics, cg.generationID)
+	if err != nil {
+		return err
+	}
+	return cg.claim(assignment.Topics)
+}
+
+func (cg *consumerGroup) joinGroup(coordinator *Broker) (*JoinGroupResponse, error) {
+	version, err := coordinator.requestVersion(11, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &JoinGroupRequest{
+		GroupID:          cg.group,
+		SessionTimeout:   int32(cg.conf.Consumer.Group.Session.Timeout / time.Millisecond),
+		RebalanceTimeout: int32(cg.conf.Consumer.Group.Rebalance.Timeout / time.Millisecond),
+		MemberID:         cg.memberID,
+		ProtocolType:     consumerGroupProtocolType,
+		IVersion:         version,
+	}
+	err = request.AddGroupProtocolMetadata(cg.conf.Consumer.Group.Rebalance.Strategy.Name(), &ConsumerGroupMemberMetadata{
+		Topics:   cg.topics,
+		UserData: cg.userData,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return coordinator.JoinGroup(request)
+}
+
+func (cg *consumerGroup) syncGroup(coordinator *Broker, plan BalanceStrategyPlan) (*SyncGroupResponse, error) {
+	version, err := coordinator.requestVersion(14, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &SyncGroupRequest{
+		GroupID:      cg.group,
+		GenerationID: cg.generationID,
+		MemberID:     cg.memberID,
+		IVersion:     version,
+	}
+	for memberID, topics := range plan {
+		err := request.AddGroupAssignmentMember(memberID, &ConsumerGroupMemberAssignment{Topics: topics})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return coordinator.SyncGroup(request)
+}
+
+// checkGroupError handles the errors common to the responses of the coordinator
+func (cg *consumerGroup) checkGroupError(err KError) error {
+	switch err {
+	case ErrNoError:
+		return nil
+	case ErrUnknownMemberID:
+		// the coordinator forgot about us, join as a new member
+		cg.memberID = ""
+	case ErrNotCoordinatorForConsumer, ErrConsumerCoordinatorNotAvailable:
+		if refreshErr := cg.client.RefreshCoordinator(cg.group); refreshErr != nil {
+			return refreshErr
+		}
+	}
+	return err
+}
+
+func (cg *consumerGroup) abandonCoordinator(coordinator *Broker) {
+	_ = coordinator.Close() // we don't care about the error this might return, we already have one
+	_ = cg.client.RefreshCoordinator(cg.group)
+}
+
+// plan assigns the partitions of the topics the members subscribe to with the configured strategy,
+// for the leader to hand out.
+func (cg *consumerGroup) plan(join *JoinGroupResponse) (BalanceStrategyPlan, error) {
+	strategy := cg.conf.Consumer.Group.Rebalance.Strategy
+	if join.GroupProtocol != strategy.Name() {
+		// we only offer the one strategy, so this is the coordinator's mistake
+		return nil, ErrInconsistentGroupProtocol
+	}
+
+	members, err := join.GetMembers()
+	if err != nil {
+		return nil, err
+	}
+
+	var subscribed []string
+	for _, meta := range members {
+		for _, topic := range meta.Topics {
+			if !containsString(subscribed, topic) {
+				subscribed = append(subscribed, topic)
+			}
+		}
+	}
+	if err := cg.client.RefreshMetadata(subscribed...); err != nil {
+		return nil, err
+	}
+
+	topics := make(map[string][]int32, len(subscribed))
+	for _, topic := range subscribed {
+		partitions, err := cg.client.Partitions(topic)
+		if err == ErrUnknownTopicOrPartition {
+			Logger.Printf("consumer-group/%s not assigning unknown topic %s\n", cg.group, topic)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		topics[topic] = partitions
+	}
+
+	return strategy.Plan(members, topics)
+}
+
+// heartbeatLoop keeps the membership of the group alive until the group rebalances, the session
+// times out, or the consumer group is closed.
+func (cg *consumerGroup) heartbeatLoop() {
+	ticker := time.NewTicker(cg.conf.Consumer.Group.Heartbeat.Interval)
+	defer ticker.Stop()
+
+	lastHeartbeat := time.Now()
+	for {
+		select {
+		case <-cg.dying:
+			return
+		case <-ticker.C:
+		}
+
+		err := cg.heartbeat()
+		switch err {
+		case nil:
+			lastHeartbeat = time.Now()
+			continue
+		case ErrRebalanceInProgress, ErrUnknownMemberID, ErrIllegalGeneration:
+			Logger.Printf("consumer-group/%s rebalancing: %s\n", cg.group, err)
+			return
+		}
+
+		cg.handleError(err)
+		if time.Since(lastHeartbeat) >= cg.conf.Consumer.Group.Session.Timeout {
+			// the coordinator will have given up on us by now
+			return
+		}
+	}
+}
+
+func (cg *consumerGroup) heartbeat() error {
+	coordinator, err := cg.client.Coordinator(cg.group)
+	if err != nil {
+		return err
+	}
+
+	version, err := coordinator.requestVersion(12, 0)
+	if err != nil {
+		return err
+	}
+
+	response, err := coordinator.Heartbeat(&HeartbeatRequest{
+		GroupID:      cg.group,
+		GenerationID: cg.generationID,
+		MemberID:     cg.memberID,
+		IVersion:     version,
+	})
+	if err != nil {
+		cg.abandonCoordinator(coordinator)
+		return err
+	}
+	return cg.checkGroupError(response.Err)
+}
+
+func (cg *consumerGroup) leave() {
+	if cg.memberID == "" {
+		return
+	}
+
+	coordinator, err := cg.client.Coordinator(cg.group)
+	if err != nil {
+		cg.handleError(err)
+		return
+	}
+
+	version, err := coordinator.requestVersion(13, 0)
+	if err != nil {
+		cg.handleError(err)
+		return
+	}
+
+	response, err := coordinator.LeaveGroup(&LeaveGroupRequest{
+		GroupID:  cg.group,
+		MemberID: cg.memberID,
+		IVersion: version,
+	})
+	if err != nil {
+		cg.handleError(err)
+	} else if response.Err != ErrNoError && response.Err != ErrUnknownMemberID {
+		cg.handleError(response.Err)
+	}
+	cg.memberID = ""
+}
+
+// Claims
+
+// consumerGroupClaim is a partition assigned to this member of the group
+type consumerGroupClaim struct {
+	topic     string
+	partition int32
+	consumer  PartitionConsumer
+	offsets   PartitionOffsetManager
+	released  chan none
+}
+
+// claim starts consuming the partitions of an assignment, from the offsets committed by the previous
+// owner of each partition.
+func (cg *consumerGroup) claim(topics map[string][]int32) error {
+	offsets, err := newOffsetManagerFromClient(cg.group, cg.memberID, cg.generationID, cg.client)
+	if err != nil {
+		return err
+	}
+
+	previous := cg.released
+	cg.released = nil
+
+	for topic, partitions := range topics {
+		for _, partition := range partitions {
+			claim, err := cg.newClaim(offsets, topic, partition)
+			if err != nil {
+				cg.release()
+				cg.released = previous
+				return err
+			}
+
+			cg.lock.Lock()
+			if cg.claims[topic] == nil {
+				cg.claims[topic] = make(map[int32]*consumerGroupClaim)
+			}
+			cg.claims[topic][partition] = claim
+			cg.lock.Unlock()
+
+			cg.forwarders.Add(1)
+			go withRecover(func() { cg.forward(claim) })
+		}
+	}
+
+	if cg.conf.Consumer.Group.Return.Notifications {
+		cg.notify(previous, topics)
+	}
+	return nil
+}
+
+func (cg *consumerGroup) newClaim(offsets *offsetManager, topic string, partition int32) (*consumerGroupClaim, error) {
+	pom, err := offsets.ManagePartition(topic, partition)
+	if err != nil {
+		return nil, err
+	}
+
+	offset, _ := pom.NextOffset()
+	pc, err := cg.consumer.ConsumePartition(topic, partition, offset)
+	if err == ErrOffsetOutOfRange {
+		Logger.Printf("consumer-group/%s offset %d of %s/%d is out of range, starting over\n", cg.group, offset, topic, partition)
+		pc, err = cg.consumer.ConsumePartition(topic, partition, cg.conf.Consumer.Offsets.Initial)
+	}
+	if err != nil {
+		_ = pom.Close()
+		return nil, err
+	}
+
+	return &consumerGroupClaim{
+		topic:     topic,
+		partition: partition,
+		consumer:  pc,
+		offsets:   pom,
+		released:  make(chan none),
+	}, nil
+}
+
+// forward feeds the messages and errors of a claimed partition to those of the group, until the
+// partition is released and its consumer and offset manager are closed.
+func (cg *consumerGroup) forward(claim *consumerGroupClaim) {
+	defer cg.forwarders.Done()
+
+	messages, errors, offsetErrors := claim.consumer.Messages(), claim.consumer.Errors(), claim.offsets.Errors()
+	for messages != nil || errors != nil || offsetErrors != nil {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				messages = nil
+				continue
+			}
+			select {
+			case cg.messages <- msg:
+			case <-claim.released:
+				// the partition is no longer ours, drop what is left
+			}
+		case err, ok := <-errors:
+			if !ok {
+				errors = nil
+				continue
+			}
+			cg.handleError(err)
+		case err, ok := <-offsetErrors:
+			if !ok {
+				offsetErrors = nil
+				continue
+			}
+			cg.handleError(err)
+		}
+	}
+}
+
+// release stops consuming every claimed partition, and waits for their marked offsets to be
+// committed.
+func (cg *consumerGroup) release() {
+	cg.lock.Lock()
+	claims := cg.claims
+	cg.claims = make(map[string]map[int32]*consumerGroupClaim)
+	cg.lock.Unlock()
+
+	cg.released = make(map[string][]int32)
+	for topic, partitions := range claims {
+		for partition, claim := range partitions {
+			close(claim.released)
+			claim.consumer.AsyncClose()
+			claim.offsets.AsyncClose()
+			cg.released[topic] = append(cg.released[topic], partition)
+		}
+	}
+
+	cg.forwarders.Wait()
+}
+
+func (cg *consumerGroup) notify(previous, current map[string][]int32) {
+	notification := &ConsumerGroupNotification{
+		Claimed:  make(map[string][]int32),
+		Released: make(map[string][]int32),
+		Current:  make(map[string][]int32),
+	}
+
+	for topic, partitions := range current {
+		for _, partition := range partitions {
+			notification.Current[topic] = append(notification.Current[topic], partition)
+			if !containsPartition(previous[topic], partition) {
+				notification.Claimed[topic] = append(notification.Claimed[topic], partition)
+			}
+		}
+	}
+	for topic, partitions := range previous {
+		for _, partition := range partitions {
+			if !containsPartition(current[topic], partition) {
+				notification.Released[topic] = append(notification.Released[topic], partition)
+			}
+		}
+	}
+
+	select {
+	case cg.notifications <- notification:
+	case <-cg.dying:
+	}
+}
+
+func containsPartition(partitions []int32, partition int32) bool {
+	for _, p := range partitions {
+		if p == partition {
+			return true
+		}
+	}
+	return false
+}
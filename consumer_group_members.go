@@ -0,0 +1,94 @@
+package sarama
+
+// ConsumerGroupMemberMetadata is the metadata a member of a consumer group sends along with each
+// group protocol it supports when joining, as laid out by the "consumer" protocol type.
+type ConsumerGroupMemberMetadata struct {
+	Version  int16
+	Topics   []string
+	UserData []byte
+}
+
+func (m *ConsumerGroupMemberMetadata) Encode(pe packetEncoder) error {
+	pe.putInt16(m.Version)
+
+	if err := pe.putArrayLength(len(m.Topics)); err != nil {
+		return err
+	}
+	for _, topic := range m.Topics {
+		if err := pe.putString(topic); err != nil {
+			return err
+		}
+	}
+
+	return pe.putBytes(m.UserData)
+}
+
+func (m *ConsumerGroupMemberMetadata) Decode(pd packetDecoder) (err error) {
+	if m.Version, err = pd.getInt16(); err != nil {
+		return err
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	m.Topics = make([]string, n)
+	for i := range m.Topics {
+		if m.Topics[i], err = pd.getString(); err != nil {
+			return err
+		}
+	}
+
+	m.UserData, err = pd.getBytes()
+	return err
+}
+
+// ConsumerGroupMemberAssignment is the set of partitions the leader of a consumer group assigns to a
+// member, as laid out by the "consumer" protocol type.
+type ConsumerGroupMemberAssignment struct {
+	Version  int16
+	Topics   map[string][]int32
+	UserData []byte
+}
+
+func (m *ConsumerGroupMemberAssignment) Encode(pe packetEncoder) error {
+	pe.putInt16(m.Version)
+
+	if err := pe.putArrayLength(len(m.Topics)); err != nil {
+		return err
+	}
+	for topic, partitions := range m.Topics {
+		if err := pe.putString(topic); err != nil {
+			return err
+		}
+		if err := pe.putInt32Array(partitions); err != nil {
+			return err
+		}
+	}
+
+	return pe.putBytes(m.UserData)
+}
+
+func (m *ConsumerGroupMemberAssignment) Decode(pd packetDecoder) (err error) {
+	if m.Version, err = pd.getInt16(); err != nil {
+		return err
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	m.Topics = make(map[string][]int32, n)
+	for i := 0; i < n; i++ {
+		topic, err := pd.getString()
+		if err != nil {
+			return err
+		}
+		if m.Topics[topic], err = pd.getInt32Array(); err != nil {
+			return err
+		}
+	}
+
+	m.UserData, err = pd.getBytes()
+	return err
+}
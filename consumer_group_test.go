@@ -0,0 +1,326 @@
+package sarama
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// newConsumerGroupTestHandlers answers for a group coordinated by broker, which leads both
+// partitions of my_topic and joins and syncs members with the given responses.
+func newConsumerGroupTestHandlers(t *testing.T, broker *mockBroker, join *JoinGroupResponse, sync *SyncGroupResponse) map[string]MockResponse {
+	return map[string]MockResponse{
+		"MetadataRequest": newMockMetadataResponse(t).
+			SetBroker(broker.Addr(), broker.BrokerID()).
+			SetLeader("my_topic", 0, broker.BrokerID()).
+			SetLeader("my_topic", 1, broker.BrokerID()),
+		"ConsumerMetadataRequest": newMockConsumerMetadataResponse(t).
+			SetCoordinator("my_group", broker),
+		"JoinGroupRequest":  newMockWrapper(join),
+		"SyncGroupRequest":  newMockWrapper(sync),
+		"HeartbeatRequest":  newMockWrapper(&HeartbeatResponse{}),
+		"LeaveGroupRequest": newMockWrapper(&LeaveGroupResponse{}),
+		"OffsetFetchRequest": newMockOffsetFetchResponse(t).
+			SetOffset("my_group", "my_topic", 0, 5, "", ErrNoError).
+			SetOffset("my_group", "my_topic", 1, -1, "", ErrNoError),
+		"OffsetCommitRequest": newMockOffsetCommitResponse(t),
+		"OffsetRequest": newMockOffsetResponse(t).
+			SetOffset("my_topic", 0, OffsetOldest, 0).
+			SetOffset("my_topic", 0, OffsetNewest, 10).
+			SetOffset("my_topic", 1, OffsetOldest, 0).
+			SetOffset("my_topic", 1, OffsetNewest, 10),
+		"FetchRequest": newMockFetchResponse(t, 1).
+			SetMessage("my_topic", 0, 6, ByteEncoder([]byte{0x00, 0x0E})).
+			SetMessage("my_topic", 1, 0, ByteEncoder([]byte{0x00, 0x0E})).
+			SetHighWaterMark("my_topic", 0, 7).
+			SetHighWaterMark("my_topic", 1, 1),
+	}
+}
+
+func TestConsumerGroupLeaderConsumesItsAssignment(t *testing.T) {
+	// Given
+	join := &JoinGroupResponse{GenerationID: 1, GroupProtocol: "range", LeaderID: "m1", MemberID: "m1"}
+	if err := join.AddMember("m1", &ConsumerGroupMemberMetadata{Topics: []string{"my_topic"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := join.AddMember("m2", &ConsumerGroupMemberMetadata{Topics: []string{"my_topic"}}); err != nil {
+		t.Fatal(err)
+	}
+	sync := new(SyncGroupResponse)
+	if err := sync.SetMemberAssignment(&ConsumerGroupMemberAssignment{Topics: map[string][]int32{"my_topic": {0, 1}}}); err != nil {
+		t.Fatal(err)
+	}
+	broker := newMockBroker(t, 0)
+	broker.SetHandlerByMap(newConsumerGroupTestHandlers(t, broker, join, sync))
+
+	config := NewConfig()
+	config.Version = V0_9_0_0
+	config.Metadata.Retry.Max = 0
+	config.Consumer.Return.Errors = true
+	config.Consumer.Offsets.Initial = OffsetOldest
+	config.Consumer.Offsets.CommitInterval = 10 * time.Millisecond
+	config.Consumer.Group.Heartbeat.Interval = 10 * time.Millisecond
+	config.Consumer.Group.Return.Notifications = true
+
+	// When
+	cg, err := NewConsumerGroup([]string{broker.Addr()}, "my_group", []string{"my_topic"}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Then: the member is notified of its claims...
+	select {
+	case notification := <-cg.Notifications():
+		expected := map[string][]int32{"my_topic": {0, 1}}
+		if !reflect.DeepEqual(notification.Current, expected) || !reflect.DeepEqual(notification.Claimed, expected) {
+			t.Error("Unexpected notification", notification)
+		}
+		if len(notification.Released) != 0 {
+			t.Error("Expected nothing to be released, got", notification.Released)
+		}
+	case err := <-cg.Errors():
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for a notification")
+	}
+
+	// ...consumes each claimed partition from its committed offset, or the initial offset if there is none...
+	offsets := make(map[int32]int64)
+	for len(offsets) < 2 {
+		select {
+		case msg := <-cg.Messages():
+			offsets[msg.Partition] = msg.Offset
+			cg.MarkOffset(msg, "")
+		case err := <-cg.Errors():
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for messages")
+		}
+	}
+	if !reflect.DeepEqual(offsets, map[int32]int64{0: 6, 1: 0}) {
+		t.Error("Unexpected offsets consumed", offsets)
+	}
+
+	safeClose(t, cg)
+
+	// ...and, as the leader, assigned the partitions in ranges among every member.
+	var syncRequest *SyncGroupRequest
+	var committed, left bool
+	for _, rr := range broker.History() {
+		switch req := rr.Request.(type) {
+		case *SyncGroupRequest:
+			syncRequest = req
+		case *OffsetCommitRequest:
+			committed = committed || req.ConsumerGroupGeneration == 1 && req.ConsumerID == "m1"
+		case *LeaveGroupRequest:
+			left = req.GroupID == "my_group" && req.MemberID == "m1"
+		}
+	}
+	if syncRequest == nil {
+		t.Fatal("The group was never synced")
+	}
+	for memberID, partitions := range map[string][]int32{"m1": {0}, "m2": {1}} {
+		assignment := new(ConsumerGroupMemberAssignment)
+		if err := Decode(syncRequest.GroupAssignments[memberID], assignment); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(assignment.Topics, map[string][]int32{"my_topic": partitions}) {
+			t.Error("Unexpected assignment for", memberID, assignment.Topics)
+		}
+	}
+	if !committed {
+		t.Error("Expected the marked offsets to be committed with the generation and member ID")
+	}
+	if !left {
+		t.Error("Expected the member to leave the group on close")
+	}
+	if err := cg.Close(); err != ErrClosedConsumerGroup {
+		t.Error("Expected ErrClosedConsumerGroup when closing again, got", err)
+	}
+
+	broker.Close()
+}
+
+func TestConsumerGroupFollowerDoesNotAssign(t *testing.T) {
+	// Given
+	join := &JoinGroupResponse{GenerationID: 1, GroupProtocol: "range", LeaderID: "m1", MemberID: "m2"}
+	sync := new(SyncGroupResponse)
+	if err := sync.SetMemberAssignment(&ConsumerGroupMemberAssignment{Topics: map[string][]int32{"my_topic": {1}}}); err != nil {
+		t.Fatal(err)
+	}
+	broker := newMockBroker(t, 0)
+	broker.SetHandlerByMap(newConsumerGroupTestHandlers(t, broker, join, sync))
+
+	// When
+	config := NewConfig()
+	config.Version = V0_9_0_0
+	config.Metadata.Retry.Max = 0
+	config.Consumer.Return.Errors = true
+	config.Consumer.Offsets.Initial = OffsetOldest
+	config.Consumer.Offsets.CommitInterval = 10 * time.Millisecond
+	config.Consumer.Group.Heartbeat.Interval = 10 * time.Millisecond
+	cg, err := NewConsumerGroup([]string{broker.Addr()}, "my_group", []string{"my_topic"}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Then
+	select {
+	case msg := <-cg.Messages():
+		if msg.Partition != 1 {
+			t.Error("Consumed a partition that was not assigned", msg.Partition)
+		}
+	case err := <-cg.Errors():
+		t.Fatal(err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for messages")
+	}
+
+	safeClose(t, cg)
+
+	for _, rr := range broker.History() {
+		if req, ok := rr.Request.(*SyncGroupRequest); ok && len(req.GroupAssignments) != 0 {
+			t.Error("A follower sent assignments", req.GroupAssignments)
+		}
+	}
+
+	broker.Close()
+}
+
+func TestConsumerGroupRebalances(t *testing.T) {
+	// Given a member first assigned both partitions
+	join := &JoinGroupResponse{GenerationID: 1, GroupProtocol: "range", LeaderID: "m1", MemberID: "m2"}
+	sync := new(SyncGroupResponse)
+	if err := sync.SetMemberAssignment(&ConsumerGroupMemberAssignment{Topics: map[string][]int32{"my_topic": {0, 1}}}); err != nil {
+		t.Fatal(err)
+	}
+	broker := newMockBroker(t, 0)
+	broker.SetHandlerByMap(newConsumerGroupTestHandlers(t, broker, join, sync))
+	defer broker.Close()
+
+	config := NewConfig()
+	config.Version = V0_9_0_0
+	config.Metadata.Retry.Max = 0
+	config.Consumer.Return.Errors = true
+	config.Consumer.Offsets.Initial = OffsetOldest
+	config.Consumer.Offsets.CommitInterval = 10 * time.Millisecond
+	config.Consumer.Group.Heartbeat.Interval = 10 * time.Millisecond
+	config.Consumer.Group.Return.Notifications = true
+	cg, err := NewConsumerGroup([]string{broker.Addr()}, "my_group", []string{"my_topic"}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer safeClose(t, cg)
+
+	expectNotification := func() *ConsumerGroupNotification {
+		select {
+		case notification := <-cg.Notifications():
+			return notification
+		case err := <-cg.Errors():
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for a notification")
+		}
+		return nil
+	}
+	expectMessage := func() *ConsumerMessage {
+		select {
+		case msg := <-cg.Messages():
+			return msg
+		case err := <-cg.Errors():
+			t.Fatal(err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Timed out waiting for a message")
+		}
+		return nil
+	}
+
+	if notification := expectNotification(); !reflect.DeepEqual(notification.Claimed, map[string][]int32{"my_topic": {0, 1}}) {
+		t.Fatal("Unexpected first notification", notification)
+	}
+	for consumed := make(map[int32]bool); len(consumed) < 2; {
+		msg := expectMessage()
+		consumed[msg.Partition] = true
+		cg.MarkOffset(msg, "")
+	}
+
+	// When another member joins: the coordinator answers heartbeats with a rebalance, and this
+	// member is left with partition 1 in the next generation
+	rejoin := &JoinGroupResponse{GenerationID: 2, GroupProtocol: "range", LeaderID: "m1", MemberID: "m2"}
+	resync := new(SyncGroupResponse)
+	if err := resync.SetMemberAssignment(&ConsumerGroupMemberAssignment{Topics: map[string][]int32{"my_topic": {1}}}); err != nil {
+		t.Fatal(err)
+	}
+	handlers := newConsumerGroupTestHandlers(t, broker, rejoin, resync)
+	handlers["HeartbeatRequest"] = newMockSequence(
+		newMockWrapper(&HeartbeatResponse{Err: ErrRebalanceInProgress}),
+		newMockWrapper(&HeartbeatResponse{}))
+	broker.SetHandlerByMap(handlers)
+
+	// Then partition 0 is reported released and partition 1 kept...
+	notification := expectNotification()
+	if !reflect.DeepEqual(notification.Released, map[string][]int32{"my_topic": {0}}) ||
+		len(notification.Claimed) != 0 ||
+		!reflect.DeepEqual(notification.Current, map[string][]int32{"my_topic": {1}}) {
+		t.Error("Unexpected notification after the rebalance", notification)
+	}
+
+	// ...partition 1 is consumed again under the new claim...
+	if msg := expectMessage(); msg.Partition != 1 {
+		t.Error("Consumed a partition that is no longer assigned", msg.Partition)
+	}
+
+	// ...and the offset marked on partition 0 was committed before rejoining the group.
+	committed := false
+	for _, rr := range broker.History() {
+		switch req := rr.Request.(type) {
+		case *OffsetCommitRequest:
+			if block := req.Blocks["my_topic"][0]; block != nil && block.Offset == 6 && req.ConsumerGroupGeneration == 1 {
+				committed = true
+			}
+		case *JoinGroupRequest:
+			if req.MemberID == "m2" && !committed {
+				t.Error("Expected the marked offset of partition 0 to be committed before rejoining")
+			}
+		}
+	}
+	if !committed {
+		t.Error("Expected the marked offset of partition 0 to be committed")
+	}
+}
+
+func TestNewConsumerGroupFromClientValidation(t *testing.T) {
+	seedBroker := newMockBroker(t, 1)
+	seedBroker.Returns(new(MetadataResponse))
+	seedBroker.Returns(new(MetadataResponse))
+
+	config := NewConfig()
+	config.Version = V0_8_2_0
+	client, err := NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewConsumerGroupFromClient("my_group", []string{"my_topic"}, client); err == nil {
+		t.Error("Expected an error for a Kafka version without consumer groups")
+	}
+	safeClose(t, client)
+
+	config.Version = V0_9_0_0
+	client, err = NewClient([]string{seedBroker.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewConsumerGroupFromClient("", []string{"my_topic"}, client); err == nil {
+		t.Error("Expected an error for a group without a name")
+	}
+	if _, err := NewConsumerGroupFromClient("my_group", nil, client); err == nil {
+		t.Error("Expected an error for a group without topics")
+	}
+	safeClose(t, client)
+
+	if _, err := NewConsumerGroupFromClient("my_group", []string{"my_topic"}, client); err != ErrClosedClient {
+		t.Error("Expected ErrClosedClient, got", err)
+	}
+
+	seedBroker.Close()
+}
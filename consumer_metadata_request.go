@@ -1,16 +1,60 @@
 package sarama
 
+// CoordinatorType is the kind of coordinator a ConsumerMetadataRequest looks for.
+type CoordinatorType int8
+
+const (
+	CoordinatorGroup       CoordinatorType = 0
+	CoordinatorTransaction CoordinatorType = 1
+)
+
+func (t CoordinatorType) String() string {
+	if t == CoordinatorTransaction {
+		return "transactional ID"
+	}
+	return "consumer group"
+}
+
 type ConsumerMetadataRequest struct {
-	ConsumerGroup string
+	ConsumerGroup   string          // the transactional ID if CoordinatorType is CoordinatorTransaction
+	CoordinatorType CoordinatorType // v1 or later
+
+	// Version can be:
+	// - 0 (kafka 0.8.2 and later)
+	// - 1 (kafka 0.11 and later, adds CoordinatorType)
+	IVersion int16
 }
 
 func (r *ConsumerMetadataRequest) Encode(pe packetEncoder) error {
-	return pe.putString(r.ConsumerGroup)
+	if r.IVersion < 0 || r.IVersion > 1 {
+		return PacketEncodingError{"invalid or unsupported ConsumerMetadataRequest version field"}
+	}
+
+	if err := pe.putString(r.ConsumerGroup); err != nil {
+		return err
+	}
+
+	if r.IVersion >= 1 {
+		pe.putInt8(int8(r.CoordinatorType))
+	}
+
+	return nil
 }
 
 func (r *ConsumerMetadataRequest) Decode(pd packetDecoder) (err error) {
-	r.ConsumerGroup, err = pd.getString()
-	return err
+	if r.ConsumerGroup, err = pd.getString(); err != nil {
+		return err
+	}
+
+	if r.IVersion >= 1 {
+		coordinatorType, err := pd.getInt8()
+		if err != nil {
+			return err
+		}
+		r.CoordinatorType = CoordinatorType(coordinatorType)
+	}
+
+	return nil
 }
 
 func (r *ConsumerMetadataRequest) Key() int16 {
@@ -18,5 +62,9 @@ func (r *ConsumerMetadataRequest) Key() int16 {
 }
 
 func (r *ConsumerMetadataRequest) Version() int16 {
-	return 0
+	return r.IVersion
+}
+
+func (r *ConsumerMetadataRequest) String() string {
+	return dump(r)
 }
@@ -17,3 +17,12 @@ func TestConsumerMetadataRequest(t *testing.T) {
 	request.ConsumerGroup = "foobar"
 	testRequest(t, "with string", request, consumerMetadataRequestString)
 }
+
+var consumerMetadataRequestTransaction = []byte{
+	0x00, 0x03, 't', 'x', 'n',
+	0x01}
+
+func TestConsumerMetadataRequestV1(t *testing.T) {
+	request := &ConsumerMetadataRequest{ConsumerGroup: "txn", CoordinatorType: CoordinatorTransaction, IVersion: 1}
+	testRequest(t, "transaction coordinator", request, consumerMetadataRequestTransaction)
+}
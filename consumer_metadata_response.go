@@ -3,23 +3,43 @@ package sarama
 import (
 	"net"
 	"strconv"
+	"time"
 )
 
 type ConsumerMetadataResponse struct {
+	ThrottleTime    time.Duration // only provided if Version >= 1
 	Err             KError
+	ErrMsg          *string // only provided if Version >= 1
 	Coordinator     *Broker
 	CoordinatorID   int32  // deprecated: use Coordinator.ID()
 	CoordinatorHost string // deprecated: use Coordinator.Addr()
 	CoordinatorPort int32  // deprecated: use Coordinator.Addr()
+
+	// Version must be set to that of the request before decoding
+	IVersion int16
 }
 
 func (r *ConsumerMetadataResponse) Decode(pd packetDecoder) (err error) {
+	if r.IVersion >= 1 {
+		millis, err := pd.getInt32()
+		if err != nil {
+			return err
+		}
+		r.ThrottleTime = time.Duration(millis) * time.Millisecond
+	}
+
 	tmp, err := pd.getInt16()
 	if err != nil {
 		return err
 	}
 	r.Err = KError(tmp)
 
+	if r.IVersion >= 1 {
+		if r.ErrMsg, err = pd.getNullableString(); err != nil {
+			return err
+		}
+	}
+
 	coordinator := new(Broker)
 	if err := coordinator.Decode(pd); err != nil {
 		return err
@@ -47,7 +67,18 @@ func (r *ConsumerMetadataResponse) Decode(pd packetDecoder) (err error) {
 }
 
 func (r *ConsumerMetadataResponse) Encode(pe packetEncoder) error {
+	if r.IVersion >= 1 {
+		pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	}
+
 	pe.putInt16(int16(r.Err))
+
+	if r.IVersion >= 1 {
+		if err := pe.putNullableString(r.ErrMsg); err != nil {
+			return err
+		}
+	}
+
 	if r.Coordinator != nil {
 		host, portstr, err := net.SplitHostPort(r.Coordinator.Addr())
 		if err != nil {
@@ -71,3 +102,7 @@ func (r *ConsumerMetadataResponse) Encode(pe packetEncoder) error {
 	pe.putInt32(r.CoordinatorPort)
 	return nil
 }
+
+func (r *ConsumerMetadataResponse) String() string {
+	return dump(r)
+}
@@ -1,6 +1,9 @@
 package sarama
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 var (
 	consumerMetadataResponseError = []byte{
@@ -33,3 +36,23 @@ func TestConsumerMetadataResponseSuccess(t *testing.T) {
 	}
 	testResponse(t, "success", &response, consumerMetadataResponseSuccess)
 }
+
+var consumerMetadataResponseV1 = []byte{
+	0x00, 0x00, 0x00, 0x64,
+	0x00, 0x00,
+	0xFF, 0xFF,
+	0x00, 0x00, 0x00, 0xAB,
+	0x00, 0x03, 'f', 'o', 'o',
+	0x00, 0x00, 0xCC, 0xDD}
+
+func TestConsumerMetadataResponseV1(t *testing.T) {
+	response := &ConsumerMetadataResponse{IVersion: 1}
+	testDecodable(t, "v1", response, consumerMetadataResponseV1)
+	if response.ThrottleTime != 100*time.Millisecond || response.Err != ErrNoError || response.ErrMsg != nil {
+		t.Error("Decoding v1 failed, got", response)
+	}
+	if response.Coordinator == nil || response.Coordinator.ID() != 0xAB || response.Coordinator.Addr() != "foo:52445" {
+		t.Error("Decoding coordinator failed, got", response.Coordinator)
+	}
+	testEncodable(t, "v1", response, consumerMetadataResponseV1)
+}
@@ -489,6 +489,217 @@ func TestConsumerNonSequentialOffsets(t *testing.T) {
 	broker0.Close()
 }
 
+// The messages inside a compressed version 1 message have offsets relative to
+// the wrapper, which carries the absolute offset of the last of them.
+func TestConsumerCompressedRelativeOffsets(t *testing.T) {
+	// Given
+	inner := new(MessageSet)
+	for i := 0; i < 3; i++ {
+		inner.Messages = append(inner.Messages, &MessageBlock{
+			Offset: int64(i),
+			Msg:    &Message{Version: 1, Value: []byte(testMsg), Timestamp: time.Unix(1479847795, 0)},
+		})
+	}
+	payload, err := Encode(inner)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	broker0 := newMockBroker(t, 0)
+	called := 0
+	broker0.SetHandler(func(req *Request) (res Encoder) {
+		switch req.Body.(type) {
+		case *MetadataRequest:
+			return newMockMetadataResponse(t).
+				SetBroker(broker0.Addr(), broker0.BrokerID()).
+				SetLeader("my_topic", 0, broker0.BrokerID()).For(req.Body)
+		case *OffsetRequest:
+			return newMockOffsetResponse(t).
+				SetOffset("my_topic", 0, OffsetNewest, 1234).
+				SetOffset("my_topic", 0, OffsetOldest, 0).For(req.Body)
+		case *FetchRequest:
+			called++
+			fetchResponse := &FetchResponse{IVersion: req.Body.Version()}
+			fetchResponse.AddError("my_topic", 0, ErrNoError)
+			if called > 1 {
+				return fetchResponse
+			}
+			block := fetchResponse.GetBlock("my_topic", 0)
+			block.MsgSet.Messages = append(block.MsgSet.Messages, &MessageBlock{
+				Offset: 12,
+				Msg: &Message{
+					Version:       1,
+					Codec:         CompressionGZIP,
+					Value:         payload,
+					LogAppendTime: true,
+					Timestamp:     time.Unix(1479847800, 0),
+				},
+			})
+			return fetchResponse
+		}
+		return nil
+	})
+
+	config := NewConfig()
+	config.Version = V0_10_0_0
+	master, err := NewConsumer([]string{broker0.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// When
+	consumer, err := master.ConsumePartition("my_topic", 0, 11)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Then: the offsets are made absolute, and the broker's timestamp is used
+	for _, offset := range []int64{11, 12} {
+		message := <-consumer.Messages()
+		assertMessageOffset(t, message, offset)
+		if !message.Timestamp.Equal(time.Unix(1479847800, 0)) {
+			t.Error("Incorrect message timestamp:", message.Timestamp)
+		}
+	}
+
+	safeClose(t, consumer)
+	safeClose(t, master)
+	broker0.Close()
+}
+
+// Kafka 0.11 and later return record batches, which carry headers, and may
+// contain control batches which take up offsets but are not returned.
+func TestConsumerRecordBatches(t *testing.T) {
+	// Given
+	broker0 := newMockBroker(t, 0)
+	called := 0
+	broker0.SetHandler(func(req *Request) (res Encoder) {
+		switch req.Body.(type) {
+		case *MetadataRequest:
+			return newMockMetadataResponse(t).
+				SetBroker(broker0.Addr(), broker0.BrokerID()).
+				SetLeader("my_topic", 0, broker0.BrokerID()).For(req.Body)
+		case *OffsetRequest:
+			return newMockOffsetResponse(t).
+				SetOffset("my_topic", 0, OffsetNewest, 1234).
+				SetOffset("my_topic", 0, OffsetOldest, 0).For(req.Body)
+		case *FetchRequest:
+			called++
+			fetchResponse := &FetchResponse{IVersion: req.Body.Version()}
+			fetchResponse.AddError("my_topic", 0, ErrNoError)
+			if called > 1 {
+				return fetchResponse
+			}
+			block := fetchResponse.GetBlock("my_topic", 0)
+			marker := &RecordBatch{Control: true}
+			marker.AddRecord(3, time.Time{}, []byte{0, 0, 0, 0}, []byte{0, 0, 0, 0, 0, 0}, nil)
+			batch := &RecordBatch{}
+			batch.AddRecord(4, time.Unix(1479847795, 0), nil, []byte(testMsg), []RecordHeader{{Key: []byte("trace"), Value: []byte("abc")}})
+			block.RecordBatches = []*RecordBatch{marker, batch}
+			return fetchResponse
+		}
+		return nil
+	})
+
+	config := NewConfig()
+	config.Version = V0_11_0_0
+	master, err := NewConsumer([]string{broker0.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// When
+	consumer, err := master.ConsumePartition("my_topic", 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Then: the control record is skipped and the headers are returned
+	message := <-consumer.Messages()
+	assertMessageOffset(t, message, 4)
+	if len(message.Headers) != 1 || string(message.Headers[0].Key) != "trace" || string(message.Headers[0].Value) != "abc" {
+		t.Error("Incorrect message headers:", message.Headers)
+	}
+	if !message.Timestamp.Equal(time.Unix(1479847795, 0)) {
+		t.Error("Incorrect message timestamp:", message.Timestamp)
+	}
+
+	safeClose(t, consumer)
+	safeClose(t, master)
+	broker0.Close()
+}
+
+// When reading committed messages, the batches of aborted transactions are
+// skipped up to the marker that aborts them.
+func TestConsumerReadCommitted(t *testing.T) {
+	// Given
+	broker0 := newMockBroker(t, 0)
+	called := 0
+	var isolation IsolationLevel
+	broker0.SetHandler(func(req *Request) (res Encoder) {
+		switch req.Body.(type) {
+		case *MetadataRequest:
+			return newMockMetadataResponse(t).
+				SetBroker(broker0.Addr(), broker0.BrokerID()).
+				SetLeader("my_topic", 0, broker0.BrokerID()).For(req.Body)
+		case *OffsetRequest:
+			return newMockOffsetResponse(t).
+				SetOffset("my_topic", 0, OffsetNewest, 1234).
+				SetOffset("my_topic", 0, OffsetOldest, 0).For(req.Body)
+		case *FetchRequest:
+			called++
+			isolation = req.Body.(*FetchRequest).Isolation
+			fetchResponse := &FetchResponse{IVersion: req.Body.Version()}
+			fetchResponse.AddError("my_topic", 0, ErrNoError)
+			if called > 1 {
+				return fetchResponse
+			}
+			block := fetchResponse.GetBlock("my_topic", 0)
+			batch := func(offset, producerID int64, control bool) *RecordBatch {
+				b := &RecordBatch{IsTransactional: true, Control: control, ProducerID: producerID}
+				b.AddRecord(offset, time.Unix(1479847795, 0), nil, []byte(testMsg), nil)
+				return b
+			}
+			block.RecordBatches = []*RecordBatch{
+				batch(3, 7, false), // aborted below
+				batch(4, 8, false),
+				batch(5, 7, true), // abort marker
+				batch(6, 8, true), // commit marker
+				batch(7, 7, false),
+			}
+			block.AbortedTransactions = []*AbortedTransaction{{ProducerID: 7, FirstOffset: 3}}
+			return fetchResponse
+		}
+		return nil
+	})
+
+	config := NewConfig()
+	config.Version = V0_11_0_0
+	config.Consumer.IsolationLevel = ReadCommitted
+	master, err := NewConsumer([]string{broker0.Addr()}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// When
+	consumer, err := master.ConsumePartition("my_topic", 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Then: only the committed messages are returned
+	assertMessageOffset(t, <-consumer.Messages(), 4)
+	assertMessageOffset(t, <-consumer.Messages(), 7)
+
+	safeClose(t, consumer)
+	safeClose(t, master)
+	broker0.Close()
+
+	if isolation != ReadCommitted {
+		t.Error("Expected the fetch requests to read committed messages")
+	}
+}
+
 // If leadership for a partition is changing then consumer resolves the new
 // leader and switches to it.
 func TestConsumerRebalancingMultiplePartitions(t *testing.T) {
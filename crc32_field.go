@@ -6,9 +6,23 @@ import (
 	"github.com/klauspost/crc32"
 )
 
+type crcPolynomial int8
+
+const (
+	crcIEEE crcPolynomial = iota
+	crcCastagnoli
+)
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
 // crc32Field implements the pushEncoder and pushDecoder interfaces for calculating CRC32s.
 type crc32Field struct {
 	startOffset int
+	polynomial  crcPolynomial
+}
+
+func newCRC32Field(polynomial crcPolynomial) *crc32Field {
+	return &crc32Field{polynomial: polynomial}
 }
 
 func (c *crc32Field) saveOffset(in int) {
@@ -20,13 +34,13 @@ func (c *crc32Field) reserveLength() int {
 }
 
 func (c *crc32Field) run(curOffset int, buf []byte) error {
-	crc := crc32.ChecksumIEEE(buf[c.startOffset+4 : curOffset])
+	crc := c.crc(buf[c.startOffset+4 : curOffset])
 	binary.BigEndian.PutUint32(buf[c.startOffset:], crc)
 	return nil
 }
 
 func (c *crc32Field) check(curOffset int, buf []byte) error {
-	crc := crc32.ChecksumIEEE(buf[c.startOffset+4 : curOffset])
+	crc := c.crc(buf[c.startOffset+4 : curOffset])
 
 	if crc != binary.BigEndian.Uint32(buf[c.startOffset:]) {
 		return PacketDecodingError{"CRC didn't match"}
@@ -34,3 +48,10 @@ func (c *crc32Field) check(curOffset int, buf []byte) error {
 
 	return nil
 }
+
+func (c *crc32Field) crc(data []byte) uint32 {
+	if c.polynomial == crcCastagnoli {
+		return crc32.Checksum(data, castagnoliTable)
+	}
+	return crc32.ChecksumIEEE(data)
+}
@@ -0,0 +1,144 @@
+package sarama
+
+import "time"
+
+// TopicPartition describes how to grow the partitions of a topic: the total number of partitions
+// it should have, and optionally the replicas of each new partition.
+type TopicPartition struct {
+	Count      int32
+	Assignment [][]int32
+}
+
+func (t *TopicPartition) Encode(pe packetEncoder) error {
+	pe.putInt32(t.Count)
+
+	if t.Assignment == nil {
+		pe.putInt32(-1)
+		return nil
+	}
+
+	if err := pe.putArrayLength(len(t.Assignment)); err != nil {
+		return err
+	}
+	for _, replicas := range t.Assignment {
+		if err := pe.putInt32Array(replicas); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *TopicPartition) Decode(pd packetDecoder) (err error) {
+	if t.Count, err = pd.getInt32(); err != nil {
+		return err
+	}
+
+	n, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	if n <= 0 {
+		return nil
+	}
+	if int(n) > pd.remaining() {
+		return ErrInsufficientData
+	}
+
+	t.Assignment = make([][]int32, n)
+	for i := range t.Assignment {
+		if t.Assignment[i], err = pd.getInt32Array(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type CreatePartitionsRequest struct {
+	TopicPartitions map[string]*TopicPartition
+	Timeout         time.Duration
+	ValidateOnly    bool
+
+	// Version can be:
+	// - 0 (kafka 1.0 and later)
+	// - 1 (kafka 2.0 and later)
+	IVersion int16
+}
+
+func (r *CreatePartitionsRequest) Encode(pe packetEncoder) error {
+	if r.IVersion < 0 || r.IVersion > 1 {
+		return PacketEncodingError{"invalid or unsupported CreatePartitionsRequest version field"}
+	}
+
+	if err := pe.putArrayLength(len(r.TopicPartitions)); err != nil {
+		return err
+	}
+	for topic, partition := range r.TopicPartitions {
+		if err := pe.putString(topic); err != nil {
+			return err
+		}
+		if err := partition.Encode(pe); err != nil {
+			return err
+		}
+	}
+
+	pe.putInt32(int32(r.Timeout / time.Millisecond))
+
+	if r.ValidateOnly {
+		pe.putInt8(1)
+	} else {
+		pe.putInt8(0)
+	}
+
+	return nil
+}
+
+func (r *CreatePartitionsRequest) Decode(pd packetDecoder) (err error) {
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.TopicPartitions = make(map[string]*TopicPartition, n)
+	for i := 0; i < n; i++ {
+		topic, err := pd.getString()
+		if err != nil {
+			return err
+		}
+		r.TopicPartitions[topic] = new(TopicPartition)
+		if err := r.TopicPartitions[topic].Decode(pd); err != nil {
+			return err
+		}
+	}
+
+	millis, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.Timeout = time.Duration(millis) * time.Millisecond
+
+	validateOnly, err := pd.getInt8()
+	if err != nil {
+		return err
+	}
+	r.ValidateOnly = validateOnly != 0
+
+	return nil
+}
+
+func (r *CreatePartitionsRequest) Key() int16 {
+	return 37
+}
+
+func (r *CreatePartitionsRequest) Version() int16 {
+	return r.IVersion
+}
+
+func (r *CreatePartitionsRequest) String() string {
+	return dump(r)
+}
+
+// the controller only responds once the partitions are created, or the request's timeout expires
+func (r *CreatePartitionsRequest) responseTimeout() time.Duration {
+	return r.Timeout
+}
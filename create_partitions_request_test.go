@@ -0,0 +1,42 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	createPartitionsRequestNoAssignment = []byte{
+		0, 0, 0, 1, // one topic
+		0, 5, 't', 'o', 'p', 'i', 'c',
+		0, 0, 0, 3, // 3 partitions
+		255, 255, 255, 255, // no assignments
+		0, 0, 0, 100, // timeout
+		0, // validate only = false
+	}
+
+	createPartitionsRequestAssignment = []byte{
+		0, 0, 0, 1,
+		0, 5, 't', 'o', 'p', 'i', 'c',
+		0, 0, 0, 3, // 3 partitions
+		0, 0, 0, 2,
+		0, 0, 0, 2, 0, 0, 0, 2, 0, 0, 0, 3,
+		0, 0, 0, 2, 0, 0, 0, 3, 0, 0, 0, 1,
+		0, 0, 0, 100,
+		1, // validate only = true
+	}
+)
+
+func TestCreatePartitionsRequest(t *testing.T) {
+	request := &CreatePartitionsRequest{
+		TopicPartitions: map[string]*TopicPartition{
+			"topic": {Count: 3},
+		},
+		Timeout: 100 * time.Millisecond,
+	}
+	testRequest(t, "no assignment", request, createPartitionsRequestNoAssignment)
+
+	request.TopicPartitions["topic"].Assignment = [][]int32{{2, 3}, {3, 1}}
+	request.ValidateOnly = true
+	testRequest(t, "assignment", request, createPartitionsRequestAssignment)
+}
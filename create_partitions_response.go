@@ -0,0 +1,60 @@
+package sarama
+
+import "time"
+
+type CreatePartitionsResponse struct {
+	ThrottleTime         time.Duration
+	TopicPartitionErrors map[string]*TopicError
+
+	// Version must be set to that of the request before decoding
+	IVersion int16
+}
+
+func (r *CreatePartitionsResponse) Encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+
+	if err := pe.putArrayLength(len(r.TopicPartitionErrors)); err != nil {
+		return err
+	}
+	for topic, topicErr := range r.TopicPartitionErrors {
+		if err := pe.putString(topic); err != nil {
+			return err
+		}
+		// the error message is there from the first version
+		if err := topicErr.encode(pe, 1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *CreatePartitionsResponse) Decode(pd packetDecoder) (err error) {
+	millis, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(millis) * time.Millisecond
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.TopicPartitionErrors = make(map[string]*TopicError, n)
+	for i := 0; i < n; i++ {
+		topic, err := pd.getString()
+		if err != nil {
+			return err
+		}
+		r.TopicPartitionErrors[topic] = new(TopicError)
+		if err := r.TopicPartitionErrors[topic].decode(pd, 1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *CreatePartitionsResponse) String() string {
+	return dump(r)
+}
@@ -0,0 +1,44 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	createPartitionResponseSuccess = []byte{
+		0, 0, 0, 100, // throttleTimeMs
+		0, 0, 0, 1,
+		0, 5, 't', 'o', 'p', 'i', 'c',
+		0, 0, // no error
+		255, 255, // no error message
+	}
+
+	createPartitionResponseFail = []byte{
+		0, 0, 0, 100, // throttleTimeMs
+		0, 0, 0, 1,
+		0, 5, 't', 'o', 'p', 'i', 'c',
+		0, 37, // partition error
+		0, 5, 'e', 'r', 'r', 'o', 'r',
+	}
+)
+
+func TestCreatePartitionsResponse(t *testing.T) {
+	response := new(CreatePartitionsResponse)
+	testDecodable(t, "success", response, createPartitionResponseSuccess)
+	if response.ThrottleTime != 100*time.Millisecond {
+		t.Error("Decoding ThrottleTime failed, got", response.ThrottleTime)
+	}
+	if err := response.TopicPartitionErrors["topic"].asError(); err != nil {
+		t.Error("Decoding error failed, got", err)
+	}
+	testResponse(t, "success", response, createPartitionResponseSuccess)
+
+	response = new(CreatePartitionsResponse)
+	testDecodable(t, "fail", response, createPartitionResponseFail)
+	topicErr := response.TopicPartitionErrors["topic"]
+	if topicErr.Err != ErrInvalidPartitions || topicErr.ErrMsg == nil || *topicErr.ErrMsg != "error" {
+		t.Error("Decoding error failed, got", topicErr)
+	}
+	testResponse(t, "fail", response, createPartitionResponseFail)
+}
@@ -0,0 +1,194 @@
+package sarama
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TopicDetail describes a topic to create: either a number of partitions and a replication factor,
+// or an explicit assignment of replicas to each partition (leaving both counts at -1).
+type TopicDetail struct {
+	NumPartitions     int32
+	ReplicationFactor int16
+	ReplicaAssignment map[int32][]int32
+	ConfigEntries     map[string]*string
+}
+
+func (t *TopicDetail) Encode(pe packetEncoder) error {
+	pe.putInt32(t.NumPartitions)
+	pe.putInt16(t.ReplicationFactor)
+
+	if err := pe.putArrayLength(len(t.ReplicaAssignment)); err != nil {
+		return err
+	}
+	for partition, replicas := range t.ReplicaAssignment {
+		pe.putInt32(partition)
+		if err := pe.putInt32Array(replicas); err != nil {
+			return err
+		}
+	}
+
+	if err := pe.putArrayLength(len(t.ConfigEntries)); err != nil {
+		return err
+	}
+	for name, value := range t.ConfigEntries {
+		if err := pe.putString(name); err != nil {
+			return err
+		}
+		if err := pe.putNullableString(value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *TopicDetail) Decode(pd packetDecoder) (err error) {
+	if t.NumPartitions, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if t.ReplicationFactor, err = pd.getInt16(); err != nil {
+		return err
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		t.ReplicaAssignment = make(map[int32][]int32, n)
+		for i := 0; i < n; i++ {
+			partition, err := pd.getInt32()
+			if err != nil {
+				return err
+			}
+			if t.ReplicaAssignment[partition], err = pd.getInt32Array(); err != nil {
+				return err
+			}
+		}
+	}
+
+	n, err = pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		t.ConfigEntries = make(map[string]*string, n)
+		for i := 0; i < n; i++ {
+			name, err := pd.getString()
+			if err != nil {
+				return err
+			}
+			if t.ConfigEntries[name], err = pd.getNullableString(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// MarshalJSON gives the JSON view of the topic with only the names of its configuration entries,
+// whose values can be secrets.
+func (t TopicDetail) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		NumPartitions     int32
+		ReplicationFactor int16
+		ReplicaAssignment map[int32][]int32
+		ConfigEntries     map[string]string
+	}{t.NumPartitions, t.ReplicationFactor, t.ReplicaAssignment, redactedConfigEntries(t.ConfigEntries)})
+}
+
+type CreateTopicsRequest struct {
+	TopicDetails map[string]*TopicDetail
+	Timeout      time.Duration
+	ValidateOnly bool // v1 or later
+
+	// Version can be:
+	// - 0 (kafka 0.10.1 and later)
+	// - 1 (kafka 0.11 and later, adding ValidateOnly and error messages to the response)
+	// - 2 (kafka 1.0 and later, adding throttle time to the response)
+	// - 3 (kafka 2.0 and later)
+	IVersion int16
+}
+
+func (r *CreateTopicsRequest) Encode(pe packetEncoder) error {
+	if r.IVersion < 0 || r.IVersion > 3 {
+		return PacketEncodingError{"invalid or unsupported CreateTopicsRequest version field"}
+	}
+
+	if err := pe.putArrayLength(len(r.TopicDetails)); err != nil {
+		return err
+	}
+	for topic, detail := range r.TopicDetails {
+		if err := pe.putString(topic); err != nil {
+			return err
+		}
+		if err := detail.Encode(pe); err != nil {
+			return err
+		}
+	}
+
+	pe.putInt32(int32(r.Timeout / time.Millisecond))
+
+	if r.IVersion >= 1 {
+		if r.ValidateOnly {
+			pe.putInt8(1)
+		} else {
+			pe.putInt8(0)
+		}
+	}
+
+	return nil
+}
+
+func (r *CreateTopicsRequest) Decode(pd packetDecoder) (err error) {
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.TopicDetails = make(map[string]*TopicDetail, n)
+	for i := 0; i < n; i++ {
+		topic, err := pd.getString()
+		if err != nil {
+			return err
+		}
+		r.TopicDetails[topic] = new(TopicDetail)
+		if err := r.TopicDetails[topic].Decode(pd); err != nil {
+			return err
+		}
+	}
+
+	millis, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.Timeout = time.Duration(millis) * time.Millisecond
+
+	if r.IVersion >= 1 {
+		validateOnly, err := pd.getInt8()
+		if err != nil {
+			return err
+		}
+		r.ValidateOnly = validateOnly != 0
+	}
+
+	return nil
+}
+
+func (r *CreateTopicsRequest) Key() int16 {
+	return 19
+}
+
+func (r *CreateTopicsRequest) Version() int16 {
+	return r.IVersion
+}
+
+func (r *CreateTopicsRequest) String() string {
+	return dump(r)
+}
+
+// the controller only responds once the topics are created, or the request's timeout expires
+func (r *CreateTopicsRequest) responseTimeout() time.Duration {
+	return r.Timeout
+}
@@ -0,0 +1,115 @@
+package sarama
+
+import (
+	"fmt"
+	"time"
+)
+
+// TopicError is the error of an operation on a topic, or on the configuration of a topic or broker,
+// along with the message the broker explains it with, if any.
+type TopicError struct {
+	Err    KError
+	ErrMsg *string
+}
+
+func (t *TopicError) Error() string {
+	text := t.Err.Error()
+	if t.ErrMsg != nil {
+		text = fmt.Sprintf("%s - %s", text, *t.ErrMsg)
+	}
+	return text
+}
+
+// asError returns nil if there is no error, and the KError if the broker did not explain it
+func (t *TopicError) asError() error {
+	switch {
+	case t.Err == ErrNoError:
+		return nil
+	case t.ErrMsg == nil:
+		return t.Err
+	}
+	return t
+}
+
+func (t *TopicError) encode(pe packetEncoder, version int16) error {
+	pe.putInt16(int16(t.Err))
+	if version >= 1 {
+		return pe.putNullableString(t.ErrMsg)
+	}
+	return nil
+}
+
+func (t *TopicError) decode(pd packetDecoder, version int16) error {
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	t.Err = KError(kerr)
+
+	if version >= 1 {
+		if t.ErrMsg, err = pd.getNullableString(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type CreateTopicsResponse struct {
+	ThrottleTime time.Duration // only provided if Version >= 2
+	TopicErrors  map[string]*TopicError
+
+	// Version must be set to that of the request before decoding
+	IVersion int16
+}
+
+func (r *CreateTopicsResponse) Encode(pe packetEncoder) error {
+	if r.IVersion >= 2 {
+		pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	}
+
+	if err := pe.putArrayLength(len(r.TopicErrors)); err != nil {
+		return err
+	}
+	for topic, topicErr := range r.TopicErrors {
+		if err := pe.putString(topic); err != nil {
+			return err
+		}
+		if err := topicErr.encode(pe, r.IVersion); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *CreateTopicsResponse) Decode(pd packetDecoder) (err error) {
+	if r.IVersion >= 2 {
+		millis, err := pd.getInt32()
+		if err != nil {
+			return err
+		}
+		r.ThrottleTime = time.Duration(millis) * time.Millisecond
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.TopicErrors = make(map[string]*TopicError, n)
+	for i := 0; i < n; i++ {
+		topic, err := pd.getString()
+		if err != nil {
+			return err
+		}
+		r.TopicErrors[topic] = new(TopicError)
+		if err := r.TopicErrors[topic].decode(pd, r.IVersion); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *CreateTopicsResponse) String() string {
+	return dump(r)
+}
@@ -0,0 +1,59 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	createTopicsResponseV0 = []byte{
+		0, 0, 0, 1,
+		0, 5, 't', 'o', 'p', 'i', 'c',
+		0, 42,
+	}
+
+	createTopicsResponseV1 = []byte{
+		0, 0, 0, 1,
+		0, 5, 't', 'o', 'p', 'i', 'c',
+		0, 42,
+		0, 3, 'm', 's', 'g',
+	}
+
+	createTopicsResponseV2 = []byte{
+		0, 0, 0, 100,
+		0, 0, 0, 1,
+		0, 5, 't', 'o', 'p', 'i', 'c',
+		0, 42,
+		0, 3, 'm', 's', 'g',
+	}
+)
+
+func TestCreateTopicsResponse(t *testing.T) {
+	response := new(CreateTopicsResponse)
+	testDecodable(t, "version 0", response, createTopicsResponseV0)
+	if err := response.TopicErrors["topic"].asError(); err != ErrInvalidRequest {
+		t.Error("Decoding error failed, got", err)
+	}
+	testEncodable(t, "version 0", response, createTopicsResponseV0)
+
+	response = &CreateTopicsResponse{IVersion: 1}
+	testDecodable(t, "version 1", response, createTopicsResponseV1)
+	topicErr := response.TopicErrors["topic"]
+	if topicErr.Err != ErrInvalidRequest || topicErr.ErrMsg == nil || *topicErr.ErrMsg != "msg" {
+		t.Error("Decoding error failed, got", topicErr)
+	}
+	if topicErr.asError() != topicErr {
+		t.Error("Expected the topic error to carry the message")
+	}
+	if topicErr.Error() != ErrInvalidRequest.Error()+" - msg" {
+		t.Error("Unexpected error message", topicErr.Error())
+	}
+	testEncodable(t, "version 1", response, createTopicsResponseV1)
+
+	response = &CreateTopicsResponse{IVersion: 2}
+	testDecodable(t, "version 2", response, createTopicsResponseV2)
+	if response.ThrottleTime != 100*time.Millisecond {
+		t.Error("Decoding ThrottleTime failed, got", response.ThrottleTime)
+	}
+	testEncodable(t, "version 2", response, createTopicsResponseV2)
+}
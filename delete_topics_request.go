@@ -0,0 +1,59 @@
+package sarama
+
+import "time"
+
+type DeleteTopicsRequest struct {
+	Topics  []string
+	Timeout time.Duration
+
+	// Version can be:
+	// - 0 (kafka 0.10.1 and later)
+	// - 1 (kafka 0.11 and later, adding throttle time to the response)
+	// - 2 (kafka 2.0 and later)
+	// - 3 (kafka 2.1 and later)
+	IVersion int16
+}
+
+func (r *DeleteTopicsRequest) Encode(pe packetEncoder) error {
+	if r.IVersion < 0 || r.IVersion > 3 {
+		return PacketEncodingError{"invalid or unsupported DeleteTopicsRequest version field"}
+	}
+
+	if err := pe.putStringArray(r.Topics); err != nil {
+		return err
+	}
+	pe.putInt32(int32(r.Timeout / time.Millisecond))
+
+	return nil
+}
+
+func (r *DeleteTopicsRequest) Decode(pd packetDecoder) (err error) {
+	if r.Topics, err = pd.getStringArray(); err != nil {
+		return err
+	}
+
+	millis, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.Timeout = time.Duration(millis) * time.Millisecond
+
+	return nil
+}
+
+func (r *DeleteTopicsRequest) Key() int16 {
+	return 20
+}
+
+func (r *DeleteTopicsRequest) Version() int16 {
+	return r.IVersion
+}
+
+func (r *DeleteTopicsRequest) String() string {
+	return dump(r)
+}
+
+// the controller only responds once the topics are deleted, or the request's timeout expires
+func (r *DeleteTopicsRequest) responseTimeout() time.Duration {
+	return r.Timeout
+}
@@ -0,0 +1,25 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var deleteTopicsRequest = []byte{
+	0, 0, 0, 2,
+	0, 5, 't', 'o', 'p', 'i', 'c',
+	0, 5, 'o', 't', 'h', 'e', 'r',
+	0, 0, 0, 100,
+}
+
+func TestDeleteTopicsRequest(t *testing.T) {
+	request := &DeleteTopicsRequest{
+		Topics:  []string{"topic", "other"},
+		Timeout: 100 * time.Millisecond,
+	}
+	testRequest(t, "version 0", request, deleteTopicsRequest)
+
+	// later versions only change the response
+	request.IVersion = 3
+	testRequest(t, "version 3", request, deleteTopicsRequest)
+}
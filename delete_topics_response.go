@@ -0,0 +1,62 @@
+package sarama
+
+import "time"
+
+type DeleteTopicsResponse struct {
+	ThrottleTime    time.Duration // only provided if Version >= 1
+	TopicErrorCodes map[string]KError
+
+	// Version must be set to that of the request before decoding
+	IVersion int16
+}
+
+func (r *DeleteTopicsResponse) Encode(pe packetEncoder) error {
+	if r.IVersion >= 1 {
+		pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	}
+
+	if err := pe.putArrayLength(len(r.TopicErrorCodes)); err != nil {
+		return err
+	}
+	for topic, errorCode := range r.TopicErrorCodes {
+		if err := pe.putString(topic); err != nil {
+			return err
+		}
+		pe.putInt16(int16(errorCode))
+	}
+
+	return nil
+}
+
+func (r *DeleteTopicsResponse) Decode(pd packetDecoder) (err error) {
+	if r.IVersion >= 1 {
+		millis, err := pd.getInt32()
+		if err != nil {
+			return err
+		}
+		r.ThrottleTime = time.Duration(millis) * time.Millisecond
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.TopicErrorCodes = make(map[string]KError, n)
+	for i := 0; i < n; i++ {
+		topic, err := pd.getString()
+		if err != nil {
+			return err
+		}
+		errorCode, err := pd.getInt16()
+		if err != nil {
+			return err
+		}
+		r.TopicErrorCodes[topic] = KError(errorCode)
+	}
+
+	return nil
+}
+
+func (r *DeleteTopicsResponse) String() string {
+	return dump(r)
+}
@@ -0,0 +1,40 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	deleteTopicsResponseV0 = []byte{
+		0, 0, 0, 1,
+		0, 5, 't', 'o', 'p', 'i', 'c',
+		0, 73,
+	}
+
+	deleteTopicsResponseV1 = []byte{
+		0, 0, 0, 100,
+		0, 0, 0, 1,
+		0, 5, 't', 'o', 'p', 'i', 'c',
+		0, 0,
+	}
+)
+
+func TestDeleteTopicsResponse(t *testing.T) {
+	response := new(DeleteTopicsResponse)
+	testDecodable(t, "version 0", response, deleteTopicsResponseV0)
+	if response.TopicErrorCodes["topic"] != ErrTopicDeletionDisabled {
+		t.Error("Decoding error failed, got", response.TopicErrorCodes)
+	}
+	testEncodable(t, "version 0", response, deleteTopicsResponseV0)
+
+	response = &DeleteTopicsResponse{IVersion: 1}
+	testDecodable(t, "version 1", response, deleteTopicsResponseV1)
+	if response.ThrottleTime != 100*time.Millisecond {
+		t.Error("Decoding ThrottleTime failed, got", response.ThrottleTime)
+	}
+	if kerr, ok := response.TopicErrorCodes["topic"]; !ok || kerr != ErrNoError {
+		t.Error("Decoding error failed, got", response.TopicErrorCodes)
+	}
+	testEncodable(t, "version 1", response, deleteTopicsResponseV1)
+}
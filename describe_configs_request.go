@@ -0,0 +1,128 @@
+package sarama
+
+// ConfigResourceType is the kind of resource whose configuration is described or altered.
+type ConfigResourceType int8
+
+const (
+	UnknownResource ConfigResourceType = 0
+	AnyResource     ConfigResourceType = 1
+	TopicResource   ConfigResourceType = 2
+	GroupResource   ConfigResourceType = 3
+	BrokerResource  ConfigResourceType = 4
+)
+
+// ConfigResource names a resource whose configuration is described. The Name of a broker is its ID;
+// an empty Name describes the default configuration shared by all the brokers.
+type ConfigResource struct {
+	Type        ConfigResourceType
+	Name        string
+	ConfigNames []string // the entries to describe, or nil for all of them
+}
+
+func (r *ConfigResource) encode(pe packetEncoder) error {
+	pe.putInt8(int8(r.Type))
+	if err := pe.putString(r.Name); err != nil {
+		return err
+	}
+
+	if r.ConfigNames == nil {
+		pe.putInt32(-1)
+		return nil
+	}
+	return pe.putStringArray(r.ConfigNames)
+}
+
+func (r *ConfigResource) decode(pd packetDecoder) (err error) {
+	resourceType, err := pd.getInt8()
+	if err != nil {
+		return err
+	}
+	r.Type = ConfigResourceType(resourceType)
+
+	if r.Name, err = pd.getString(); err != nil {
+		return err
+	}
+
+	// a null array of names asks for every entry, which getArrayLength does not allow
+	n, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	if n < 0 {
+		r.ConfigNames = nil
+		return nil
+	}
+	r.ConfigNames = make([]string, n)
+	for i := range r.ConfigNames {
+		if r.ConfigNames[i], err = pd.getString(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type DescribeConfigsRequest struct {
+	Resources       []*ConfigResource
+	IncludeSynonyms bool // v1 or later
+
+	// Version can be:
+	// - 0 (kafka 0.11 and later)
+	// - 1 (kafka 1.1 and later, adding IncludeSynonyms and config sources to the response)
+	// - 2 (kafka 2.0 and later)
+	IVersion int16
+}
+
+func (r *DescribeConfigsRequest) Encode(pe packetEncoder) error {
+	if r.IVersion < 0 || r.IVersion > 2 {
+		return PacketEncodingError{"invalid or unsupported DescribeConfigsRequest version field"}
+	}
+
+	if err := pe.putArrayLength(len(r.Resources)); err != nil {
+		return err
+	}
+	for _, resource := range r.Resources {
+		if err := resource.encode(pe); err != nil {
+			return err
+		}
+	}
+
+	if r.IVersion >= 1 {
+		pe.putBool(r.IncludeSynonyms)
+	}
+
+	return nil
+}
+
+func (r *DescribeConfigsRequest) Decode(pd packetDecoder) (err error) {
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.Resources = make([]*ConfigResource, n)
+	for i := range r.Resources {
+		r.Resources[i] = new(ConfigResource)
+		if err := r.Resources[i].decode(pd); err != nil {
+			return err
+		}
+	}
+
+	if r.IVersion >= 1 {
+		if r.IncludeSynonyms, err = pd.getBool(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *DescribeConfigsRequest) Key() int16 {
+	return 32
+}
+
+func (r *DescribeConfigsRequest) Version() int16 {
+	return r.IVersion
+}
+
+func (r *DescribeConfigsRequest) String() string {
+	return dump(r)
+}
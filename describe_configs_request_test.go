@@ -0,0 +1,32 @@
+package sarama
+
+import "testing"
+
+var (
+	describeConfigsRequestV0 = []byte{
+		0, 0, 0, 2,
+		2, // topic
+		0, 3, 'f', 'o', 'o',
+		0, 0, 0, 1,
+		0, 12, 'r', 'e', 't', 'e', 'n', 't', 'i', 'o', 'n', '.', 'm', 's',
+		4, // broker
+		0, 1, '1',
+		255, 255, 255, 255, // all entries
+	}
+
+	describeConfigsRequestV1 = append(describeConfigsRequestV0, byte(1))
+)
+
+func TestDescribeConfigsRequest(t *testing.T) {
+	request := &DescribeConfigsRequest{
+		Resources: []*ConfigResource{
+			{Type: TopicResource, Name: "foo", ConfigNames: []string{"retention.ms"}},
+			{Type: BrokerResource, Name: "1"},
+		},
+	}
+	testRequest(t, "version 0", request, describeConfigsRequestV0)
+
+	request.IVersion = 1
+	request.IncludeSynonyms = true
+	testRequest(t, "version 1", request, describeConfigsRequestV1)
+}
@@ -0,0 +1,287 @@
+package sarama
+
+import "time"
+
+// ConfigSource is where the value of a configuration entry comes from.
+type ConfigSource int8
+
+const (
+	SourceUnknown              ConfigSource = 0
+	SourceTopic                ConfigSource = 1 // dynamic configuration of the topic
+	SourceDynamicBroker        ConfigSource = 2 // dynamic configuration of the broker
+	SourceDynamicDefaultBroker ConfigSource = 3 // dynamic configuration shared by all the brokers
+	SourceStaticBroker         ConfigSource = 4 // the broker's configuration file
+	SourceDefault              ConfigSource = 5 // the default value
+	SourceDynamicBrokerLogger  ConfigSource = 6 // dynamic configuration of the broker's loggers
+)
+
+func (s ConfigSource) String() string {
+	switch s {
+	case SourceTopic:
+		return "DYNAMIC_TOPIC_CONFIG"
+	case SourceDynamicBroker:
+		return "DYNAMIC_BROKER_CONFIG"
+	case SourceDynamicDefaultBroker:
+		return "DYNAMIC_DEFAULT_BROKER_CONFIG"
+	case SourceStaticBroker:
+		return "STATIC_BROKER_CONFIG"
+	case SourceDefault:
+		return "DEFAULT_CONFIG"
+	case SourceDynamicBrokerLogger:
+		return "DYNAMIC_BROKER_LOGGER_CONFIG"
+	}
+	return "UNKNOWN"
+}
+
+// ConfigSynonym is one of the values a configuration entry might take, in order of precedence.
+type ConfigSynonym struct {
+	Name   string
+	Value  *string
+	Source ConfigSource
+}
+
+func (s *ConfigSynonym) encode(pe packetEncoder) error {
+	if err := pe.putString(s.Name); err != nil {
+		return err
+	}
+	if err := pe.putNullableString(s.Value); err != nil {
+		return err
+	}
+	pe.putInt8(int8(s.Source))
+	return nil
+}
+
+func (s *ConfigSynonym) decode(pd packetDecoder) (err error) {
+	if s.Name, err = pd.getString(); err != nil {
+		return err
+	}
+	if s.Value, err = pd.getNullableString(); err != nil {
+		return err
+	}
+	source, err := pd.getInt8()
+	if err != nil {
+		return err
+	}
+	s.Source = ConfigSource(source)
+	return nil
+}
+
+// ConfigEntry is a configuration entry of a topic or broker.
+type ConfigEntry struct {
+	Name      string
+	Value     *string // nil if the entry is sensitive
+	ReadOnly  bool
+	Source    ConfigSource
+	Sensitive bool
+	Synonyms  []*ConfigSynonym // only provided if Version >= 1 and the request included synonyms
+}
+
+// Default returns whether the entry has its default value.
+func (e *ConfigEntry) Default() bool {
+	return e.Source == SourceDefault
+}
+
+func (e *ConfigEntry) encode(pe packetEncoder, version int16) error {
+	if err := pe.putString(e.Name); err != nil {
+		return err
+	}
+	if err := pe.putNullableString(e.Value); err != nil {
+		return err
+	}
+	pe.putBool(e.ReadOnly)
+
+	if version == 0 {
+		pe.putBool(e.Default())
+	} else {
+		pe.putInt8(int8(e.Source))
+	}
+
+	pe.putBool(e.Sensitive)
+
+	if version >= 1 {
+		if err := pe.putArrayLength(len(e.Synonyms)); err != nil {
+			return err
+		}
+		for _, synonym := range e.Synonyms {
+			if err := synonym.encode(pe); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (e *ConfigEntry) decode(pd packetDecoder, version int16, resourceType ConfigResourceType) (err error) {
+	if e.Name, err = pd.getString(); err != nil {
+		return err
+	}
+	if e.Value, err = pd.getNullableString(); err != nil {
+		return err
+	}
+	if e.ReadOnly, err = pd.getBool(); err != nil {
+		return err
+	}
+
+	if version == 0 {
+		// version 0 only tells whether the value is the default; dynamic configuration of the
+		// brokers came later, so any other value is the topic's own or from the broker's file
+		isDefault, err := pd.getBool()
+		if err != nil {
+			return err
+		}
+		switch {
+		case isDefault:
+			e.Source = SourceDefault
+		case resourceType == TopicResource:
+			e.Source = SourceTopic
+		case resourceType == BrokerResource:
+			e.Source = SourceStaticBroker
+		default:
+			e.Source = SourceUnknown
+		}
+	} else {
+		source, err := pd.getInt8()
+		if err != nil {
+			return err
+		}
+		e.Source = ConfigSource(source)
+	}
+
+	if e.Sensitive, err = pd.getBool(); err != nil {
+		return err
+	}
+
+	if version >= 1 {
+		n, err := pd.getArrayLength()
+		if err != nil {
+			return err
+		}
+		e.Synonyms = make([]*ConfigSynonym, n)
+		for i := range e.Synonyms {
+			e.Synonyms[i] = new(ConfigSynonym)
+			if err := e.Synonyms[i].decode(pd); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ResourceConfigs holds the configuration entries of a topic or broker, or the error saying why
+// they could not be described.
+type ResourceConfigs struct {
+	Err     KError
+	ErrMsg  *string
+	Type    ConfigResourceType
+	Name    string
+	Configs []*ConfigEntry
+}
+
+func (r *ResourceConfigs) encode(pe packetEncoder, version int16) error {
+	pe.putInt16(int16(r.Err))
+	if err := pe.putNullableString(r.ErrMsg); err != nil {
+		return err
+	}
+	pe.putInt8(int8(r.Type))
+	if err := pe.putString(r.Name); err != nil {
+		return err
+	}
+
+	if err := pe.putArrayLength(len(r.Configs)); err != nil {
+		return err
+	}
+	for _, entry := range r.Configs {
+		if err := entry.encode(pe, version); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *ResourceConfigs) decode(pd packetDecoder, version int16) (err error) {
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.Err = KError(kerr)
+
+	if r.ErrMsg, err = pd.getNullableString(); err != nil {
+		return err
+	}
+
+	resourceType, err := pd.getInt8()
+	if err != nil {
+		return err
+	}
+	r.Type = ConfigResourceType(resourceType)
+
+	if r.Name, err = pd.getString(); err != nil {
+		return err
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.Configs = make([]*ConfigEntry, n)
+	for i := range r.Configs {
+		r.Configs[i] = new(ConfigEntry)
+		if err := r.Configs[i].decode(pd, version, r.Type); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type DescribeConfigsResponse struct {
+	ThrottleTime time.Duration
+	Resources    []*ResourceConfigs
+
+	// Version must be set to that of the request before decoding
+	IVersion int16
+}
+
+func (r *DescribeConfigsResponse) Encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+
+	if err := pe.putArrayLength(len(r.Resources)); err != nil {
+		return err
+	}
+	for _, resource := range r.Resources {
+		if err := resource.encode(pe, r.IVersion); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *DescribeConfigsResponse) Decode(pd packetDecoder) (err error) {
+	millis, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(millis) * time.Millisecond
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.Resources = make([]*ResourceConfigs, n)
+	for i := range r.Resources {
+		r.Resources[i] = new(ResourceConfigs)
+		if err := r.Resources[i].decode(pd, r.IVersion); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *DescribeConfigsResponse) String() string {
+	return dump(r)
+}
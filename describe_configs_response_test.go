@@ -0,0 +1,69 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	describeConfigsResponseV0 = []byte{
+		0, 0, 0, 100,
+		0, 0, 0, 1,
+		0, 0, // no error
+		255, 255, // no error message
+		2, // topic
+		0, 3, 'f', 'o', 'o',
+		0, 0, 0, 1,
+		0, 12, 'r', 'e', 't', 'e', 'n', 't', 'i', 'o', 'n', '.', 'm', 's',
+		0, 2, '-', '1',
+		0, // read only
+		0, // default
+		0, // sensitive
+	}
+
+	describeConfigsResponseV1 = []byte{
+		0, 0, 0, 100,
+		0, 0, 0, 1,
+		0, 0, // no error
+		255, 255, // no error message
+		2, // topic
+		0, 3, 'f', 'o', 'o',
+		0, 0, 0, 1,
+		0, 12, 'r', 'e', 't', 'e', 'n', 't', 'i', 'o', 'n', '.', 'm', 's',
+		0, 2, '-', '1',
+		0, // read only
+		1, // source
+		0, // sensitive
+		0, 0, 0, 1,
+		0, 12, 'r', 'e', 't', 'e', 'n', 't', 'i', 'o', 'n', '.', 'm', 's',
+		0, 2, '-', '1',
+		1, // source
+	}
+)
+
+func TestDescribeConfigsResponse(t *testing.T) {
+	response := new(DescribeConfigsResponse)
+	testDecodable(t, "version 0", response, describeConfigsResponseV0)
+	if response.ThrottleTime != 100*time.Millisecond {
+		t.Error("Decoding ThrottleTime failed, got", response.ThrottleTime)
+	}
+	if len(response.Resources) != 1 || len(response.Resources[0].Configs) != 1 {
+		t.Fatal("Decoding resources failed, got", response.Resources)
+	}
+	entry := response.Resources[0].Configs[0]
+	if entry.Name != "retention.ms" || entry.Value == nil || *entry.Value != "-1" {
+		t.Error("Decoding entry failed, got", entry)
+	}
+	if entry.Source != SourceTopic || entry.Default() || entry.ReadOnly || entry.Sensitive {
+		t.Error("Decoding entry flags failed, got", entry)
+	}
+	testEncodable(t, "version 0", response, describeConfigsResponseV0)
+
+	response = &DescribeConfigsResponse{IVersion: 1}
+	testDecodable(t, "version 1", response, describeConfigsResponseV1)
+	entry = response.Resources[0].Configs[0]
+	if entry.Source != SourceTopic || len(entry.Synonyms) != 1 || entry.Synonyms[0].Source != SourceTopic {
+		t.Error("Decoding entry sources failed, got", entry)
+	}
+	testEncodable(t, "version 1", response, describeConfigsResponseV1)
+}
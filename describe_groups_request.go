@@ -0,0 +1,35 @@
+package sarama
+
+type DescribeGroupsRequest struct {
+	Groups []string
+
+	// Version can be:
+	// - 0 (kafka 0.9 and later)
+	// - 1 (kafka 0.11 and later, adding throttle time to the response)
+	// - 2 (kafka 2.0 and later)
+	IVersion int16
+}
+
+func (r *DescribeGroupsRequest) Encode(pe packetEncoder) error {
+	if r.IVersion < 0 || r.IVersion > 2 {
+		return PacketEncodingError{"invalid or unsupported DescribeGroupsRequest version field"}
+	}
+	return pe.putStringArray(r.Groups)
+}
+
+func (r *DescribeGroupsRequest) Decode(pd packetDecoder) (err error) {
+	r.Groups, err = pd.getStringArray()
+	return err
+}
+
+func (r *DescribeGroupsRequest) Key() int16 {
+	return 15
+}
+
+func (r *DescribeGroupsRequest) Version() int16 {
+	return r.IVersion
+}
+
+func (r *DescribeGroupsRequest) String() string {
+	return dump(r)
+}
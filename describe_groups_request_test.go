@@ -0,0 +1,26 @@
+package sarama
+
+import "testing"
+
+var (
+	describeGroupsRequestEmpty = []byte{
+		0, 0, 0, 0, // 0 groups
+	}
+
+	describeGroupsRequestTwoGroups = []byte{
+		0, 0, 0, 2, // 2 groups
+		0, 3, 'f', 'o', 'o',
+		0, 3, 'b', 'a', 'r',
+	}
+)
+
+func TestDescribeGroupsRequest(t *testing.T) {
+	request := new(DescribeGroupsRequest)
+	testRequest(t, "no groups", request, describeGroupsRequestEmpty)
+
+	request.Groups = []string{"foo", "bar"}
+	testRequest(t, "two groups", request, describeGroupsRequestTwoGroups)
+
+	request.IVersion = 1
+	testRequest(t, "version 1", request, describeGroupsRequestTwoGroups)
+}
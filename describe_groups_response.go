@@ -0,0 +1,199 @@
+package sarama
+
+import "time"
+
+// GroupMemberDescription describes a member of a group. For groups of the "consumer" protocol type,
+// GetMemberMetadata and GetMemberAssignment decode what the member subscribed to and what it was
+// assigned.
+type GroupMemberDescription struct {
+	ClientID         string
+	ClientHost       string
+	MemberMetadata   []byte
+	MemberAssignment []byte
+}
+
+// GetMemberMetadata decodes the metadata the member joined a consumer group with.
+func (m *GroupMemberDescription) GetMemberMetadata() (*ConsumerGroupMemberMetadata, error) {
+	metadata := new(ConsumerGroupMemberMetadata)
+	if len(m.MemberMetadata) == 0 {
+		return metadata, nil
+	}
+	if err := Decode(m.MemberMetadata, metadata); err != nil {
+		return nil, err
+	}
+	return metadata, nil
+}
+
+// GetMemberAssignment decodes the partitions the member of a consumer group was assigned, which are
+// empty while the group is rebalancing.
+func (m *GroupMemberDescription) GetMemberAssignment() (*ConsumerGroupMemberAssignment, error) {
+	assignment := new(ConsumerGroupMemberAssignment)
+	if len(m.MemberAssignment) == 0 {
+		return assignment, nil
+	}
+	if err := Decode(m.MemberAssignment, assignment); err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+func (m *GroupMemberDescription) encode(pe packetEncoder) error {
+	if err := pe.putString(m.ClientID); err != nil {
+		return err
+	}
+	if err := pe.putString(m.ClientHost); err != nil {
+		return err
+	}
+	if err := pe.putBytes(m.MemberMetadata); err != nil {
+		return err
+	}
+	return pe.putBytes(m.MemberAssignment)
+}
+
+func (m *GroupMemberDescription) decode(pd packetDecoder) (err error) {
+	if m.ClientID, err = pd.getString(); err != nil {
+		return err
+	}
+	if m.ClientHost, err = pd.getString(); err != nil {
+		return err
+	}
+	if m.MemberMetadata, err = pd.getBytes(); err != nil {
+		return err
+	}
+	m.MemberAssignment, err = pd.getBytes()
+	return err
+}
+
+// GroupDescription describes a group, its state (such as "Stable", "PreparingRebalance" or "Dead")
+// and its members, mapped by member ID.
+type GroupDescription struct {
+	Err          KError
+	GroupID      string
+	State        string
+	ProtocolType string
+	Protocol     string
+	Members      map[string]*GroupMemberDescription
+}
+
+func (gd *GroupDescription) encode(pe packetEncoder) error {
+	pe.putInt16(int16(gd.Err))
+
+	if err := pe.putString(gd.GroupID); err != nil {
+		return err
+	}
+	if err := pe.putString(gd.State); err != nil {
+		return err
+	}
+	if err := pe.putString(gd.ProtocolType); err != nil {
+		return err
+	}
+	if err := pe.putString(gd.Protocol); err != nil {
+		return err
+	}
+
+	if err := pe.putArrayLength(len(gd.Members)); err != nil {
+		return err
+	}
+	for memberID, member := range gd.Members {
+		if err := pe.putString(memberID); err != nil {
+			return err
+		}
+		if err := member.encode(pe); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (gd *GroupDescription) decode(pd packetDecoder) (err error) {
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	gd.Err = KError(kerr)
+
+	if gd.GroupID, err = pd.getString(); err != nil {
+		return err
+	}
+	if gd.State, err = pd.getString(); err != nil {
+		return err
+	}
+	if gd.ProtocolType, err = pd.getString(); err != nil {
+		return err
+	}
+	if gd.Protocol, err = pd.getString(); err != nil {
+		return err
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	gd.Members = make(map[string]*GroupMemberDescription, n)
+	for i := 0; i < n; i++ {
+		memberID, err := pd.getString()
+		if err != nil {
+			return err
+		}
+		gd.Members[memberID] = new(GroupMemberDescription)
+		if err := gd.Members[memberID].decode(pd); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type DescribeGroupsResponse struct {
+	ThrottleTime time.Duration // only provided if Version >= 1
+	Groups       []*GroupDescription
+
+	// Version must be set to that of the request before decoding
+	IVersion int16
+}
+
+func (r *DescribeGroupsResponse) Encode(pe packetEncoder) error {
+	if r.IVersion >= 1 {
+		pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	}
+
+	if err := pe.putArrayLength(len(r.Groups)); err != nil {
+		return err
+	}
+	for _, group := range r.Groups {
+		if err := group.encode(pe); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *DescribeGroupsResponse) Decode(pd packetDecoder) error {
+	if r.IVersion >= 1 {
+		millis, err := pd.getInt32()
+		if err != nil {
+			return err
+		}
+		r.ThrottleTime = time.Duration(millis) * time.Millisecond
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.Groups = make([]*GroupDescription, n)
+	for i := range r.Groups {
+		r.Groups[i] = new(GroupDescription)
+		if err := r.Groups[i].decode(pd); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *DescribeGroupsResponse) String() string {
+	return dump(r)
+}
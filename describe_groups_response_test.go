@@ -0,0 +1,85 @@
+package sarama
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var (
+	describeGroupsResponseEmpty = []byte{
+		0, 0, 0, 0, // no groups
+	}
+
+	describeGroupsResponsePopulatedV1 = []byte{
+		0, 0, 0, 100,
+		0, 0, 0, 2, // 2 groups
+
+		0, 0, // no error
+		0, 3, 'f', 'o', 'o', // group
+		0, 6, 'S', 't', 'a', 'b', 'l', 'e', // state
+		0, 8, 'c', 'o', 'n', 's', 'u', 'm', 'e', 'r', // protocol type
+		0, 5, 'r', 'a', 'n', 'g', 'e', // protocol
+		0, 0, 0, 1, // 1 member
+		0, 2, 'i', 'd', // member id
+		0, 6, 's', 'a', 'r', 'a', 'm', 'a', // client id
+		0, 9, 'l', 'o', 'c', 'a', 'l', 'h', 'o', 's', 't', // client host
+		0, 0, 0, 0, // no metadata
+		0, 0, 0, 23, // assignment
+		0, 0, // version
+		0, 0, 0, 1,
+		0, 3, 'f', 'o', 'o',
+		0, 0, 0, 1, 0, 0, 0, 0,
+		255, 255, 255, 255, // no user data
+
+		0, 30, // ErrGroupAuthorizationFailed
+		0, 0,
+		0, 0,
+		0, 0,
+		0, 0,
+		0, 0, 0, 0,
+	}
+)
+
+func TestDescribeGroupsResponse(t *testing.T) {
+	response := new(DescribeGroupsResponse)
+	testDecodable(t, "empty", response, describeGroupsResponseEmpty)
+	if len(response.Groups) != 0 {
+		t.Error("Expected no groups")
+	}
+	testEncodable(t, "empty", response, describeGroupsResponseEmpty)
+
+	response = &DescribeGroupsResponse{IVersion: 1}
+	testDecodable(t, "populated", response, describeGroupsResponsePopulatedV1)
+	if response.ThrottleTime != 100*time.Millisecond {
+		t.Error("Decoding ThrottleTime failed, got", response.ThrottleTime)
+	}
+	if len(response.Groups) != 2 {
+		t.Fatal("Expected two groups, got", len(response.Groups))
+	}
+
+	group := response.Groups[0]
+	if group.Err != ErrNoError || group.GroupID != "foo" || group.State != "Stable" ||
+		group.ProtocolType != "consumer" || group.Protocol != "range" {
+		t.Error("Decoding group failed, got", group)
+	}
+	member := group.Members["id"]
+	if member == nil || member.ClientID != "sarama" || member.ClientHost != "localhost" {
+		t.Fatal("Decoding member failed, got", group.Members)
+	}
+	assignment, err := member.GetMemberAssignment()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(assignment.Topics, map[string][]int32{"foo": {0}}) {
+		t.Error("Decoding member assignment failed, got", assignment.Topics)
+	}
+	if metadata, err := member.GetMemberMetadata(); err != nil || len(metadata.Topics) != 0 {
+		t.Error("Expected empty member metadata, got", metadata, err)
+	}
+
+	if response.Groups[1].Err != ErrGroupAuthorizationFailed {
+		t.Error("Decoding group error failed, got", response.Groups[1].Err)
+	}
+	testEncodable(t, "populated", response, describeGroupsResponsePopulatedV1)
+}
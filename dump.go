@@ -0,0 +1,230 @@
+package sarama
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	brokerType   = reflect.TypeOf(&Broker{})
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+)
+
+// redactedFields are the fields, by type and name, whose values dump never shows because they can
+// hold secrets, such as passwords in configuration entries or SASL authentication bytes. Only their
+// keys or size are shown.
+var redactedFields = map[string]bool{
+	"AlterConfigsResource.ConfigEntries":    true,
+	"TopicDetail.ConfigEntries":             true,
+	"IncrementalAlterConfigsEntry.Value":    true,
+	"SaslAuthenticateRequest.SaslAuthBytes": true,
+}
+
+// redactedConfigEntries gives the JSON view of the redactedFields holding configuration entries: their
+// names, each with its value redacted.
+func redactedConfigEntries(entries map[string]*string) map[string]string {
+	if entries == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(entries))
+	for name := range entries {
+		redacted[name] = "<redacted>"
+	}
+	return redacted
+}
+
+// redactedConfigValue gives the JSON view of a redacted configuration value, which only shows
+// whether it is set.
+func redactedConfigValue(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	redacted := "<redacted>"
+	return &redacted
+}
+
+// dump returns the human-readable view of a protocol message that its String method returns: its
+// exported fields, recursively, with the entries of maps sorted by key so that the same message
+// always gives the same string. Message sets and record batches are expanded, including the inner
+// messages of compressed ones, and errors and durations are spelled out. The values of
+// redactedFields are left out.
+func dump(v interface{}) string {
+	var buf bytes.Buffer
+	dumpValue(&buf, reflect.ValueOf(v))
+	return buf.String()
+}
+
+// dumpTruncated is dump cut short after max bytes, for logging.
+func dumpTruncated(v interface{}, max int) string {
+	dumped := dump(v)
+	if len(dumped) <= max {
+		return dumped
+	}
+	return fmt.Sprintf("%s... (%d more bytes)", dumped[:max], len(dumped)-max)
+}
+
+func dumpValue(buf *bytes.Buffer, v reflect.Value) {
+	if !v.IsValid() {
+		buf.WriteString("nil")
+		return
+	}
+
+	switch {
+	case v.Type() == timeType:
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			buf.WriteString("0")
+		} else {
+			buf.WriteString(t.UTC().Format(time.RFC3339Nano))
+		}
+		return
+	case v.Type() == brokerType:
+		if v.IsNil() {
+			buf.WriteString("nil")
+		} else {
+			b := v.Interface().(*Broker)
+			fmt.Fprintf(buf, "Broker{ID: %d, Addr: %q, Rack: %q}", b.ID(), b.Addr(), b.Rack())
+		}
+		return
+	case isPlainValue(v) && v.Type().Implements(errorType):
+		// KError, whose messages are whole sentences
+		fmt.Fprintf(buf, "%q", v.Interface().(error).Error())
+		return
+	case isPlainValue(v) && v.Type().Implements(stringerType):
+		// time.Duration and the like
+		fmt.Fprint(buf, v.Interface())
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteString("nil")
+			return
+		}
+		dumpValue(buf, v.Elem())
+	case reflect.Struct:
+		buf.WriteString(v.Type().Name())
+		buf.WriteByte('{')
+		first := true
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if field.PkgPath != "" {
+				continue // unexported
+			}
+			if !first {
+				buf.WriteString(", ")
+			}
+			first = false
+			buf.WriteString(field.Name)
+			buf.WriteString(": ")
+			if redactedFields[v.Type().Name()+"."+field.Name] {
+				dumpRedacted(buf, v.Field(i))
+			} else {
+				dumpValue(buf, v.Field(i))
+			}
+		}
+		buf.WriteByte('}')
+	case reflect.Map:
+		if v.IsNil() {
+			buf.WriteString("nil")
+			return
+		}
+		keys := dumpKeys(v.MapKeys())
+		sort.Sort(keys)
+		buf.WriteString("map[")
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			dumpValue(buf, key)
+			buf.WriteString(": ")
+			dumpValue(buf, v.MapIndex(key))
+		}
+		buf.WriteByte(']')
+	case reflect.Slice:
+		if v.IsNil() {
+			buf.WriteString("nil")
+			return
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			fmt.Fprintf(buf, "%q", v.Bytes())
+			return
+		}
+		fallthrough
+	case reflect.Array:
+		buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			dumpValue(buf, v.Index(i))
+		}
+		buf.WriteByte(']')
+	case reflect.String:
+		fmt.Fprintf(buf, "%q", v.String())
+	default:
+		fmt.Fprint(buf, v.Interface())
+	}
+}
+
+// dumpRedacted writes the keys of a map with their values redacted, the size of a byte slice, or
+// only whether any other value is set.
+func dumpRedacted(buf *bytes.Buffer, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Map, reflect.Slice, reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			buf.WriteString("nil")
+			return
+		}
+	}
+
+	switch {
+	case v.Kind() == reflect.Map:
+		keys := dumpKeys(v.MapKeys())
+		sort.Sort(keys)
+		buf.WriteString("map[")
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteString(", ")
+			}
+			dumpValue(buf, key)
+			buf.WriteString(": <redacted>")
+		}
+		buf.WriteByte(']')
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+		fmt.Fprintf(buf, "<redacted, %d bytes>", v.Len())
+	default:
+		buf.WriteString("<redacted>")
+	}
+}
+
+// isPlainValue reports whether v is neither a struct nor a pointer or interface that may lead to
+// one, which are dumped field by field even if they have a String method of their own.
+func isPlainValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Struct, reflect.Ptr, reflect.Interface:
+		return false
+	}
+	return true
+}
+
+// dumpKeys sorts the keys of a map, which are strings or integers in every protocol message.
+type dumpKeys []reflect.Value
+
+func (k dumpKeys) Len() int      { return len(k) }
+func (k dumpKeys) Swap(i, j int) { k[i], k[j] = k[j], k[i] }
+func (k dumpKeys) Less(i, j int) bool {
+	switch k[i].Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return k[i].Int() < k[j].Int()
+	case reflect.String:
+		return k[i].String() < k[j].String()
+	}
+	return fmt.Sprint(k[i].Interface()) < fmt.Sprint(k[j].Interface())
+}
@@ -0,0 +1,188 @@
+package sarama
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDumpCompressedProduceRequest(t *testing.T) {
+	inner := new(MessageSet)
+	inner.addMessage(&Message{Key: []byte("k"), Value: []byte("hello")})
+	inner.addMessage(&Message{Value: []byte("world")})
+	innerBytes, err := Encode(inner)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := new(ProduceRequest)
+	request.AddMessage("foo", 1, &Message{Codec: CompressionGZIP, Value: innerBytes})
+	request.AddMessage("bar", 0, &Message{Value: []byte("plain")})
+
+	// decoding decompresses the inner messages, as they would be when captured off the wire
+	packet, err := Encode(&Request{ClientID: "dump", Body: request})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeRequest(bytes.NewReader(packet))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dumped := decoded.Body.(*ProduceRequest).String()
+	for _, expected := range []string{`"hello"`, `"world"`, `Key: "k"`, `"plain"`, "RequiredAcks: 0"} {
+		if !strings.Contains(dumped, expected) {
+			t.Errorf("Expected %s in the dump of the request, got %s", expected, dumped)
+		}
+	}
+	if strings.Index(dumped, `"bar"`) > strings.Index(dumped, `"foo"`) {
+		t.Error("Expected the topics to be sorted, got", dumped)
+	}
+	for i := 0; i < 10; i++ {
+		if again := decoded.Body.(*ProduceRequest).String(); again != dumped {
+			t.Fatalf("Expected the same dump every time, got\n%s\nthen\n%s", dumped, again)
+		}
+	}
+
+	if _, err := json.Marshal(decoded.Body); err != nil {
+		t.Error("Expected the request to marshal to JSON, got", err)
+	}
+}
+
+func TestDumpMetadataResponse(t *testing.T) {
+	rack := "rack-a"
+	broker := NewBroker("localhost:9092")
+	broker.id = 3
+	broker.rack = &rack
+
+	response := &MetadataResponse{IVersion: 1, ControllerID: 3, Brokers: []*Broker{broker}}
+	response.AddTopicPartition("foo", 0, 3, []int32{3}, []int32{3}, ErrLeaderNotAvailable)
+
+	dumped := response.String()
+	for _, expected := range []string{`Broker{ID: 3, Addr: "localhost:9092", Rack: "rack-a"}`, `"` + ErrLeaderNotAvailable.Error() + `"`, "ControllerID: 3"} {
+		if !strings.Contains(dumped, expected) {
+			t.Errorf("Expected %s in the dump of the response, got %s", expected, dumped)
+		}
+	}
+
+	marshalled, err := json.Marshal(response)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(marshalled), `{"ID":3,"Addr":"localhost:9092","Rack":"rack-a"}`) {
+		t.Error("Expected the broker ID and rack in the JSON of the response, got", string(marshalled))
+	}
+}
+
+func TestDumpEveryMessage(t *testing.T) {
+	for key := int16(0); key < 64; key++ {
+		for version := int16(0); version <= maxRequestVersion(key, MaxVersion); version++ {
+			for _, body := range []interface{}{allocateBody(key, version), allocateResponseBody(key, version)} {
+				if body == nil {
+					continue
+				}
+				if _, ok := body.(interface {
+					String() string
+				}); !ok {
+					t.Errorf("Expected %T to have a String method", body)
+				}
+				if _, err := json.Marshal(body); err != nil {
+					t.Errorf("Expected %T to marshal to JSON, got %v", body, err)
+				}
+			}
+		}
+	}
+}
+
+func TestDumpRedactsConfigValues(t *testing.T) {
+	secret := "org.apache.kafka.common.security.plain.PlainLoginModule required password=\"hunter2\";"
+	requests := []interface {
+		String() string
+	}{
+		&CreateTopicsRequest{TopicDetails: map[string]*TopicDetail{
+			"foo": {NumPartitions: 1, ConfigEntries: map[string]*string{"sasl.jaas.config": &secret}},
+		}},
+		&AlterConfigsRequest{Resources: []*AlterConfigsResource{
+			{Type: BrokerResource, Name: "1", ConfigEntries: map[string]*string{"sasl.jaas.config": &secret}},
+		}},
+		&IncrementalAlterConfigsRequest{Resources: []*IncrementalAlterConfigsResource{
+			{Type: BrokerResource, Name: "1", ConfigEntries: map[string]IncrementalAlterConfigsEntry{
+				"sasl.jaas.config": {Operation: IncrementalAlterConfigsOperationSet, Value: &secret},
+			}},
+		}},
+	}
+
+	for _, request := range requests {
+		dumped := request.String()
+		if strings.Contains(dumped, "hunter2") {
+			t.Errorf("Expected the config value to be redacted from %T, got %s", request, dumped)
+		}
+		if !strings.Contains(dumped, `"sasl.jaas.config"`) || !strings.Contains(dumped, "<redacted>") {
+			t.Errorf("Expected the redacted config name in the dump of %T, got %s", request, dumped)
+		}
+	}
+}
+
+func TestDumpRedactsJSON(t *testing.T) {
+	secret := "hunter2"
+	secretBytes := []byte("\x00user\x00" + secret)
+	// a value of each type in redactedFields, with the field set to the secret
+	values := map[string]interface{}{
+		"AlterConfigsResource": &AlterConfigsRequest{Resources: []*AlterConfigsResource{
+			{Type: BrokerResource, Name: "1", ConfigEntries: map[string]*string{"sasl.jaas.config": &secret}},
+		}},
+		"TopicDetail": &CreateTopicsRequest{TopicDetails: map[string]*TopicDetail{
+			"foo": {NumPartitions: 1, ConfigEntries: map[string]*string{"sasl.jaas.config": &secret}},
+		}},
+		"IncrementalAlterConfigsEntry": &IncrementalAlterConfigsRequest{Resources: []*IncrementalAlterConfigsResource{
+			{Type: BrokerResource, Name: "1", ConfigEntries: map[string]IncrementalAlterConfigsEntry{
+				"sasl.jaas.config": {Operation: IncrementalAlterConfigsOperationSet, Value: &secret},
+			}},
+		}},
+		"SaslAuthenticateRequest": &SaslAuthenticateRequest{SaslAuthBytes: secretBytes},
+	}
+
+	for field := range redactedFields {
+		typeName := strings.Split(field, ".")[0]
+		value, ok := values[typeName]
+		if !ok {
+			t.Errorf("Expected a %s to check the JSON view of %s against", typeName, field)
+			continue
+		}
+		if dumped := fmt.Sprint(value); strings.Contains(dumped, secret) {
+			t.Errorf("Expected %s to be redacted from the dump, got %s", field, dumped)
+		}
+		marshalled, err := json.Marshal(value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(marshalled), secret) || strings.Contains(string(marshalled), base64.StdEncoding.EncodeToString(secretBytes)) {
+			t.Errorf("Expected %s to be redacted from the JSON view, got %s", field, marshalled)
+		}
+	}
+
+	// the JSON view of a value held directly, rather than behind a pointer, is redacted too
+	marshalled, err := json.Marshal(TopicDetail{ConfigEntries: map[string]*string{"sasl.jaas.config": &secret}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(marshalled) != `{"NumPartitions":0,"ReplicationFactor":0,"ReplicaAssignment":null,"ConfigEntries":{"sasl.jaas.config":"\u003credacted\u003e"}}` {
+		t.Error("Expected the config entry names with their values redacted, got", string(marshalled))
+	}
+}
+
+func TestDumpTruncated(t *testing.T) {
+	request := new(ProduceRequest)
+	request.AddMessage("foo", 0, &Message{Value: bytes.Repeat([]byte("x"), 10000)})
+
+	dumped := dumpTruncated(request, 100)
+	if !strings.HasPrefix(dumped, request.String()[:100]) || !strings.HasSuffix(dumped, "more bytes)") || len(dumped) > 150 {
+		t.Error("Expected the dump to be cut short after 100 bytes, got", dumped)
+	}
+	if short := new(HeartbeatRequest); dumpTruncated(short, 100) != short.String() {
+		t.Error("Expected a short dump to be left whole, got", dumpTruncated(short, 100))
+	}
+}
@@ -0,0 +1,55 @@
+package sarama
+
+// EndTxnRequest commits or aborts the transaction in progress.
+type EndTxnRequest struct {
+	TransactionalID string
+	ProducerID      int64
+	ProducerEpoch   int16
+	Commit          bool // false to abort
+
+	// Version can be:
+	// - 0 (kafka 0.11 and later)
+	// - 1 (kafka 2.0 and later, laid out as 0)
+	IVersion int16
+}
+
+func (r *EndTxnRequest) Encode(pe packetEncoder) error {
+	if r.IVersion < 0 || r.IVersion > 1 {
+		return PacketEncodingError{"invalid or unsupported EndTxnRequest version field"}
+	}
+
+	if err := pe.putString(r.TransactionalID); err != nil {
+		return err
+	}
+	pe.putInt64(r.ProducerID)
+	pe.putInt16(r.ProducerEpoch)
+	pe.putBool(r.Commit)
+
+	return nil
+}
+
+func (r *EndTxnRequest) Decode(pd packetDecoder) (err error) {
+	if r.TransactionalID, err = pd.getString(); err != nil {
+		return err
+	}
+	if r.ProducerID, err = pd.getInt64(); err != nil {
+		return err
+	}
+	if r.ProducerEpoch, err = pd.getInt16(); err != nil {
+		return err
+	}
+	r.Commit, err = pd.getBool()
+	return err
+}
+
+func (r *EndTxnRequest) Key() int16 {
+	return 26
+}
+
+func (r *EndTxnRequest) Version() int16 {
+	return r.IVersion
+}
+
+func (r *EndTxnRequest) String() string {
+	return dump(r)
+}
@@ -0,0 +1,27 @@
+package sarama
+
+import "testing"
+
+var (
+	endTxnRequestCommit = []byte{
+		0, 3, 't', 'x', 'n',
+		0, 0, 0, 0, 0, 0, 31, 64, // producer ID 8000
+		0, 1, // epoch 1
+		1,
+	}
+
+	endTxnRequestAbort = []byte{
+		0, 3, 't', 'x', 'n',
+		0, 0, 0, 0, 0, 0, 31, 64, // producer ID 8000
+		0, 1, // epoch 1
+		0,
+	}
+)
+
+func TestEndTxnRequest(t *testing.T) {
+	request := &EndTxnRequest{TransactionalID: "txn", ProducerID: 8000, ProducerEpoch: 1, Commit: true}
+	testRequest(t, "commit", request, endTxnRequestCommit)
+
+	request = &EndTxnRequest{TransactionalID: "txn", ProducerID: 8000, ProducerEpoch: 1, IVersion: 1}
+	testRequest(t, "abort v1", request, endTxnRequestAbort)
+}
@@ -0,0 +1,20 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var endTxnResponse = []byte{
+	0, 0, 0, 100,
+	0, 51, // ErrConcurrentTransactions
+}
+
+func TestEndTxnResponse(t *testing.T) {
+	response := new(EndTxnResponse)
+	testDecodable(t, "", response, endTxnResponse)
+	if response.ThrottleTime != 100*time.Millisecond || response.Err != ErrConcurrentTransactions {
+		t.Error("Decoding failed, got", response)
+	}
+	testEncodable(t, "", response, endTxnResponse)
+}
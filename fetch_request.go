@@ -1,36 +1,97 @@
 package sarama
 
 type fetchRequestBlock struct {
-	FetchOffset int64
-	MaxBytes    int32
+	CurrentLeaderEpoch int32 // v9 or later, -1 if unknown
+	FetchOffset        int64
+	LogStartOffset     int64 // v5 or later, only meaningful for followers; -1 for consumers
+	MaxBytes           int32
 }
 
-func (f *fetchRequestBlock) Encode(pe packetEncoder) error {
+func (f *fetchRequestBlock) encode(pe packetEncoder, version int16) error {
+	if version >= 9 {
+		pe.putInt32(f.CurrentLeaderEpoch)
+	}
 	pe.putInt64(f.FetchOffset)
+	if version >= 5 {
+		pe.putInt64(f.LogStartOffset)
+	}
 	pe.putInt32(f.MaxBytes)
 	return nil
 }
 
-func (f *fetchRequestBlock) Decode(pd packetDecoder) (err error) {
+func (f *fetchRequestBlock) decode(pd packetDecoder, version int16) (err error) {
+	f.CurrentLeaderEpoch = -1
+	if version >= 9 {
+		if f.CurrentLeaderEpoch, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
 	if f.FetchOffset, err = pd.getInt64(); err != nil {
 		return err
 	}
+	f.LogStartOffset = -1
+	if version >= 5 {
+		if f.LogStartOffset, err = pd.getInt64(); err != nil {
+			return err
+		}
+	}
 	if f.MaxBytes, err = pd.getInt32(); err != nil {
 		return err
 	}
 	return nil
 }
 
+// IsolationLevel determines whether version 4 and later of FetchRequest return the messages of
+// transactions which have not been committed (yet).
+type IsolationLevel int8
+
+const (
+	ReadUncommitted IsolationLevel = 0
+	ReadCommitted   IsolationLevel = 1
+)
+
 type FetchRequest struct {
 	MaxWaitTime int32
 	MinBytes    int32
-	Blocks      map[string]map[int32]*fetchRequestBlock
+	MaxBytes    int32          // v3 or later
+	Isolation   IsolationLevel // v4 or later
+
+	// Version can be:
+	// - 0 (kafka 0.8 and later)
+	// - 1 (kafka 0.9 and later)
+	// - 2 (kafka 0.10 and later, returning version 1 messages)
+	// - 3 (kafka 0.10.1 and later)
+	// - 4 (kafka 0.11 and later, returning RecordBatches)
+	// - 5 (kafka 0.11 and later, adds log start offsets)
+	// - 6 (kafka 1.0 and later, laid out as 5)
+	// - 7 (kafka 1.1 and later, adds fetch sessions)
+	// - 8 (kafka 2.0 and later, laid out as 7)
+	// - 9 (kafka 2.1 and later, adds current leader epochs)
+	// - 10 (kafka 2.1 and later, laid out as 9, may return ZSTD compressed data)
+	// Fetch sessions are not supported: from version 7 every request is a full
+	// fetch, outside of any session.
+	IVersion int16
+	Blocks   map[string]map[int32]*fetchRequestBlock
 }
 
 func (f *FetchRequest) Encode(pe packetEncoder) (err error) {
+	if f.IVersion < 0 || f.IVersion > 10 {
+		return PacketEncodingError{"invalid or unsupported FetchRequest version field"}
+	}
+
 	pe.putInt32(-1) // replica ID is always -1 for clients
 	pe.putInt32(f.MaxWaitTime)
 	pe.putInt32(f.MinBytes)
+	if f.IVersion >= 3 {
+		pe.putInt32(f.MaxBytes)
+	}
+	if f.IVersion >= 4 {
+		pe.putInt8(int8(f.Isolation))
+	}
+	if f.IVersion >= 7 {
+		pe.putInt32(0)  // no session ID
+		pe.putInt32(-1) // the epoch of a full fetch outside of a session
+	}
 	err = pe.putArrayLength(len(f.Blocks))
 	if err != nil {
 		return err
@@ -46,12 +107,18 @@ func (f *FetchRequest) Encode(pe packetEncoder) (err error) {
 		}
 		for partition, block := range blocks {
 			pe.putInt32(partition)
-			err = block.Encode(pe)
+			err = block.encode(pe, f.IVersion)
 			if err != nil {
 				return err
 			}
 		}
 	}
+	if f.IVersion >= 7 {
+		// no partitions to forget, as there is no session
+		if err = pe.putArrayLength(0); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -65,14 +132,34 @@ func (f *FetchRequest) Decode(pd packetDecoder) (err error) {
 	if f.MinBytes, err = pd.getInt32(); err != nil {
 		return err
 	}
+	if f.IVersion >= 3 {
+		if f.MaxBytes, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
+	if f.IVersion >= 4 {
+		isolation, err := pd.getInt8()
+		if err != nil {
+			return err
+		}
+		f.Isolation = IsolationLevel(isolation)
+	}
+	if f.IVersion >= 7 {
+		// the session ID and epoch
+		if _, err = pd.getInt32(); err != nil {
+			return err
+		}
+		if _, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
 	topicCount, err := pd.getArrayLength()
 	if err != nil {
 		return err
 	}
-	if topicCount == 0 {
-		return nil
+	if topicCount > 0 {
+		f.Blocks = make(map[string]map[int32]*fetchRequestBlock)
 	}
-	f.Blocks = make(map[string]map[int32]*fetchRequestBlock)
 	for i := 0; i < topicCount; i++ {
 		topic, err := pd.getString()
 		if err != nil {
@@ -89,12 +176,27 @@ func (f *FetchRequest) Decode(pd packetDecoder) (err error) {
 				return err
 			}
 			fetchBlock := &fetchRequestBlock{}
-			if err = fetchBlock.Decode(pd); err != nil {
-				return nil
+			if err = fetchBlock.decode(pd, f.IVersion); err != nil {
+				return err
 			}
 			f.Blocks[topic][partition] = fetchBlock
 		}
 	}
+	if f.IVersion >= 7 {
+		// the partitions to forget, of which a full fetch has none
+		forgotten, err := pd.getArrayLength()
+		if err != nil {
+			return err
+		}
+		for i := 0; i < forgotten; i++ {
+			if _, err = pd.getString(); err != nil {
+				return err
+			}
+			if _, err = pd.getInt32Array(); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
@@ -103,7 +205,11 @@ func (f *FetchRequest) Key() int16 {
 }
 
 func (f *FetchRequest) Version() int16 {
-	return 0
+	return f.IVersion
+}
+
+func (f *FetchRequest) String() string {
+	return dump(f)
 }
 
 func (f *FetchRequest) AddBlock(topic string, partitionID int32, fetchOffset int64, maxBytes int32) {
@@ -116,8 +222,10 @@ func (f *FetchRequest) AddBlock(topic string, partitionID int32, fetchOffset int
 	}
 
 	tmp := new(fetchRequestBlock)
+	tmp.CurrentLeaderEpoch = -1
 	tmp.MaxBytes = maxBytes
 	tmp.FetchOffset = fetchOffset
+	tmp.LogStartOffset = -1
 
 	f.Blocks[topic][partitionID] = tmp
 }
@@ -17,6 +17,22 @@ var (
 		0x00, 0x05, 't', 'o', 'p', 'i', 'c',
 		0x00, 0x00, 0x00, 0x01,
 		0x00, 0x00, 0x00, 0x12, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x34, 0x00, 0x00, 0x00, 0x56}
+
+	fetchRequestOneBlockV10 = []byte{
+		0xFF, 0xFF, 0xFF, 0xFF, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0xFF, // max bytes
+		0x01,                   // read committed
+		0x00, 0x00, 0x00, 0x00, // no session
+		0xFF, 0xFF, 0xFF, 0xFF, // full fetch
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x05, 't', 'o', 'p', 'i', 'c',
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x00, 0x12,
+		0xFF, 0xFF, 0xFF, 0xFF, // unknown leader epoch
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x34,
+		0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, // no log start offset
+		0x00, 0x00, 0x00, 0x56,
+		0x00, 0x00, 0x00, 0x00} // nothing forgotten
 )
 
 func TestFetchRequest(t *testing.T) {
@@ -32,3 +48,9 @@ func TestFetchRequest(t *testing.T) {
 	request.AddBlock("topic", 0x12, 0x34, 0x56)
 	testRequest(t, "one block", request, fetchRequestOneBlock)
 }
+
+func TestFetchRequestV10(t *testing.T) {
+	request := &FetchRequest{MaxBytes: 0xFF, Isolation: ReadCommitted, IVersion: 10}
+	request.AddBlock("topic", 0x12, 0x34, 0x56)
+	testRequest(t, "one block", request, fetchRequestOneBlockV10)
+}
@@ -1,12 +1,51 @@
 package sarama
 
+import (
+	"sort"
+	"time"
+)
+
+type AbortedTransaction struct {
+	ProducerID  int64
+	FirstOffset int64
+}
+
+func (t *AbortedTransaction) Decode(pd packetDecoder) (err error) {
+	if t.ProducerID, err = pd.getInt64(); err != nil {
+		return err
+	}
+	t.FirstOffset, err = pd.getInt64()
+	return err
+}
+
+func (t *AbortedTransaction) Encode(pe packetEncoder) error {
+	pe.putInt64(t.ProducerID)
+	pe.putInt64(t.FirstOffset)
+	return nil
+}
+
+// abortedTransactionsByOffset sorts aborted transactions by their first offset.
+type abortedTransactionsByOffset []*AbortedTransaction
+
+func (a abortedTransactionsByOffset) Len() int           { return len(a) }
+func (a abortedTransactionsByOffset) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+func (a abortedTransactionsByOffset) Less(i, j int) bool { return a[i].FirstOffset < a[j].FirstOffset }
+
 type FetchResponseBlock struct {
 	Err                 KError
 	HighWaterMarkOffset int64
-	MsgSet              MessageSet
+	LastStableOffset    int64                 // v4 or later
+	LogStartOffset      int64                 // v5 or later
+	AbortedTransactions []*AbortedTransaction // v4 or later
+
+	// The fetched data: messages in the old format go in MsgSet, record batches in RecordBatches.
+	// Since v4 the broker may return both, when a partition has data written in both formats.
+	MsgSet                     MessageSet
+	RecordBatches              []*RecordBatch
+	PartialTrailingRecordBatch bool // whether the data on the wire ended with an incomplete RecordBatch
 }
 
-func (pr *FetchResponseBlock) Decode(pd packetDecoder) (err error) {
+func (pr *FetchResponseBlock) Decode(pd packetDecoder, version int16) (err error) {
 	tmp, err := pd.getInt16()
 	if err != nil {
 		return err
@@ -18,38 +57,160 @@ func (pr *FetchResponseBlock) Decode(pd packetDecoder) (err error) {
 		return err
 	}
 
-	msgSetSize, err := pd.getInt32()
+	if version >= 4 {
+		if pr.LastStableOffset, err = pd.getInt64(); err != nil {
+			return err
+		}
+		if version >= 5 {
+			if pr.LogStartOffset, err = pd.getInt64(); err != nil {
+				return err
+			}
+		}
+		// read by hand, as this array is null (-1) rather than empty when there are none
+		numTransactions, err := pd.getInt32()
+		if err != nil {
+			return err
+		}
+		if int(numTransactions) > pd.remaining()/16 {
+			return ErrInsufficientData
+		}
+		if numTransactions > 0 {
+			pr.AbortedTransactions = make([]*AbortedTransaction, numTransactions)
+			for i := range pr.AbortedTransactions {
+				pr.AbortedTransactions[i] = new(AbortedTransaction)
+				if err = pr.AbortedTransactions[i].Decode(pd); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	recordsSize, err := pd.getInt32()
 	if err != nil {
 		return err
 	}
 
-	msgSetDecoder, err := pd.getSubset(int(msgSetSize))
+	recordsDecoder, err := pd.getSubset(int(recordsSize))
 	if err != nil {
 		return err
 	}
-	err = (&pr.MsgSet).Decode(msgSetDecoder)
 
-	return err
+	for recordsDecoder.remaining() > 0 {
+		// both formats keep the magic byte at the same position, after the offset, the size,
+		// and a CRC (message sets) or a leader epoch (record batches)
+		magic, err := recordsDecoder.peekInt8(16)
+		if err == ErrInsufficientData {
+			pr.MsgSet.PartialTrailingMessage = true
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if magic < 2 {
+			msb := new(MessageBlock)
+			switch err = msb.Decode(recordsDecoder); err {
+			case nil:
+				pr.MsgSet.Messages = append(pr.MsgSet.Messages, msb)
+			case ErrInsufficientData:
+				// As an optimization the server is allowed to return a partial message at the
+				// end of the message set. Clients should handle this case. So we just ignore such things.
+				pr.MsgSet.PartialTrailingMessage = true
+				return nil
+			default:
+				return err
+			}
+		} else {
+			batch := new(RecordBatch)
+			switch err = batch.Decode(recordsDecoder); err {
+			case nil:
+				pr.RecordBatches = append(pr.RecordBatches, batch)
+			case ErrInsufficientData:
+				pr.PartialTrailingRecordBatch = true
+				return nil
+			default:
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (pr *FetchResponseBlock) isPartial() bool {
+	return pr.MsgSet.PartialTrailingMessage || pr.PartialTrailingRecordBatch
+}
+
+// sortedAbortedTransactions returns a copy of AbortedTransactions, sorted by first offset.
+func (pr *FetchResponseBlock) sortedAbortedTransactions() []*AbortedTransaction {
+	sorted := make([]*AbortedTransaction, len(pr.AbortedTransactions))
+	copy(sorted, pr.AbortedTransactions)
+	sort.Sort(abortedTransactionsByOffset(sorted))
+	return sorted
 }
 
 type FetchResponse struct {
-	Blocks map[string]map[int32]*FetchResponseBlock
+	Blocks       map[string]map[int32]*FetchResponseBlock
+	ThrottleTime time.Duration // only provided if Version >= 1
+	Err          KError        // v7 or later, the error of the fetch session
+	SessionID    int32         // v7 or later
+
+	// Version must be set to that of the request before decoding
+	IVersion int16
 }
 
-func (pr *FetchResponseBlock) Encode(pe packetEncoder) (err error) {
+func (pr *FetchResponseBlock) Encode(pe packetEncoder, version int16) (err error) {
 	pe.putInt16(int16(pr.Err))
 
 	pe.putInt64(pr.HighWaterMarkOffset)
 
+	if version >= 4 {
+		pe.putInt64(pr.LastStableOffset)
+		if version >= 5 {
+			pe.putInt64(pr.LogStartOffset)
+		}
+		if err = pe.putArrayLength(len(pr.AbortedTransactions)); err != nil {
+			return err
+		}
+		for _, transaction := range pr.AbortedTransactions {
+			if err = transaction.Encode(pe); err != nil {
+				return err
+			}
+		}
+	}
+
 	pe.push(&lengthField{})
 	err = pr.MsgSet.Encode(pe)
 	if err != nil {
 		return err
 	}
+	for _, batch := range pr.RecordBatches {
+		if err = batch.Encode(pe); err != nil {
+			return err
+		}
+	}
 	return pe.pop()
 }
 
 func (fr *FetchResponse) Decode(pd packetDecoder) (err error) {
+	if fr.IVersion >= 1 {
+		millis, err := pd.getInt32()
+		if err != nil {
+			return err
+		}
+		fr.ThrottleTime = time.Duration(millis) * time.Millisecond
+	}
+
+	if fr.IVersion >= 7 {
+		kerr, err := pd.getInt16()
+		if err != nil {
+			return err
+		}
+		fr.Err = KError(kerr)
+		if fr.SessionID, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
+
 	numTopics, err := pd.getArrayLength()
 	if err != nil {
 		return err
@@ -76,7 +237,7 @@ func (fr *FetchResponse) Decode(pd packetDecoder) (err error) {
 			}
 
 			block := new(FetchResponseBlock)
-			err = block.Decode(pd)
+			err = block.Decode(pd, fr.IVersion)
 			if err != nil {
 				return err
 			}
@@ -88,6 +249,15 @@ func (fr *FetchResponse) Decode(pd packetDecoder) (err error) {
 }
 
 func (fr *FetchResponse) Encode(pe packetEncoder) (err error) {
+	if fr.IVersion >= 1 {
+		pe.putInt32(int32(fr.ThrottleTime / time.Millisecond))
+	}
+
+	if fr.IVersion >= 7 {
+		pe.putInt16(int16(fr.Err))
+		pe.putInt32(fr.SessionID)
+	}
+
 	err = pe.putArrayLength(len(fr.Blocks))
 	if err != nil {
 		return err
@@ -106,7 +276,7 @@ func (fr *FetchResponse) Encode(pe packetEncoder) (err error) {
 
 		for id, block := range partitions {
 			pe.putInt32(id)
-			err = block.Encode(pe)
+			err = block.Encode(pe, fr.IVersion)
 			if err != nil {
 				return err
 			}
@@ -116,6 +286,10 @@ func (fr *FetchResponse) Encode(pe packetEncoder) (err error) {
 	return nil
 }
 
+func (fr *FetchResponse) String() string {
+	return dump(fr)
+}
+
 func (fr *FetchResponse) GetBlock(topic string, partition int32) *FetchResponseBlock {
 	if fr.Blocks == nil {
 		return nil
@@ -171,3 +345,32 @@ func (fr *FetchResponse) AddMessage(topic string, partition int32, key, value IE
 	msgBlock := &MessageBlock{Msg: msg, Offset: offset}
 	frb.MsgSet.Messages = append(frb.MsgSet.Messages, msgBlock)
 }
+
+func (fr *FetchResponse) AddRecord(topic string, partition int32, key, value IEncoder, offset int64) {
+	if fr.Blocks == nil {
+		fr.Blocks = make(map[string]map[int32]*FetchResponseBlock)
+	}
+	partitions, ok := fr.Blocks[topic]
+	if !ok {
+		partitions = make(map[int32]*FetchResponseBlock)
+		fr.Blocks[topic] = partitions
+	}
+	frb, ok := partitions[partition]
+	if !ok {
+		frb = new(FetchResponseBlock)
+		partitions[partition] = frb
+	}
+	var kb []byte
+	var vb []byte
+	if key != nil {
+		kb, _ = key.IEncode()
+	}
+	if value != nil {
+		vb, _ = value.IEncode()
+	}
+	if len(frb.RecordBatches) == 0 {
+		frb.RecordBatches = append(frb.RecordBatches, &RecordBatch{ProducerID: -1, ProducerEpoch: -1, FirstSequence: -1})
+	}
+	batch := frb.RecordBatches[len(frb.RecordBatches)-1]
+	batch.AddRecord(offset, time.Time{}, kb, vb, nil)
+}
@@ -3,12 +3,17 @@ package sarama
 import (
 	"bytes"
 	"testing"
+	"time"
 )
 
 var (
 	emptyFetchResponse = []byte{
 		0x00, 0x00, 0x00, 0x00}
 
+	emptyFetchResponseV1 = []byte{
+		0x00, 0x00, 0x00, 0x0A, // throttle time
+		0x00, 0x00, 0x00, 0x00}
+
 	oneMessageFetchResponse = []byte{
 		0x00, 0x00, 0x00, 0x01,
 		0x00, 0x05, 't', 'o', 'p', 'i', 'c',
@@ -28,6 +33,86 @@ var (
 		0x00, 0x00, 0x00, 0x02, 0x00, 0xEE}
 )
 
+func TestOneRecordBatchFetchResponseV4(t *testing.T) {
+	raw := []byte{
+		0x00, 0x00, 0x00, 0x00, // throttle time
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x05, 't', 'o', 'p', 'i', 'c',
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x00, 0x05,
+		0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, // high water mark
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, // last stable offset
+		0xFF, 0xFF, 0xFF, 0xFF, // no aborted transactions
+		0x00, 0x00, 0x00, byte(len(oneRecordBatch))}
+	raw = append(raw, oneRecordBatch...)
+
+	response := FetchResponse{IVersion: 4}
+	testDecodable(t, "one record batch", &response, raw)
+
+	block := response.GetBlock("topic", 5)
+	if block == nil {
+		t.Fatal("GetBlock didn't return block.")
+	}
+	if block.LastStableOffset != 0x10 {
+		t.Error("Decoding didn't produce correct last stable offset.")
+	}
+	if block.AbortedTransactions != nil {
+		t.Error("Decoding produced aborted transactions where there were none.")
+	}
+	if len(block.MsgSet.Messages) != 0 || len(block.RecordBatches) != 1 {
+		t.Fatal("Decoding produced incorrect number of messages or record batches.")
+	}
+	if len(block.RecordBatches[0].Records) != 1 {
+		t.Error("Decoding produced incorrect number of records.")
+	}
+
+	// a batch cut short by the broker is flagged, not an error
+	response = FetchResponse{IVersion: 4}
+	raw[len(raw)-len(oneRecordBatch)-1] += 30
+	raw = append(raw, oneRecordBatch[:30]...)
+	testDecodable(t, "partial record batch", &response, raw)
+	if block = response.GetBlock("topic", 5); !block.PartialTrailingRecordBatch || len(block.RecordBatches) != 1 {
+		t.Error("Decoding didn't detect the partial trailing record batch.")
+	}
+}
+
+func TestOneRecordBatchFetchResponseV10(t *testing.T) {
+	raw := []byte{
+		0x00, 0x00, 0x00, 0x00, // throttle time
+		0x00, 0x00, // no error
+		0x00, 0x00, 0x00, 0x00, // no session
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x05, 't', 'o', 'p', 'i', 'c',
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x00, 0x05,
+		0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, // high water mark
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, // last stable offset
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, // log start offset
+		0x00, 0x00, 0x00, 0x00, // no aborted transactions
+		0x00, 0x00, 0x00, byte(len(oneRecordBatch))}
+	raw = append(raw, oneRecordBatch...)
+
+	response := FetchResponse{IVersion: 10}
+	testDecodable(t, "one record batch", &response, raw)
+
+	if response.Err != ErrNoError || response.SessionID != 0 {
+		t.Error("Decoding didn't produce the correct session:", response.Err, response.SessionID)
+	}
+	block := response.GetBlock("topic", 5)
+	if block == nil {
+		t.Fatal("GetBlock didn't return block.")
+	}
+	if block.LastStableOffset != 0x10 || block.LogStartOffset != 2 {
+		t.Error("Decoding didn't produce correct last stable and log start offsets.")
+	}
+	if len(block.RecordBatches) != 1 || len(block.RecordBatches[0].Records) != 1 {
+		t.Fatal("Decoding produced incorrect number of record batches or records.")
+	}
+	testEncodable(t, "one record batch", &response, raw)
+}
+
 func TestEmptyFetchResponse(t *testing.T) {
 	response := FetchResponse{}
 	testDecodable(t, "empty", &response, emptyFetchResponse)
@@ -38,6 +123,18 @@ func TestEmptyFetchResponse(t *testing.T) {
 
 }
 
+func TestEmptyFetchResponseV1(t *testing.T) {
+	response := FetchResponse{IVersion: 1}
+	testDecodable(t, "empty v1", &response, emptyFetchResponseV1)
+
+	if response.ThrottleTime != 10*time.Millisecond {
+		t.Error("Decoding produced incorrect throttle time:", response.ThrottleTime)
+	}
+	if len(response.Blocks) != 0 {
+		t.Error("Decoding produced topic blocks where there were none.")
+	}
+}
+
 func TestOneMessageFetchResponse(t *testing.T) {
 	response := FetchResponse{}
 	testDecodable(t, "one message", &response, oneMessageFetchResponse)
@@ -0,0 +1,47 @@
+package sarama
+
+type HeartbeatRequest struct {
+	GroupID      string
+	GenerationID int32
+	MemberID     string
+
+	// Version can be:
+	// - 0 (kafka 0.9 and later)
+	// - 1 (kafka 0.11 and later, adding throttle time to the response)
+	IVersion int16
+}
+
+func (r *HeartbeatRequest) Encode(pe packetEncoder) error {
+	if r.IVersion < 0 || r.IVersion > 1 {
+		return PacketEncodingError{"invalid or unsupported HeartbeatRequest version field"}
+	}
+
+	if err := pe.putString(r.GroupID); err != nil {
+		return err
+	}
+	pe.putInt32(r.GenerationID)
+	return pe.putString(r.MemberID)
+}
+
+func (r *HeartbeatRequest) Decode(pd packetDecoder) (err error) {
+	if r.GroupID, err = pd.getString(); err != nil {
+		return err
+	}
+	if r.GenerationID, err = pd.getInt32(); err != nil {
+		return err
+	}
+	r.MemberID, err = pd.getString()
+	return err
+}
+
+func (r *HeartbeatRequest) Key() int16 {
+	return 12
+}
+
+func (r *HeartbeatRequest) Version() int16 {
+	return r.IVersion
+}
+
+func (r *HeartbeatRequest) String() string {
+	return dump(r)
+}
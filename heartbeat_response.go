@@ -0,0 +1,40 @@
+package sarama
+
+import "time"
+
+type HeartbeatResponse struct {
+	ThrottleTime time.Duration // only provided if Version >= 1
+	Err          KError
+
+	// Version must be set to that of the request before decoding
+	IVersion int16
+}
+
+func (r *HeartbeatResponse) Encode(pe packetEncoder) error {
+	if r.IVersion >= 1 {
+		pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	}
+	pe.putInt16(int16(r.Err))
+	return nil
+}
+
+func (r *HeartbeatResponse) Decode(pd packetDecoder) error {
+	if r.IVersion >= 1 {
+		millis, err := pd.getInt32()
+		if err != nil {
+			return err
+		}
+		r.ThrottleTime = time.Duration(millis) * time.Millisecond
+	}
+
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.Err = KError(kerr)
+	return nil
+}
+
+func (r *HeartbeatResponse) String() string {
+	return dump(r)
+}
@@ -0,0 +1,34 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	heartbeatResponseNoError = []byte{
+		0x00, 0x00}
+
+	heartbeatResponseRebalancingV1 = []byte{
+		0x00, 0x00, 0x00, 0x64,
+		0x00, 0x1B}
+)
+
+func TestHeartbeatResponse(t *testing.T) {
+	response := new(HeartbeatResponse)
+	testDecodable(t, "no error", response, heartbeatResponseNoError)
+	if response.Err != ErrNoError {
+		t.Error("Decoding error failed: no error expected but found", response.Err)
+	}
+	testResponse(t, "no error", response, heartbeatResponseNoError)
+
+	response = &HeartbeatResponse{IVersion: 1}
+	testDecodable(t, "rebalancing", response, heartbeatResponseRebalancingV1)
+	if response.ThrottleTime != 100*time.Millisecond {
+		t.Error("Decoding ThrottleTime failed, found:", response.ThrottleTime)
+	}
+	if response.Err != ErrRebalanceInProgress {
+		t.Error("Decoding error failed: ErrRebalanceInProgress expected but found", response.Err)
+	}
+	testEncodable(t, "rebalancing", response, heartbeatResponseRebalancingV1)
+}
@@ -0,0 +1,154 @@
+package sarama
+
+import "encoding/json"
+
+// IncrementalAlterConfigsOperation is how an incremental alteration changes a configuration entry.
+type IncrementalAlterConfigsOperation int8
+
+const (
+	IncrementalAlterConfigsOperationSet      IncrementalAlterConfigsOperation = 0
+	IncrementalAlterConfigsOperationDelete   IncrementalAlterConfigsOperation = 1 // reverts the entry to its default
+	IncrementalAlterConfigsOperationAppend   IncrementalAlterConfigsOperation = 2 // adds the value to a list entry
+	IncrementalAlterConfigsOperationSubtract IncrementalAlterConfigsOperation = 3 // removes the value from a list entry
+)
+
+// IncrementalAlterConfigsEntry is a change to a single configuration entry.
+type IncrementalAlterConfigsEntry struct {
+	Operation IncrementalAlterConfigsOperation
+	Value     *string
+}
+
+// MarshalJSON gives the JSON view of the entry without its value, which can be a secret.
+func (e IncrementalAlterConfigsEntry) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Operation IncrementalAlterConfigsOperation
+		Value     *string
+	}{e.Operation, redactedConfigValue(e.Value)})
+}
+
+// IncrementalAlterConfigsResource holds the changes to the configuration entries of a topic or
+// broker. Unlike with AlterConfigsResource, entries that are left out keep their values.
+type IncrementalAlterConfigsResource struct {
+	Type          ConfigResourceType
+	Name          string
+	ConfigEntries map[string]IncrementalAlterConfigsEntry
+}
+
+func (r *IncrementalAlterConfigsResource) encode(pe packetEncoder) error {
+	pe.putInt8(int8(r.Type))
+	if err := pe.putString(r.Name); err != nil {
+		return err
+	}
+
+	if err := pe.putArrayLength(len(r.ConfigEntries)); err != nil {
+		return err
+	}
+	for name, entry := range r.ConfigEntries {
+		if err := pe.putString(name); err != nil {
+			return err
+		}
+		pe.putInt8(int8(entry.Operation))
+		if err := pe.putNullableString(entry.Value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *IncrementalAlterConfigsResource) decode(pd packetDecoder) (err error) {
+	resourceType, err := pd.getInt8()
+	if err != nil {
+		return err
+	}
+	r.Type = ConfigResourceType(resourceType)
+
+	if r.Name, err = pd.getString(); err != nil {
+		return err
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.ConfigEntries = make(map[string]IncrementalAlterConfigsEntry, n)
+	for i := 0; i < n; i++ {
+		name, err := pd.getString()
+		if err != nil {
+			return err
+		}
+
+		var entry IncrementalAlterConfigsEntry
+		operation, err := pd.getInt8()
+		if err != nil {
+			return err
+		}
+		entry.Operation = IncrementalAlterConfigsOperation(operation)
+		if entry.Value, err = pd.getNullableString(); err != nil {
+			return err
+		}
+		r.ConfigEntries[name] = entry
+	}
+
+	return nil
+}
+
+type IncrementalAlterConfigsRequest struct {
+	Resources    []*IncrementalAlterConfigsResource
+	ValidateOnly bool
+
+	// Version can be:
+	// - 0 (kafka 2.3 and later)
+	IVersion int16
+}
+
+func (r *IncrementalAlterConfigsRequest) Encode(pe packetEncoder) error {
+	if r.IVersion != 0 {
+		return PacketEncodingError{"invalid or unsupported IncrementalAlterConfigsRequest version field"}
+	}
+
+	if err := pe.putArrayLength(len(r.Resources)); err != nil {
+		return err
+	}
+	for _, resource := range r.Resources {
+		if err := resource.encode(pe); err != nil {
+			return err
+		}
+	}
+
+	pe.putBool(r.ValidateOnly)
+
+	return nil
+}
+
+func (r *IncrementalAlterConfigsRequest) Decode(pd packetDecoder) (err error) {
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.Resources = make([]*IncrementalAlterConfigsResource, n)
+	for i := range r.Resources {
+		r.Resources[i] = new(IncrementalAlterConfigsResource)
+		if err := r.Resources[i].decode(pd); err != nil {
+			return err
+		}
+	}
+
+	if r.ValidateOnly, err = pd.getBool(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *IncrementalAlterConfigsRequest) Key() int16 {
+	return 44
+}
+
+func (r *IncrementalAlterConfigsRequest) Version() int16 {
+	return r.IVersion
+}
+
+func (r *IncrementalAlterConfigsRequest) String() string {
+	return dump(r)
+}
@@ -0,0 +1,30 @@
+package sarama
+
+import "testing"
+
+var incrementalAlterConfigsRequest = []byte{
+	0, 0, 0, 1,
+	4, // broker
+	0, 1, '1',
+	0, 0, 0, 1,
+	0, 19, 'l', 'o', 'g', '.', 'c', 'l', 'e', 'a', 'n', 'e', 'r', '.', 't', 'h', 'r', 'e', 'a', 'd', 's',
+	0, // set
+	0, 1, '2',
+	1, // validate only
+}
+
+func TestIncrementalAlterConfigsRequest(t *testing.T) {
+	threads := "2"
+
+	request := &IncrementalAlterConfigsRequest{
+		Resources: []*IncrementalAlterConfigsResource{{
+			Type: BrokerResource,
+			Name: "1",
+			ConfigEntries: map[string]IncrementalAlterConfigsEntry{
+				"log.cleaner.threads": {Operation: IncrementalAlterConfigsOperationSet, Value: &threads},
+			},
+		}},
+		ValidateOnly: true,
+	}
+	testRequest(t, "version 0", request, incrementalAlterConfigsRequest)
+}
@@ -0,0 +1,16 @@
+package sarama
+
+// IncrementalAlterConfigsResponse is identical on the wire to AlterConfigsResponse.
+type IncrementalAlterConfigsResponse AlterConfigsResponse
+
+func (r *IncrementalAlterConfigsResponse) Encode(pe packetEncoder) error {
+	return (*AlterConfigsResponse)(r).Encode(pe)
+}
+
+func (r *IncrementalAlterConfigsResponse) Decode(pd packetDecoder) error {
+	return (*AlterConfigsResponse)(r).Decode(pd)
+}
+
+func (r *IncrementalAlterConfigsResponse) String() string {
+	return dump(r)
+}
@@ -0,0 +1,12 @@
+package sarama
+
+import "testing"
+
+func TestIncrementalAlterConfigsResponse(t *testing.T) {
+	response := new(IncrementalAlterConfigsResponse)
+	testDecodable(t, "incremental alter configs", response, alterConfigsResponse)
+	if len(response.Resources) != 1 || response.Resources[0].Err != ErrInvalidConfig {
+		t.Error("Decoding resources failed, got", response.Resources)
+	}
+	testEncodable(t, "incremental alter configs", response, alterConfigsResponse)
+}
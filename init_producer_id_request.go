@@ -0,0 +1,54 @@
+package sarama
+
+import "time"
+
+// InitProducerIDRequest asks for the producer ID and epoch that idempotent and transactional
+// producers stamp their record batches with.
+type InitProducerIDRequest struct {
+	TransactionalID    *string // nil for a producer that is only idempotent
+	TransactionTimeout time.Duration
+
+	// Version can be:
+	// - 0 (kafka 0.11 and later)
+	// - 1 (kafka 2.0 and later, laid out as 0)
+	IVersion int16
+}
+
+func (r *InitProducerIDRequest) Encode(pe packetEncoder) error {
+	if r.IVersion < 0 || r.IVersion > 1 {
+		return PacketEncodingError{"invalid or unsupported InitProducerIDRequest version field"}
+	}
+
+	if err := pe.putNullableString(r.TransactionalID); err != nil {
+		return err
+	}
+	pe.putInt32(int32(r.TransactionTimeout / time.Millisecond))
+
+	return nil
+}
+
+func (r *InitProducerIDRequest) Decode(pd packetDecoder) (err error) {
+	if r.TransactionalID, err = pd.getNullableString(); err != nil {
+		return err
+	}
+
+	millis, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.TransactionTimeout = time.Duration(millis) * time.Millisecond
+
+	return nil
+}
+
+func (r *InitProducerIDRequest) Key() int16 {
+	return 22
+}
+
+func (r *InitProducerIDRequest) Version() int16 {
+	return r.IVersion
+}
+
+func (r *InitProducerIDRequest) String() string {
+	return dump(r)
+}
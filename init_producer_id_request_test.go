@@ -0,0 +1,27 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	initProducerIDRequestNull = []byte{
+		255, 255,
+		0, 0, 0, 100,
+	}
+
+	initProducerIDRequest = []byte{
+		0, 3, 't', 'x', 'n',
+		0, 0, 0xea, 0x60,
+	}
+)
+
+func TestInitProducerIDRequest(t *testing.T) {
+	request := &InitProducerIDRequest{TransactionTimeout: 100 * time.Millisecond}
+	testRequest(t, "idempotent", request, initProducerIDRequestNull)
+
+	transactionalID := "txn"
+	request = &InitProducerIDRequest{TransactionalID: &transactionalID, TransactionTimeout: time.Minute, IVersion: 1}
+	testRequest(t, "transactional v1", request, initProducerIDRequest)
+}
@@ -0,0 +1,43 @@
+package sarama
+
+import "time"
+
+type InitProducerIDResponse struct {
+	ThrottleTime  time.Duration
+	Err           KError
+	ProducerID    int64
+	ProducerEpoch int16
+}
+
+func (r *InitProducerIDResponse) Encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	pe.putInt16(int16(r.Err))
+	pe.putInt64(r.ProducerID)
+	pe.putInt16(r.ProducerEpoch)
+
+	return nil
+}
+
+func (r *InitProducerIDResponse) Decode(pd packetDecoder) (err error) {
+	millis, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(millis) * time.Millisecond
+
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.Err = KError(kerr)
+
+	if r.ProducerID, err = pd.getInt64(); err != nil {
+		return err
+	}
+	r.ProducerEpoch, err = pd.getInt16()
+	return err
+}
+
+func (r *InitProducerIDResponse) String() string {
+	return dump(r)
+}
@@ -0,0 +1,41 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	initProducerIDResponse = []byte{
+		0, 0, 0, 100,
+		0, 0,
+		0, 0, 0, 0, 0, 0, 31, 64, // producer ID 8000
+		0, 0, // epoch 0
+	}
+
+	initProducerIDResponseError = []byte{
+		0, 0, 0, 0,
+		0, 15,
+		255, 255, 255, 255, 255, 255, 255, 255,
+		255, 255,
+	}
+)
+
+func TestInitProducerIDResponse(t *testing.T) {
+	response := new(InitProducerIDResponse)
+	testDecodable(t, "no error", response, initProducerIDResponse)
+	if response.Err != ErrNoError || response.ProducerID != 8000 || response.ProducerEpoch != 0 {
+		t.Error("Decoding failed, got", response)
+	}
+	if response.ThrottleTime != 100*time.Millisecond {
+		t.Error("Decoding throttle time failed, got", response.ThrottleTime)
+	}
+	testEncodable(t, "no error", response, initProducerIDResponse)
+
+	response = new(InitProducerIDResponse)
+	testDecodable(t, "error", response, initProducerIDResponseError)
+	if response.Err != ErrConsumerCoordinatorNotAvailable || response.ProducerID != -1 || response.ProducerEpoch != -1 {
+		t.Error("Decoding error failed, got", response)
+	}
+	testEncodable(t, "error", response, initProducerIDResponseError)
+}
@@ -0,0 +1,140 @@
+package sarama
+
+import "time"
+
+// GroupProtocol is one of the protocols a member supports when joining a group, along with the
+// member's metadata for that protocol.
+type GroupProtocol struct {
+	Name     string
+	Metadata []byte
+}
+
+func (p *GroupProtocol) Encode(pe packetEncoder) error {
+	if err := pe.putString(p.Name); err != nil {
+		return err
+	}
+	return pe.putBytes(p.Metadata)
+}
+
+func (p *GroupProtocol) Decode(pd packetDecoder) (err error) {
+	if p.Name, err = pd.getString(); err != nil {
+		return err
+	}
+	p.Metadata, err = pd.getBytes()
+	return err
+}
+
+type JoinGroupRequest struct {
+	GroupID          string
+	SessionTimeout   int32
+	RebalanceTimeout int32 // v1 or later
+	MemberID         string
+	ProtocolType     string
+	GroupProtocols   []*GroupProtocol // in order of preference
+
+	// Version can be:
+	// - 0 (kafka 0.9 and later)
+	// - 1 (kafka 0.10.1 and later, with a rebalance timeout separate from the session timeout)
+	// - 2 (kafka 0.11 and later, adding throttle time to the response)
+	IVersion int16
+}
+
+func (r *JoinGroupRequest) Encode(pe packetEncoder) error {
+	if r.IVersion < 0 || r.IVersion > 2 {
+		return PacketEncodingError{"invalid or unsupported JoinGroupRequest version field"}
+	}
+
+	if err := pe.putString(r.GroupID); err != nil {
+		return err
+	}
+	pe.putInt32(r.SessionTimeout)
+	if r.IVersion >= 1 {
+		pe.putInt32(r.RebalanceTimeout)
+	}
+	if err := pe.putString(r.MemberID); err != nil {
+		return err
+	}
+	if err := pe.putString(r.ProtocolType); err != nil {
+		return err
+	}
+
+	if err := pe.putArrayLength(len(r.GroupProtocols)); err != nil {
+		return err
+	}
+	for _, protocol := range r.GroupProtocols {
+		if err := protocol.Encode(pe); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *JoinGroupRequest) Decode(pd packetDecoder) (err error) {
+	if r.GroupID, err = pd.getString(); err != nil {
+		return err
+	}
+	if r.SessionTimeout, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if r.IVersion >= 1 {
+		if r.RebalanceTimeout, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
+	if r.MemberID, err = pd.getString(); err != nil {
+		return err
+	}
+	if r.ProtocolType, err = pd.getString(); err != nil {
+		return err
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.GroupProtocols = make([]*GroupProtocol, n)
+	for i := range r.GroupProtocols {
+		r.GroupProtocols[i] = new(GroupProtocol)
+		if err := r.GroupProtocols[i].Decode(pd); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *JoinGroupRequest) Key() int16 {
+	return 11
+}
+
+func (r *JoinGroupRequest) Version() int16 {
+	return r.IVersion
+}
+
+func (r *JoinGroupRequest) String() string {
+	return dump(r)
+}
+
+// the coordinator holds on to the request until every member has rejoined or the rebalance
+// times out (the session timeout before version 1)
+func (r *JoinGroupRequest) responseTimeout() time.Duration {
+	if r.IVersion >= 1 {
+		return time.Duration(r.RebalanceTimeout) * time.Millisecond
+	}
+	return time.Duration(r.SessionTimeout) * time.Millisecond
+}
+
+func (r *JoinGroupRequest) AddGroupProtocol(name string, metadata []byte) {
+	r.GroupProtocols = append(r.GroupProtocols, &GroupProtocol{Name: name, Metadata: metadata})
+}
+
+func (r *JoinGroupRequest) AddGroupProtocolMetadata(name string, metadata *ConsumerGroupMemberMetadata) error {
+	bin, err := Encode(metadata)
+	if err != nil {
+		return err
+	}
+
+	r.AddGroupProtocol(name, bin)
+	return nil
+}
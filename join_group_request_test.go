@@ -0,0 +1,72 @@
+package sarama
+
+import "testing"
+
+var (
+	joinGroupRequestNoProtocolsV0 = []byte{
+		0x00, 0x03, 'f', 'o', 'o', // Group ID
+		0x00, 0x00, 0x00, 0x64, // Session timeout
+		0x00, 0x00, // Member ID
+		0x00, 0x08, 'c', 'o', 'n', 's', 'u', 'm', 'e', 'r', // Protocol type
+		0x00, 0x00, 0x00, 0x00} // 0 protocols
+
+	joinGroupRequestOneProtocolV0 = []byte{
+		0x00, 0x03, 'f', 'o', 'o', // Group ID
+		0x00, 0x00, 0x00, 0x64, // Session timeout
+		0x00, 0x03, 'b', 'a', 'r', // Member ID
+		0x00, 0x08, 'c', 'o', 'n', 's', 'u', 'm', 'e', 'r', // Protocol type
+		0x00, 0x00, 0x00, 0x01, // 1 group protocol
+		0x00, 0x03, 'o', 'n', 'e', // Protocol name
+		0x00, 0x00, 0x00, 0x03, 0x01, 0x02, 0x03} // protocol metadata
+
+	joinGroupRequestOneProtocolV1 = []byte{
+		0x00, 0x03, 'f', 'o', 'o', // Group ID
+		0x00, 0x00, 0x00, 0x64, // Session timeout
+		0x00, 0x00, 0x01, 0xF4, // Rebalance timeout
+		0x00, 0x03, 'b', 'a', 'r', // Member ID
+		0x00, 0x08, 'c', 'o', 'n', 's', 'u', 'm', 'e', 'r', // Protocol type
+		0x00, 0x00, 0x00, 0x01, // 1 group protocol
+		0x00, 0x03, 'o', 'n', 'e', // Protocol name
+		0x00, 0x00, 0x00, 0x03, 0x01, 0x02, 0x03} // protocol metadata
+)
+
+func TestJoinGroupRequestV0(t *testing.T) {
+	request := new(JoinGroupRequest)
+	request.GroupID = "foo"
+	request.SessionTimeout = 100
+	request.ProtocolType = "consumer"
+	request.GroupProtocols = []*GroupProtocol{}
+	testRequest(t, "no protocols", request, joinGroupRequestNoProtocolsV0)
+
+	request.MemberID = "bar"
+	request.AddGroupProtocol("one", []byte{0x01, 0x02, 0x03})
+	testRequest(t, "one protocol", request, joinGroupRequestOneProtocolV0)
+}
+
+func TestJoinGroupRequestV1(t *testing.T) {
+	request := new(JoinGroupRequest)
+	request.IVersion = 1
+	request.GroupID = "foo"
+	request.SessionTimeout = 100
+	request.RebalanceTimeout = 500
+	request.MemberID = "bar"
+	request.ProtocolType = "consumer"
+	request.AddGroupProtocol("one", []byte{0x01, 0x02, 0x03})
+	testRequest(t, "one protocol", request, joinGroupRequestOneProtocolV1)
+
+	// version 2 only changes the response
+	request.IVersion = 2
+	testRequest(t, "one protocol v2", request, joinGroupRequestOneProtocolV1)
+}
+
+func TestJoinGroupRequestResponseTimeout(t *testing.T) {
+	request := &JoinGroupRequest{SessionTimeout: 100, RebalanceTimeout: 500}
+	if timeout := request.responseTimeout(); timeout.Nanoseconds() != 100e6 {
+		t.Error("Expected the session timeout for version 0, got", timeout)
+	}
+
+	request.IVersion = 1
+	if timeout := request.responseTimeout(); timeout.Nanoseconds() != 500e6 {
+		t.Error("Expected the rebalance timeout for version 1, got", timeout)
+	}
+}
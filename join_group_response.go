@@ -0,0 +1,128 @@
+package sarama
+
+import "time"
+
+type JoinGroupResponse struct {
+	ThrottleTime  time.Duration // only provided if Version >= 2
+	Err           KError
+	GenerationID  int32
+	GroupProtocol string
+	LeaderID      string
+	MemberID      string
+	Members       map[string][]byte // only provided to the leader
+
+	// Version must be set to that of the request before decoding
+	IVersion int16
+}
+
+func (r *JoinGroupResponse) GetMembers() (map[string]ConsumerGroupMemberMetadata, error) {
+	members := make(map[string]ConsumerGroupMemberMetadata, len(r.Members))
+	for id, bin := range r.Members {
+		meta := new(ConsumerGroupMemberMetadata)
+		if err := Decode(bin, meta); err != nil {
+			return nil, err
+		}
+		members[id] = *meta
+	}
+	return members, nil
+}
+
+func (r *JoinGroupResponse) Encode(pe packetEncoder) error {
+	if r.IVersion >= 2 {
+		pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	}
+	pe.putInt16(int16(r.Err))
+	pe.putInt32(r.GenerationID)
+
+	if err := pe.putString(r.GroupProtocol); err != nil {
+		return err
+	}
+	if err := pe.putString(r.LeaderID); err != nil {
+		return err
+	}
+	if err := pe.putString(r.MemberID); err != nil {
+		return err
+	}
+
+	if err := pe.putArrayLength(len(r.Members)); err != nil {
+		return err
+	}
+	for memberID, metadata := range r.Members {
+		if err := pe.putString(memberID); err != nil {
+			return err
+		}
+		if err := pe.putBytes(metadata); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *JoinGroupResponse) Decode(pd packetDecoder) (err error) {
+	if r.IVersion >= 2 {
+		millis, err := pd.getInt32()
+		if err != nil {
+			return err
+		}
+		r.ThrottleTime = time.Duration(millis) * time.Millisecond
+	}
+
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.Err = KError(kerr)
+
+	if r.GenerationID, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if r.GroupProtocol, err = pd.getString(); err != nil {
+		return err
+	}
+	if r.LeaderID, err = pd.getString(); err != nil {
+		return err
+	}
+	if r.MemberID, err = pd.getString(); err != nil {
+		return err
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return nil
+	}
+
+	r.Members = make(map[string][]byte, n)
+	for i := 0; i < n; i++ {
+		memberID, err := pd.getString()
+		if err != nil {
+			return err
+		}
+		if r.Members[memberID], err = pd.getBytes(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *JoinGroupResponse) String() string {
+	return dump(r)
+}
+
+// testing API
+
+func (r *JoinGroupResponse) AddMember(memberID string, metadata *ConsumerGroupMemberMetadata) error {
+	bin, err := Encode(metadata)
+	if err != nil {
+		return err
+	}
+	if r.Members == nil {
+		r.Members = make(map[string][]byte)
+	}
+	r.Members[memberID] = bin
+	return nil
+}
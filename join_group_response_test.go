@@ -0,0 +1,89 @@
+package sarama
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var (
+	joinGroupResponseNoError = []byte{
+		0x00, 0x00, // No error
+		0x00, 0x01, 0x02, 0x03, // Generation ID
+		0x00, 0x03, 'o', 'n', 'e', // Protocol name chosen
+		0x00, 0x03, 'f', 'o', 'o', // Leader ID
+		0x00, 0x03, 'b', 'a', 'r', // Member ID
+		0x00, 0x00, 0x00, 0x00} // No member info
+
+	joinGroupResponseWithError = []byte{
+		0x00, 0x17, // Error: inconsistent group protocol
+		0x00, 0x00, 0x00, 0x00, // Generation ID
+		0x00, 0x00, // Protocol name chosen
+		0x00, 0x00, // Leader ID
+		0x00, 0x00, // Member ID
+		0x00, 0x00, 0x00, 0x00} // No member info
+
+	joinGroupResponseLeaderV2 = []byte{
+		0x00, 0x00, 0x00, 0x64, // Throttle time
+		0x00, 0x00, // No error
+		0x00, 0x01, 0x02, 0x03, // Generation ID
+		0x00, 0x03, 'o', 'n', 'e', // Protocol name chosen
+		0x00, 0x03, 'f', 'o', 'o', // Leader ID
+		0x00, 0x03, 'f', 'o', 'o', // Member ID == Leader ID
+		0x00, 0x00, 0x00, 0x01, // 1 member
+		0x00, 0x03, 'f', 'o', 'o', // Member ID
+		0x00, 0x00, 0x00, 0x11, // Member metadata
+		0x00, 0x00, // Version
+		0x00, 0x00, 0x00, 0x01, 0x00, 0x05, 't', 'o', 'p', 'i', 'c', // Topics
+		0xFF, 0xFF, 0xFF, 0xFF} // User data
+)
+
+func TestJoinGroupResponse(t *testing.T) {
+	response := new(JoinGroupResponse)
+	testDecodable(t, "no error", response, joinGroupResponseNoError)
+	if response.Err != ErrNoError {
+		t.Error("Decoding Err failed: no error expected but found", response.Err)
+	}
+	if response.GenerationID != 66051 {
+		t.Error("Decoding GenerationID failed, found:", response.GenerationID)
+	}
+	if response.LeaderID != "foo" {
+		t.Error("Decoding LeaderID failed, found:", response.LeaderID)
+	}
+	if response.MemberID != "bar" {
+		t.Error("Decoding MemberID failed, found:", response.MemberID)
+	}
+	if len(response.Members) != 0 {
+		t.Error("Decoding Members failed, found:", response.Members)
+	}
+	testResponse(t, "no error", response, joinGroupResponseNoError)
+
+	response = new(JoinGroupResponse)
+	testDecodable(t, "with error", response, joinGroupResponseWithError)
+	if response.Err != ErrInconsistentGroupProtocol {
+		t.Error("Decoding Err failed: ErrInconsistentGroupProtocol expected but found", response.Err)
+	}
+	testResponse(t, "with error", response, joinGroupResponseWithError)
+}
+
+func TestJoinGroupResponseLeaderV2(t *testing.T) {
+	response := &JoinGroupResponse{IVersion: 2}
+	testDecodable(t, "leader", response, joinGroupResponseLeaderV2)
+	if response.ThrottleTime != 100*time.Millisecond {
+		t.Error("Decoding ThrottleTime failed, found:", response.ThrottleTime)
+	}
+	if response.LeaderID != response.MemberID {
+		t.Error("Decoding MemberID failed, found:", response.MemberID)
+	}
+
+	members, err := response.GetMembers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]ConsumerGroupMemberMetadata{"foo": {Topics: []string{"topic"}}}
+	if !reflect.DeepEqual(members, expected) {
+		t.Error("Decoding members failed, found:", members)
+	}
+
+	testEncodable(t, "leader", response, joinGroupResponseLeaderV2)
+}
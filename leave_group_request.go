@@ -0,0 +1,42 @@
+package sarama
+
+type LeaveGroupRequest struct {
+	GroupID  string
+	MemberID string
+
+	// Version can be:
+	// - 0 (kafka 0.9 and later)
+	// - 1 (kafka 0.11 and later, adding throttle time to the response)
+	IVersion int16
+}
+
+func (r *LeaveGroupRequest) Encode(pe packetEncoder) error {
+	if r.IVersion < 0 || r.IVersion > 1 {
+		return PacketEncodingError{"invalid or unsupported LeaveGroupRequest version field"}
+	}
+
+	if err := pe.putString(r.GroupID); err != nil {
+		return err
+	}
+	return pe.putString(r.MemberID)
+}
+
+func (r *LeaveGroupRequest) Decode(pd packetDecoder) (err error) {
+	if r.GroupID, err = pd.getString(); err != nil {
+		return err
+	}
+	r.MemberID, err = pd.getString()
+	return err
+}
+
+func (r *LeaveGroupRequest) Key() int16 {
+	return 13
+}
+
+func (r *LeaveGroupRequest) Version() int16 {
+	return r.IVersion
+}
+
+func (r *LeaveGroupRequest) String() string {
+	return dump(r)
+}
@@ -0,0 +1,19 @@
+package sarama
+
+import "testing"
+
+var basicLeaveGroupRequest = []byte{
+	0x00, 0x03, 'f', 'o', 'o',
+	0x00, 0x03, 'b', 'a', 'r',
+}
+
+func TestLeaveGroupRequest(t *testing.T) {
+	request := new(LeaveGroupRequest)
+	request.GroupID = "foo"
+	request.MemberID = "bar"
+	testRequest(t, "basic", request, basicLeaveGroupRequest)
+
+	// version 1 only changes the response
+	request.IVersion = 1
+	testRequest(t, "basic v1", request, basicLeaveGroupRequest)
+}
@@ -0,0 +1,34 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	leaveGroupResponseNoError = []byte{
+		0x00, 0x00}
+
+	leaveGroupResponseWithErrorV1 = []byte{
+		0x00, 0x00, 0x00, 0x64,
+		0x00, 0x19}
+)
+
+func TestLeaveGroupResponse(t *testing.T) {
+	response := new(LeaveGroupResponse)
+	testDecodable(t, "no error", response, leaveGroupResponseNoError)
+	if response.Err != ErrNoError {
+		t.Error("Decoding error failed: no error expected but found", response.Err)
+	}
+	testResponse(t, "no error", response, leaveGroupResponseNoError)
+
+	response = &LeaveGroupResponse{IVersion: 1}
+	testDecodable(t, "with error", response, leaveGroupResponseWithErrorV1)
+	if response.ThrottleTime != 100*time.Millisecond {
+		t.Error("Decoding ThrottleTime failed, found:", response.ThrottleTime)
+	}
+	if response.Err != ErrUnknownMemberID {
+		t.Error("Decoding error failed: ErrUnknownMemberID expected but found", response.Err)
+	}
+	testEncodable(t, "with error", response, leaveGroupResponseWithErrorV1)
+}
@@ -0,0 +1,32 @@
+package sarama
+
+type ListGroupsRequest struct {
+	// Version can be:
+	// - 0 (kafka 0.9 and later)
+	// - 1 (kafka 0.11 and later, adding throttle time to the response)
+	// - 2 (kafka 2.0 and later)
+	IVersion int16
+}
+
+func (r *ListGroupsRequest) Encode(pe packetEncoder) error {
+	if r.IVersion < 0 || r.IVersion > 2 {
+		return PacketEncodingError{"invalid or unsupported ListGroupsRequest version field"}
+	}
+	return nil
+}
+
+func (r *ListGroupsRequest) Decode(pd packetDecoder) (err error) {
+	return nil
+}
+
+func (r *ListGroupsRequest) Key() int16 {
+	return 16
+}
+
+func (r *ListGroupsRequest) Version() int16 {
+	return r.IVersion
+}
+
+func (r *ListGroupsRequest) String() string {
+	return dump(r)
+}
@@ -0,0 +1,8 @@
+package sarama
+
+import "testing"
+
+func TestListGroupsRequest(t *testing.T) {
+	testRequest(t, "version 0", new(ListGroupsRequest), []byte{})
+	testRequest(t, "version 1", &ListGroupsRequest{IVersion: 1}, []byte{})
+}
@@ -0,0 +1,70 @@
+package sarama
+
+import "time"
+
+type ListGroupsResponse struct {
+	ThrottleTime time.Duration // only provided if Version >= 1
+	Err          KError
+	Groups       map[string]string // maps the groups the broker coordinates to their protocol types
+
+	// Version must be set to that of the request before decoding
+	IVersion int16
+}
+
+func (r *ListGroupsResponse) Encode(pe packetEncoder) error {
+	if r.IVersion >= 1 {
+		pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	}
+	pe.putInt16(int16(r.Err))
+
+	if err := pe.putArrayLength(len(r.Groups)); err != nil {
+		return err
+	}
+	for group, protocolType := range r.Groups {
+		if err := pe.putString(group); err != nil {
+			return err
+		}
+		if err := pe.putString(protocolType); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *ListGroupsResponse) Decode(pd packetDecoder) error {
+	if r.IVersion >= 1 {
+		millis, err := pd.getInt32()
+		if err != nil {
+			return err
+		}
+		r.ThrottleTime = time.Duration(millis) * time.Millisecond
+	}
+
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.Err = KError(kerr)
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.Groups = make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		group, err := pd.getString()
+		if err != nil {
+			return err
+		}
+		if r.Groups[group], err = pd.getString(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *ListGroupsResponse) String() string {
+	return dump(r)
+}
@@ -0,0 +1,51 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	listGroupsResponseEmpty = []byte{
+		0, 0, // no error
+		0, 0, 0, 0, // no groups
+	}
+
+	listGroupsResponseError = []byte{
+		0, 15, // ErrConsumerCoordinatorNotAvailable
+		0, 0, 0, 0, // no groups
+	}
+
+	listGroupsResponseWithConsumerV1 = []byte{
+		0, 0, 0, 100,
+		0, 0, // no error
+		0, 0, 0, 1,
+		0, 3, 'f', 'o', 'o',
+		0, 8, 'c', 'o', 'n', 's', 'u', 'm', 'e', 'r',
+	}
+)
+
+func TestListGroupsResponse(t *testing.T) {
+	response := new(ListGroupsResponse)
+	testDecodable(t, "no error", response, listGroupsResponseEmpty)
+	if response.Err != ErrNoError || len(response.Groups) != 0 {
+		t.Error("Decoding failed, got", response)
+	}
+	testEncodable(t, "no error", response, listGroupsResponseEmpty)
+
+	response = new(ListGroupsResponse)
+	testDecodable(t, "error", response, listGroupsResponseError)
+	if response.Err != ErrConsumerCoordinatorNotAvailable {
+		t.Error("Decoding error failed, got", response.Err)
+	}
+
+	response = &ListGroupsResponse{IVersion: 1}
+	testDecodable(t, "version 1 with consumer group", response, listGroupsResponseWithConsumerV1)
+	if response.ThrottleTime != 100*time.Millisecond {
+		t.Error("Decoding ThrottleTime failed, got", response.ThrottleTime)
+	}
+	if len(response.Groups) != 1 || response.Groups["foo"] != "consumer" {
+		t.Error("Decoding groups failed, got", response.Groups)
+	}
+	testEncodable(t, "version 1 with consumer group", response, listGroupsResponseWithConsumerV1)
+}
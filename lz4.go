@@ -0,0 +1,335 @@
+package sarama
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// Kafka wraps LZ4 compressed data in the LZ4 frame format
+// (https://github.com/lz4/lz4/blob/master/doc/lz4_Frame_format.md), made of a frame
+// descriptor followed by a series of blocks in the LZ4 block format.
+
+const (
+	lz4Magic = 0x184D2204
+
+	lz4FlagVersion         = 0x40
+	lz4FlagVersionMask     = 0xC0
+	lz4FlagBlockChecksum   = 0x10
+	lz4FlagContentSize     = 0x08
+	lz4FlagContentChecksum = 0x04
+	lz4FlagDictID          = 0x01
+
+	lz4BlockMaxSize64KB = 4 << 4 // the block size Kafka's own producer uses
+	lz4BlockMaxSize     = 64 << 10
+	lz4UncompressedBit  = 1 << 31
+
+	lz4MinMatch     = 4
+	lz4MFLimit      = 12 // the last match must start at least this many bytes before the end of a block
+	lz4LastLiterals = 5  // the last bytes of a block are always literals
+	lz4MaxOffset    = 65535
+	lz4HashLog      = 14
+)
+
+var errLZ4Corrupt = errors.New("kafka: LZ4 data is corrupt")
+
+// lz4Encode compresses src as a single LZ4 frame with independent 64KB blocks.
+func lz4Encode(src []byte) []byte {
+	dst := make([]byte, 7, 7+len(src)+len(src)/255+16)
+	binary.LittleEndian.PutUint32(dst, lz4Magic)
+	dst[4] = lz4FlagVersion | 0x20 // independent blocks
+	dst[5] = lz4BlockMaxSize64KB
+	dst[6] = byte(xxh32(dst[4:6], 0) >> 8)
+
+	var block []byte
+	for len(src) > 0 {
+		chunk := src
+		if len(chunk) > lz4BlockMaxSize {
+			chunk = chunk[:lz4BlockMaxSize]
+		}
+		src = src[len(chunk):]
+
+		block = lz4CompressBlock(block[:0], chunk)
+		if len(block) < len(chunk) {
+			dst = appendUint32LE(dst, uint32(len(block)))
+			dst = append(dst, block...)
+		} else {
+			dst = appendUint32LE(dst, uint32(len(chunk))|lz4UncompressedBit)
+			dst = append(dst, chunk...)
+		}
+	}
+
+	return appendUint32LE(dst, 0) // end mark
+}
+
+// lz4Decode decompresses a LZ4 frame.
+func lz4Decode(src []byte) ([]byte, error) {
+	if len(src) < 7 || binary.LittleEndian.Uint32(src) != lz4Magic {
+		return nil, PacketDecodingError{"LZ4 compression specified, but data is not a LZ4 frame"}
+	}
+
+	flags := src[4]
+	if flags&lz4FlagVersionMask != lz4FlagVersion {
+		return nil, PacketDecodingError{"unsupported LZ4 frame version"}
+	}
+	if flags&lz4FlagDictID != 0 {
+		return nil, PacketDecodingError{"unsupported LZ4 frame with dictionary"}
+	}
+
+	descriptor := 6
+	if flags&lz4FlagContentSize != 0 {
+		descriptor += 8
+	}
+	if len(src) < descriptor+1 {
+		return nil, errLZ4Corrupt
+	}
+	// Kafka before 0.10 computed the header checksum over the magic number as well
+	// (KAFKA-3160), so either form is accepted
+	checksum := src[descriptor]
+	if checksum != byte(xxh32(src[4:descriptor], 0)>>8) && checksum != byte(xxh32(src[:descriptor], 0)>>8) {
+		return nil, PacketDecodingError{"LZ4 frame header checksum mismatch"}
+	}
+	src = src[descriptor+1:]
+
+	dst := make([]byte, 0, 2*len(src))
+	for {
+		if len(src) < 4 {
+			return nil, errLZ4Corrupt
+		}
+		size := binary.LittleEndian.Uint32(src)
+		src = src[4:]
+		if size == 0 {
+			break
+		}
+
+		uncompressed := size&lz4UncompressedBit != 0
+		size &^= lz4UncompressedBit
+		if uint32(len(src)) < size {
+			return nil, errLZ4Corrupt
+		}
+
+		var err error
+		if uncompressed {
+			dst = append(dst, src[:size]...)
+		} else if dst, err = lz4DecompressBlock(dst, src[:size]); err != nil {
+			return nil, err
+		}
+		src = src[size:]
+
+		if flags&lz4FlagBlockChecksum != 0 {
+			if len(src) < 4 {
+				return nil, errLZ4Corrupt
+			}
+			src = src[4:]
+		}
+	}
+
+	if flags&lz4FlagContentChecksum != 0 {
+		if len(src) < 4 {
+			return nil, errLZ4Corrupt
+		}
+		if binary.LittleEndian.Uint32(src) != xxh32(dst, 0) {
+			return nil, PacketDecodingError{"LZ4 content checksum mismatch"}
+		}
+	}
+
+	return dst, nil
+}
+
+// lz4CompressBlock appends the LZ4 block encoding of src to dst, using a single hash
+// table lookup per position to find matches.
+func lz4CompressBlock(dst, src []byte) []byte {
+	var table [1 << lz4HashLog]int32 // positions plus one, so zero means empty
+
+	anchor, i := 0, 0
+	matchLimit := len(src) - lz4LastLiterals
+	for i+lz4MFLimit <= len(src) {
+		seq := binary.LittleEndian.Uint32(src[i:])
+		h := (seq * 2654435761) >> (32 - lz4HashLog)
+		ref := int(table[h]) - 1
+		table[h] = int32(i + 1)
+
+		if ref < 0 || i-ref > lz4MaxOffset || binary.LittleEndian.Uint32(src[ref:]) != seq {
+			i++
+			continue
+		}
+
+		length := lz4MinMatch
+		for i+length < matchLimit && src[ref+length] == src[i+length] {
+			length++
+		}
+
+		dst = lz4AppendSequence(dst, src[anchor:i], i-ref, length)
+		i += length
+		anchor = i
+	}
+
+	return lz4AppendSequence(dst, src[anchor:], 0, 0)
+}
+
+// lz4AppendSequence appends the given literals followed by a match, unless length is 0
+// which only happens for the final sequence of a block.
+func lz4AppendSequence(dst, literals []byte, offset, length int) []byte {
+	var token byte
+	if len(literals) >= 15 {
+		token = 15 << 4
+	} else {
+		token = byte(len(literals)) << 4
+	}
+	if length > 0 {
+		if length-lz4MinMatch >= 15 {
+			token |= 15
+		} else {
+			token |= byte(length - lz4MinMatch)
+		}
+	}
+
+	dst = append(dst, token)
+	if len(literals) >= 15 {
+		dst = lz4AppendLength(dst, len(literals)-15)
+	}
+	dst = append(dst, literals...)
+
+	if length > 0 {
+		dst = append(dst, byte(offset), byte(offset>>8))
+		if length-lz4MinMatch >= 15 {
+			dst = lz4AppendLength(dst, length-lz4MinMatch-15)
+		}
+	}
+	return dst
+}
+
+func lz4AppendLength(dst []byte, n int) []byte {
+	for ; n >= 255; n -= 255 {
+		dst = append(dst, 255)
+	}
+	return append(dst, byte(n))
+}
+
+// lz4DecompressBlock appends the decompressed contents of a LZ4 block to dst. Matches
+// may refer back into data already in dst, as with blocks that are not independent.
+func lz4DecompressBlock(dst, src []byte) ([]byte, error) {
+	i := 0
+	for i < len(src) {
+		token := src[i]
+		i++
+
+		literals := int(token >> 4)
+		if literals == 15 {
+			n, err := lz4ReadLength(src, &i)
+			if err != nil {
+				return nil, err
+			}
+			literals += n
+		}
+		if len(src)-i < literals {
+			return nil, errLZ4Corrupt
+		}
+		dst = append(dst, src[i:i+literals]...)
+		i += literals
+
+		if i == len(src) {
+			break // the last sequence has no match
+		}
+
+		if len(src)-i < 2 {
+			return nil, errLZ4Corrupt
+		}
+		offset := int(src[i]) | int(src[i+1])<<8
+		i += 2
+		if offset == 0 || offset > len(dst) {
+			return nil, errLZ4Corrupt
+		}
+
+		length := int(token & 15)
+		if length == 15 {
+			n, err := lz4ReadLength(src, &i)
+			if err != nil {
+				return nil, err
+			}
+			length += n
+		}
+		length += lz4MinMatch
+
+		// byte by byte, as the match may overlap what it is copying
+		start := len(dst) - offset
+		for j := 0; j < length; j++ {
+			dst = append(dst, dst[start+j])
+		}
+	}
+	return dst, nil
+}
+
+func lz4ReadLength(src []byte, i *int) (int, error) {
+	n := 0
+	for {
+		if *i >= len(src) {
+			return 0, errLZ4Corrupt
+		}
+		b := src[*i]
+		*i++
+		n += int(b)
+		if b != 255 {
+			return n, nil
+		}
+	}
+}
+
+func appendUint32LE(dst []byte, v uint32) []byte {
+	return append(dst, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+const (
+	xxhPrime1 uint32 = 2654435761
+	xxhPrime2 uint32 = 2246822519
+	xxhPrime3 uint32 = 3266489917
+	xxhPrime4 uint32 = 668265263
+	xxhPrime5 uint32 = 374761393
+)
+
+// xxh32 is the 32-bit xxHash used for LZ4 frame checksums.
+func xxh32(b []byte, seed uint32) uint32 {
+	n := len(b)
+	var h uint32
+
+	if n >= 16 {
+		v1 := seed + xxhPrime1 + xxhPrime2
+		v2 := seed + xxhPrime2
+		v3 := seed
+		v4 := seed - xxhPrime1
+		for len(b) >= 16 {
+			v1 = xxh32Round(v1, binary.LittleEndian.Uint32(b))
+			v2 = xxh32Round(v2, binary.LittleEndian.Uint32(b[4:]))
+			v3 = xxh32Round(v3, binary.LittleEndian.Uint32(b[8:]))
+			v4 = xxh32Round(v4, binary.LittleEndian.Uint32(b[12:]))
+			b = b[16:]
+		}
+		h = rotl32(v1, 1) + rotl32(v2, 7) + rotl32(v3, 12) + rotl32(v4, 18)
+	} else {
+		h = seed + xxhPrime5
+	}
+
+	h += uint32(n)
+	for ; len(b) >= 4; b = b[4:] {
+		h += binary.LittleEndian.Uint32(b) * xxhPrime3
+		h = rotl32(h, 17) * xxhPrime4
+	}
+	for _, c := range b {
+		h += uint32(c) * xxhPrime5
+		h = rotl32(h, 11) * xxhPrime1
+	}
+
+	h ^= h >> 15
+	h *= xxhPrime2
+	h ^= h >> 13
+	h *= xxhPrime3
+	h ^= h >> 16
+	return h
+}
+
+func xxh32Round(acc, input uint32) uint32 {
+	return rotl32(acc+input*xxhPrime2, 13) * xxhPrime1
+}
+
+func rotl32(x uint32, r uint) uint32 {
+	return x<<r | x>>(32-r)
+}
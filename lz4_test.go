@@ -0,0 +1,68 @@
+package sarama
+
+import (
+	"bytes"
+	"testing"
+)
+
+var lz4TestCases = map[string][]byte{
+	"": []byte{4, 34, 77, 24, 96, 64, 130, 0, 0, 0, 0},
+	"REPEATREPEATREPEATREPEATREPEATREPEAT": []byte{
+		4, 34, 77, 24, // magic
+		96, 64, 130, // frame descriptor
+		16, 0, 0, 0, 111, 82, 69, 80, 69, 65, 84, 6, 0, 6, 80, 69, 80, 69, 65, 84, // block
+		0, 0, 0, 0}, // end mark
+}
+
+// as written by the lz4 command line tool, with a content checksum
+var lz4FrameWithChecksum = []byte{4, 34, 77, 24, 100, 64, 167, 16, 0, 0, 0, 111, 82, 69, 80, 69, 65, 84, 6, 0, 6, 80, 69, 80, 69, 65, 84, 0, 0, 0, 0, 223, 230, 38, 67}
+
+func TestLZ4Encode(t *testing.T) {
+	for src, exp := range lz4TestCases {
+		dst := lz4Encode([]byte(src))
+		if !bytes.Equal(dst, exp) {
+			t.Errorf("Expected %s to generate %v, but was %v", src, exp, dst)
+		}
+	}
+}
+
+func TestLZ4Decode(t *testing.T) {
+	for exp, src := range lz4TestCases {
+		dst, err := lz4Decode(src)
+		if err != nil {
+			t.Error("Encoding error: ", err)
+		} else if !bytes.Equal(dst, []byte(exp)) {
+			t.Errorf("Expected %s to be generated from %v, but was %s", exp, src, string(dst))
+		}
+	}
+
+	dst, err := lz4Decode(lz4FrameWithChecksum)
+	if err != nil {
+		t.Error("Encoding error: ", err)
+	} else if string(dst) != "REPEATREPEATREPEATREPEATREPEATREPEAT" {
+		t.Errorf("Unexpected data decoded from frame with checksum: %s", string(dst))
+	}
+
+	corrupt := append([]byte(nil), lz4FrameWithChecksum...)
+	corrupt[14]++
+	if _, err := lz4Decode(corrupt); err == nil {
+		t.Error("Expected an error decoding a frame with a bad content checksum")
+	}
+	if _, err := lz4Decode(lz4FrameWithChecksum[:20]); err == nil {
+		t.Error("Expected an error decoding a truncated frame")
+	}
+}
+
+func TestLZ4RoundTrip(t *testing.T) {
+	src := make([]byte, 3*lz4BlockMaxSize)
+	for i := range src {
+		src[i] = byte(i % 251)
+	}
+	dst, err := lz4Decode(lz4Encode(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, dst) {
+		t.Error("LZ4 round trip of several blocks produced different data")
+	}
+}
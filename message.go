@@ -1,45 +1,67 @@
 package sarama
 
 import (
-	"bytes"
-	"compress/gzip"
 	"fmt"
-	"io/ioutil"
+	"time"
 )
 
 // CompressionCodec represents the various compression codecs recognized by Kafka in messages.
 type CompressionCodec int8
 
-// only the last two bits are really used
-const compressionCodecMask int8 = 0x03
+// only the last three bits are really used
+const compressionCodecMask int8 = 0x07
+
+// set on version 1 messages whose timestamp was assigned by the broker when appending to the log
+const timestampTypeMask int8 = 0x08
 
 const (
 	CompressionNone   CompressionCodec = 0
 	CompressionGZIP   CompressionCodec = 1
 	CompressionSnappy CompressionCodec = 2
+	CompressionLZ4    CompressionCodec = 3
+	CompressionZSTD   CompressionCodec = 4
 )
 
-// The spec just says: "This is a version id used to allow backwards compatible evolution of the message
-// binary format." but it doesn't say what the current value is, so presumably 0...
-const messageFormat int8 = 0
+// CompressionLevelDefault is the compression level that leaves the choice up to the codec. Being
+// the zero value, it also applies to messages and record batches that do not set a level.
+const CompressionLevelDefault = 0
+
+// CompressionLevelZero asks the codec for its level 0, which can not be asked for as 0 since that
+// is CompressionLevelDefault. For GZIP, level 0 stores the data without compressing it.
+const CompressionLevelZero = -1000
 
 type Message struct {
-	Codec CompressionCodec // codec used to compress the message contents
-	Key   []byte           // the message key, may be nil
-	Value []byte           // the message contents
-	Set   *MessageSet      // the message set a message might wrap
+	Codec            CompressionCodec // codec used to compress the message contents
+	Key              []byte           // the message key, may be nil
+	Value            []byte           // the message contents
+	Set              *MessageSet      // the message set a message might wrap
+	Version          int8             // v1 requires Kafka 0.10
+	Timestamp        time.Time        // the timestamp of the message (version 1+ only)
+	LogAppendTime    bool             // whether the broker assigned Timestamp rather than the producer (version 1+ only)
+	CompressionLevel int              // the level to compress the message contents at, see Config.Producer.CompressionLevel
 
 	compressedCache []byte
 }
 
 func (m *Message) Encode(pe packetEncoder) error {
+	if m.Version < 0 || m.Version > 1 {
+		return PacketEncodingError{fmt.Sprintf("unsupported message version (%d)", m.Version)}
+	}
+
 	pe.push(&crc32Field{})
 
-	pe.putInt8(messageFormat)
+	pe.putInt8(m.Version)
 
 	attributes := int8(m.Codec) & compressionCodecMask
+	if m.LogAppendTime {
+		attributes |= timestampTypeMask
+	}
 	pe.putInt8(attributes)
 
+	if m.Version >= 1 {
+		pe.putInt64(millisecondsSinceEpoch(m.Timestamp))
+	}
+
 	err := pe.putBytes(m.Key)
 	if err != nil {
 		return err
@@ -50,28 +72,13 @@ func (m *Message) Encode(pe packetEncoder) error {
 	if m.compressedCache != nil {
 		payload = m.compressedCache
 		m.compressedCache = nil
+	} else if m.Codec == CompressionNone {
+		payload = m.Value
 	} else {
-		switch m.Codec {
-		case CompressionNone:
-			payload = m.Value
-		case CompressionGZIP:
-			var buf bytes.Buffer
-			writer := gzip.NewWriter(&buf)
-			if _, err = writer.Write(m.Value); err != nil {
-				return err
-			}
-			if err = writer.Close(); err != nil {
-				return err
-			}
-			m.compressedCache = buf.Bytes()
-			payload = m.compressedCache
-		case CompressionSnappy:
-			tmp := snappyEncode(m.Value)
-			m.compressedCache = tmp
-			payload = m.compressedCache
-		default:
-			return PacketEncodingError{fmt.Sprintf("unsupported compression codec (%d)", m.Codec)}
+		if m.compressedCache, err = compress(m.Codec, m.CompressionLevel, m.Value); err != nil {
+			return err
 		}
+		payload = m.compressedCache
 	}
 
 	if err = pe.putBytes(payload); err != nil {
@@ -87,12 +94,12 @@ func (m *Message) Decode(pd packetDecoder) (err error) {
 		return err
 	}
 
-	format, err := pd.getInt8()
+	m.Version, err = pd.getInt8()
 	if err != nil {
 		return err
 	}
-	if format != messageFormat {
-		return PacketDecodingError{"unexpected messageFormat"}
+	if m.Version < 0 || m.Version > 1 {
+		return PacketDecodingError{fmt.Sprintf("unknown message version (%d)", m.Version)}
 	}
 
 	attribute, err := pd.getInt8()
@@ -100,6 +107,16 @@ func (m *Message) Decode(pd packetDecoder) (err error) {
 		return err
 	}
 	m.Codec = CompressionCodec(attribute & compressionCodecMask)
+	m.LogAppendTime = attribute&timestampTypeMask != 0
+
+	m.Timestamp = time.Time{}
+	if m.Version >= 1 {
+		millis, err := pd.getInt64()
+		if err != nil {
+			return err
+		}
+		m.Timestamp = timeFromMilliseconds(millis)
+	}
 
 	m.Key, err = pd.getBytes()
 	if err != nil {
@@ -111,35 +128,13 @@ func (m *Message) Decode(pd packetDecoder) (err error) {
 		return err
 	}
 
-	switch m.Codec {
-	case CompressionNone:
-		// nothing to do
-	case CompressionGZIP:
-		if m.Value == nil {
-			return PacketDecodingError{"GZIP compression specified, but no data to uncompress"}
-		}
-		reader, err := gzip.NewReader(bytes.NewReader(m.Value))
-		if err != nil {
-			return err
-		}
-		if m.Value, err = ioutil.ReadAll(reader); err != nil {
-			return err
-		}
-		if err := m.decodeSet(); err != nil {
-			return err
-		}
-	case CompressionSnappy:
-		if m.Value == nil {
-			return PacketDecodingError{"Snappy compression specified, but no data to uncompress"}
-		}
-		if m.Value, err = snappyDecode(m.Value); err != nil {
+	if m.Codec != CompressionNone {
+		if m.Value, err = decompress(m.Codec, m.Value); err != nil {
 			return err
 		}
 		if err := m.decodeSet(); err != nil {
 			return err
 		}
-	default:
-		return PacketDecodingError{fmt.Sprintf("invalid compression specified (%d)", m.Codec)}
 	}
 
 	return pd.pop()
@@ -1,10 +1,25 @@
 package sarama
 
 type MetadataRequest struct {
-	Topics []string
+	Topics []string // all topics when empty
+
+	// Version can be:
+	// - 0 (kafka 0.8 and later)
+	// - 1 (kafka 0.10 and later, adding the controller, racks and internal topics to the response)
+	IVersion int16
 }
 
 func (mr *MetadataRequest) Encode(pe packetEncoder) error {
+	if mr.IVersion < 0 || mr.IVersion > 1 {
+		return PacketEncodingError{"invalid or unsupported MetadataRequest version field"}
+	}
+
+	if mr.IVersion >= 1 && len(mr.Topics) == 0 {
+		// from version 1 an empty array asks for no topics at all, and null for all of them
+		pe.putInt32(-1)
+		return nil
+	}
+
 	err := pe.putArrayLength(len(mr.Topics))
 	if err != nil {
 		return err
@@ -20,13 +35,17 @@ func (mr *MetadataRequest) Encode(pe packetEncoder) error {
 }
 
 func (mr *MetadataRequest) Decode(pd packetDecoder) error {
-	topicCount, err := pd.getArrayLength()
+	topicCount, err := pd.getInt32()
 	if err != nil {
 		return err
 	}
-	if topicCount == 0 {
+	if topicCount <= 0 {
+		// null (version 1 and later) and empty both stand for all topics
 		return nil
 	}
+	if int(topicCount) > pd.remaining() {
+		return ErrInsufficientData
+	}
 
 	mr.Topics = make([]string, topicCount)
 	for i := range mr.Topics {
@@ -44,5 +63,9 @@ func (mr *MetadataRequest) Key() int16 {
 }
 
 func (mr *MetadataRequest) Version() int16 {
-	return 0
+	return mr.IVersion
+}
+
+func (mr *MetadataRequest) String() string {
+	return dump(mr)
 }
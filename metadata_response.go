@@ -59,10 +59,15 @@ func (pm *PartitionMetadata) Encode(pe packetEncoder) (err error) {
 type TopicMetadata struct {
 	Err        KError
 	Name       string
+	IsInternal bool // only provided if Version >= 1
 	Partitions []*PartitionMetadata
 }
 
 func (tm *TopicMetadata) Decode(pd packetDecoder) (err error) {
+	return tm.decode(pd, 0)
+}
+
+func (tm *TopicMetadata) decode(pd packetDecoder, version int16) (err error) {
 	tmp, err := pd.getInt16()
 	if err != nil {
 		return err
@@ -74,6 +79,14 @@ func (tm *TopicMetadata) Decode(pd packetDecoder) (err error) {
 		return err
 	}
 
+	if version >= 1 {
+		internal, err := pd.getInt8()
+		if err != nil {
+			return err
+		}
+		tm.IsInternal = internal != 0
+	}
+
 	n, err := pd.getArrayLength()
 	if err != nil {
 		return err
@@ -91,6 +104,10 @@ func (tm *TopicMetadata) Decode(pd packetDecoder) (err error) {
 }
 
 func (tm *TopicMetadata) Encode(pe packetEncoder) (err error) {
+	return tm.encode(pe, 0)
+}
+
+func (tm *TopicMetadata) encode(pe packetEncoder, version int16) (err error) {
 	pe.putInt16(int16(tm.Err))
 
 	err = pe.putString(tm.Name)
@@ -98,6 +115,14 @@ func (tm *TopicMetadata) Encode(pe packetEncoder) (err error) {
 		return err
 	}
 
+	if version >= 1 {
+		if tm.IsInternal {
+			pe.putInt8(1)
+		} else {
+			pe.putInt8(0)
+		}
+	}
+
 	err = pe.putArrayLength(len(tm.Partitions))
 	if err != nil {
 		return err
@@ -114,8 +139,12 @@ func (tm *TopicMetadata) Encode(pe packetEncoder) (err error) {
 }
 
 type MetadataResponse struct {
-	Brokers []*Broker
-	Topics  []*TopicMetadata
+	Brokers      []*Broker
+	ControllerID int32 // only provided if Version >= 1
+	Topics       []*TopicMetadata
+
+	// Version must be set to that of the request before decoding
+	IVersion int16
 }
 
 func (m *MetadataResponse) Decode(pd packetDecoder) (err error) {
@@ -127,12 +156,18 @@ func (m *MetadataResponse) Decode(pd packetDecoder) (err error) {
 	m.Brokers = make([]*Broker, n)
 	for i := 0; i < n; i++ {
 		m.Brokers[i] = new(Broker)
-		err = m.Brokers[i].Decode(pd)
+		err = m.Brokers[i].decode(pd, m.IVersion)
 		if err != nil {
 			return err
 		}
 	}
 
+	if m.IVersion >= 1 {
+		if m.ControllerID, err = pd.getInt32(); err != nil {
+			return err
+		}
+	}
+
 	n, err = pd.getArrayLength()
 	if err != nil {
 		return err
@@ -141,7 +176,7 @@ func (m *MetadataResponse) Decode(pd packetDecoder) (err error) {
 	m.Topics = make([]*TopicMetadata, n)
 	for i := 0; i < n; i++ {
 		m.Topics[i] = new(TopicMetadata)
-		err = m.Topics[i].Decode(pd)
+		err = m.Topics[i].decode(pd, m.IVersion)
 		if err != nil {
 			return err
 		}
@@ -156,18 +191,22 @@ func (m *MetadataResponse) Encode(pe packetEncoder) error {
 		return err
 	}
 	for _, broker := range m.Brokers {
-		err = broker.Encode(pe)
+		err = broker.encode(pe, m.IVersion)
 		if err != nil {
 			return err
 		}
 	}
 
+	if m.IVersion >= 1 {
+		pe.putInt32(m.ControllerID)
+	}
+
 	err = pe.putArrayLength(len(m.Topics))
 	if err != nil {
 		return err
 	}
 	for _, tm := range m.Topics {
-		err = tm.Encode(pe)
+		err = tm.encode(pe, m.IVersion)
 		if err != nil {
 			return err
 		}
@@ -176,6 +215,10 @@ func (m *MetadataResponse) Encode(pe packetEncoder) error {
 	return nil
 }
 
+func (m *MetadataResponse) String() string {
+	return dump(m)
+}
+
 // testing API
 
 func (m *MetadataResponse) AddBroker(addr string, id int32) {
@@ -36,6 +36,27 @@ var (
 		0x00, 0x00,
 		0x00, 0x03, 'b', 'a', 'r',
 		0x00, 0x00, 0x00, 0x00}
+
+	brokersAndTopicsMetadataResponseV1 = []byte{
+		0x00, 0x00, 0x00, 0x02,
+
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x09, 'l', 'o', 'c', 'a', 'l', 'h', 'o', 's', 't',
+		0x00, 0x00, 0x00, 0x33,
+		0x00, 0x05, 'r', 'a', 'c', 'k', '0',
+
+		0x00, 0x00, 0x00, 0x02,
+		0x00, 0x09, 'l', 'o', 'c', 'a', 'l', 'h', 'o', 's', 't',
+		0x00, 0x00, 0x00, 0x34,
+		0xFF, 0xFF,
+
+		0x00, 0x00, 0x00, 0x02, // controller
+
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x00,
+		0x00, 0x12, '_', '_', 'c', 'o', 'n', 's', 'u', 'm', 'e', 'r', '_', 'o', 'f', 'f', 's', 'e', 't', 's',
+		0x01, // internal
+		0x00, 0x00, 0x00, 0x00}
 )
 
 func TestEmptyMetadataResponse(t *testing.T) {
@@ -61,13 +82,13 @@ func TestMetadataResponseWithBrokers(t *testing.T) {
 	if response.Brokers[0].id != 0xabff {
 		t.Error("Decoding produced invalid broker 0 id.")
 	}
-	if response.Brokers[0].addr != "localhost:51" {
+	if response.Brokers[0].IAddr != "localhost:51" {
 		t.Error("Decoding produced invalid broker 0 address.")
 	}
 	if response.Brokers[1].id != 0x010203 {
 		t.Error("Decoding produced invalid broker 1 id.")
 	}
-	if response.Brokers[1].addr != "google.com:273" {
+	if response.Brokers[1].IAddr != "google.com:273" {
 		t.Error("Decoding produced invalid broker 1 address.")
 	}
 
@@ -137,3 +158,26 @@ func TestMetadataResponseWithTopics(t *testing.T) {
 		t.Error("Decoding produced invalid partition count for topic 1.")
 	}
 }
+
+func TestMetadataResponseV1(t *testing.T) {
+	response := MetadataResponse{IVersion: 1}
+
+	testDecodable(t, "brokers and topics", &response, brokersAndTopicsMetadataResponseV1)
+	if len(response.Brokers) != 2 {
+		t.Fatal("Decoding produced", len(response.Brokers), "brokers where there were two!")
+	}
+	if response.Brokers[0].rack == nil || *response.Brokers[0].rack != "rack0" {
+		t.Error("Decoding produced invalid broker 0 rack.")
+	}
+	if response.Brokers[1].rack != nil {
+		t.Error("Decoding produced a rack for broker 1 where there was none.")
+	}
+	if response.ControllerID != 2 {
+		t.Error("Decoding produced invalid controller id", response.ControllerID)
+	}
+	if len(response.Topics) != 1 || !response.Topics[0].IsInternal {
+		t.Error("Decoding produced invalid internal topic.")
+	}
+
+	testEncodable(t, "brokers and topics", &response, brokersAndTopicsMetadataResponseV1)
+}
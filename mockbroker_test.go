@@ -47,6 +47,7 @@ type mockBroker struct {
 	latency      time.Duration
 	handler      requestHandlerFunc
 	history      []RequestResponse
+	saslTokens   [][]byte
 	lock         sync.Mutex
 }
 
@@ -91,6 +92,15 @@ func (b *mockBroker) History() []RequestResponse {
 	return history
 }
 
+// SASLTokens returns the raw authentication bytes received after SASL handshakes.
+func (b *mockBroker) SASLTokens() [][]byte {
+	b.lock.Lock()
+	tokens := make([][]byte, len(b.saslTokens))
+	copy(tokens, b.saslTokens)
+	b.lock.Unlock()
+	return tokens
+}
+
 func (b *mockBroker) Port() int32 {
 	return b.port
 }
@@ -152,8 +162,20 @@ func (b *mockBroker) handleRequests(conn net.Conn, idx int, wg *sync.WaitGroup)
 	}()
 
 	resHeader := make([]byte, 8)
+	expectSASLToken := false
 	for {
-		req, err := decodeRequest(conn)
+		if expectSASLToken {
+			// a successful version 0 handshake is followed by the raw token, accepted with an
+			// empty answer
+			expectSASLToken = false
+			if err = b.readSASLToken(conn); err != nil {
+				b.serverError(err)
+				break
+			}
+			continue
+		}
+
+		req, err := DecodeRequest(conn)
 		if err != nil {
 			Logger.Printf("*** mockbroker/%d/%d: invalid request: err=%+v, %+v", b.brokerID, idx, err, spew.Sdump(req))
 			b.serverError(err)
@@ -175,6 +197,10 @@ func (b *mockBroker) handleRequests(conn net.Conn, idx int, wg *sync.WaitGroup)
 		}
 		Logger.Printf("*** mockbroker/%d/%d: served %v -> %v", b.brokerID, idx, req, res)
 
+		if handshake, ok := res.(*SaslHandshakeResponse); ok && handshake.Err == ErrNoError && req.Body.Version() == 0 {
+			expectSASLToken = true
+		}
+
 		encodedRes, err := Encode(res)
 		if err != nil {
 			b.serverError(err)
@@ -198,6 +224,24 @@ func (b *mockBroker) handleRequests(conn net.Conn, idx int, wg *sync.WaitGroup)
 	Logger.Printf("*** mockbroker/%d/%d: connection closed, err=%v", b.BrokerID(), idx, err)
 }
 
+func (b *mockBroker) readSASLToken(conn net.Conn) error {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return err
+	}
+	token := make([]byte, binary.BigEndian.Uint32(header))
+	if _, err := io.ReadFull(conn, token); err != nil {
+		return err
+	}
+
+	b.lock.Lock()
+	b.saslTokens = append(b.saslTokens, token)
+	b.lock.Unlock()
+
+	_, err := conn.Write([]byte{0, 0, 0, 0})
+	return err
+}
+
 func (b *mockBroker) defaultRequestHandler(req *Request) (res Encoder) {
 	select {
 	case res, ok := <-b.expectations:
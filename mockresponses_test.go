@@ -1,6 +1,7 @@
 package sarama
 
 import (
+	"sync"
 	"testing"
 )
 
@@ -22,11 +23,33 @@ func newMockWrapper(res Encoder) *mockWrapper {
 	return &mockWrapper{res: res}
 }
 
+// mockSequence returns the given responses in order, the last one again and again.
+type mockSequence struct {
+	lock      sync.Mutex
+	responses []MockResponse
+}
+
+func newMockSequence(responses ...MockResponse) *mockSequence {
+	return &mockSequence{responses: responses}
+}
+
+func (ms *mockSequence) For(reqBody Decoder) (res Encoder) {
+	ms.lock.Lock()
+	defer ms.lock.Unlock()
+
+	res = ms.responses[0].For(reqBody)
+	if len(ms.responses) > 1 {
+		ms.responses = ms.responses[1:]
+	}
+	return res
+}
+
 // mockMetadataResponse is a `MetadataResponse` builder.
 type mockMetadataResponse struct {
-	leaders map[string]map[int32]int32
-	brokers map[string]int32
-	t       *testing.T
+	leaders      map[string]map[int32]int32
+	brokers      map[string]int32
+	controllerID int32
+	t            *testing.T
 }
 
 func newMockMetadataResponse(t *testing.T) *mockMetadataResponse {
@@ -52,9 +75,14 @@ func (mmr *mockMetadataResponse) SetBroker(addr string, brokerID int32) *mockMet
 	return mmr
 }
 
+func (mmr *mockMetadataResponse) SetController(brokerID int32) *mockMetadataResponse {
+	mmr.controllerID = brokerID
+	return mmr
+}
+
 func (mor *mockMetadataResponse) For(reqBody Decoder) Encoder {
 	metadataRequest := reqBody.(*MetadataRequest)
-	metadataResponse := &MetadataResponse{}
+	metadataResponse := &MetadataResponse{IVersion: metadataRequest.IVersion, ControllerID: mor.controllerID}
 	for addr, brokerID := range mor.brokers {
 		metadataResponse.AddBroker(addr, brokerID)
 	}
@@ -104,8 +132,8 @@ func (mor *mockOffsetResponse) SetOffset(topic string, partition int32, time, of
 
 func (mor *mockOffsetResponse) For(reqBody Decoder) Encoder {
 	offsetRequest := reqBody.(*OffsetRequest)
-	offsetResponse := &OffsetResponse{}
-	for topic, partitions := range offsetRequest.blocks {
+	offsetResponse := &OffsetResponse{IVersion: offsetRequest.IVersion}
+	for topic, partitions := range offsetRequest.Blocks {
 		for partition, block := range partitions {
 			offset := mor.getOffset(topic, partition, block.Time)
 			offsetResponse.AddTopicPartition(topic, partition, offset)
@@ -174,8 +202,8 @@ func (mfr *mockFetchResponse) SetHighWaterMark(topic string, partition int32, of
 
 func (mfr *mockFetchResponse) For(reqBody Decoder) Encoder {
 	fetchRequest := reqBody.(*FetchRequest)
-	res := &FetchResponse{}
-	for topic, partitions := range fetchRequest.blocks {
+	res := &FetchResponse{IVersion: fetchRequest.IVersion}
+	for topic, partitions := range fetchRequest.Blocks {
 		for partition, block := range partitions {
 			initialOffset := block.FetchOffset
 			offset := initialOffset
@@ -257,11 +285,11 @@ func (mr *mockConsumerMetadataResponse) SetError(group string, kerror KError) *m
 func (mr *mockConsumerMetadataResponse) For(reqBody Decoder) Encoder {
 	req := reqBody.(*ConsumerMetadataRequest)
 	group := req.ConsumerGroup
-	res := &ConsumerMetadataResponse{}
+	res := &ConsumerMetadataResponse{IVersion: req.IVersion}
 	v := mr.coordinators[group]
 	switch v := v.(type) {
 	case *mockBroker:
-		res.Coordinator = &Broker{id: v.BrokerID(), addr: v.Addr()}
+		res.Coordinator = &Broker{id: v.BrokerID(), IAddr: v.Addr()}
 	case KError:
 		res.Err = v
 	}
@@ -300,7 +328,7 @@ func (mr *mockOffsetCommitResponse) For(reqBody Decoder) Encoder {
 	req := reqBody.(*OffsetCommitRequest)
 	group := req.ConsumerGroup
 	res := &OffsetCommitResponse{}
-	for topic, partitions := range req.blocks {
+	for topic, partitions := range req.Blocks {
 		for partition := range partitions {
 			res.AddError(topic, partition, mr.getError(group, topic, partition))
 		}
@@ -349,8 +377,13 @@ func (mr *mockProduceResponse) SetError(topic string, partition int32, kerror KE
 
 func (mr *mockProduceResponse) For(reqBody Decoder) Encoder {
 	req := reqBody.(*ProduceRequest)
-	res := &ProduceResponse{}
-	for topic, partitions := range req.msgSets {
+	res := &ProduceResponse{IVersion: req.IVersion}
+	for topic, partitions := range req.MsgSets {
+		for partition := range partitions {
+			res.AddTopicPartition(topic, partition, mr.getError(topic, partition))
+		}
+	}
+	for topic, partitions := range req.RecordBatches {
 		for partition := range partitions {
 			res.AddTopicPartition(topic, partition, mr.getError(topic, partition))
 		}
@@ -401,7 +434,7 @@ func (mr *mockOffsetFetchResponse) SetOffset(group, topic string, partition int3
 func (mr *mockOffsetFetchResponse) For(reqBody Decoder) Encoder {
 	req := reqBody.(*OffsetFetchRequest)
 	group := req.ConsumerGroup
-	res := &OffsetFetchResponse{}
+	res := &OffsetFetchResponse{IVersion: req.IVersion}
 	for topic, partitions := range mr.offsets[group] {
 		for partition, block := range partitions {
 			res.AddBlock(topic, partition, block)
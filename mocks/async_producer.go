@@ -9,7 +9,8 @@ import (
 // AsyncProducer implements sarama's Producer interface for testing purposes.
 // Before you can send messages to it's Input channel, you have to set expectations
 // so it knows how to handle the input. This way you can easily test success and
-// failure scenarios.
+// failure scenarios. It does not track transactions: BeginTxn, CommitTxn, AbortTxn
+// and AddOffsetsToTxn always succeed.
 type AsyncProducer struct {
 	l            sync.Mutex
 	t            ErrorReporter
@@ -118,6 +119,27 @@ func (mp *AsyncProducer) Errors() <-chan *sarama.ProducerError {
 	return mp.errors
 }
 
+// BeginTxn corresponds with the BeginTxn method of sarama's Producer implementation.
+func (mp *AsyncProducer) BeginTxn() error {
+	return nil
+}
+
+// CommitTxn corresponds with the CommitTxn method of sarama's Producer implementation.
+func (mp *AsyncProducer) CommitTxn() error {
+	return nil
+}
+
+// AbortTxn corresponds with the AbortTxn method of sarama's Producer implementation.
+func (mp *AsyncProducer) AbortTxn() error {
+	return nil
+}
+
+// AddOffsetsToTxn corresponds with the AddOffsetsToTxn method of sarama's Producer
+// implementation.
+func (mp *AsyncProducer) AddOffsetsToTxn(offsets map[string][]*sarama.PartitionOffsetMetadata, groupID string) error {
+	return nil
+}
+
 ////////////////////////////////////////////////
 // Setting expectations
 ////////////////////////////////////////////////
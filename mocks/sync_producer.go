@@ -8,7 +8,8 @@ import (
 // SyncProducer implements sarama's SyncProducer interface for testing purposes.
 // Before you can use it, you have to set expectations on the mock SyncProducer
 // to tell it how to handle calls to SendMessage, so you can easily test success
-// and failure scenarios.
+// and failure scenarios. It does not track transactions: BeginTxn, CommitTxn,
+// AbortTxn and AddOffsetsToTxn always succeed.
 type SyncProducer struct {
 	l            sync.Mutex
 	t            ErrorReporter
@@ -70,6 +71,27 @@ func (sp *SyncProducer) Close() error {
 	return nil
 }
 
+// BeginTxn corresponds with the BeginTxn method of sarama's SyncProducer implementation.
+func (sp *SyncProducer) BeginTxn() error {
+	return nil
+}
+
+// CommitTxn corresponds with the CommitTxn method of sarama's SyncProducer implementation.
+func (sp *SyncProducer) CommitTxn() error {
+	return nil
+}
+
+// AbortTxn corresponds with the AbortTxn method of sarama's SyncProducer implementation.
+func (sp *SyncProducer) AbortTxn() error {
+	return nil
+}
+
+// AddOffsetsToTxn corresponds with the AddOffsetsToTxn method of sarama's SyncProducer
+// implementation.
+func (sp *SyncProducer) AddOffsetsToTxn(offsets map[string][]*sarama.PartitionOffsetMetadata, groupID string) error {
+	return nil
+}
+
 ////////////////////////////////////////////////
 // Setting expectations
 ////////////////////////////////////////////////
@@ -5,6 +5,11 @@ package sarama
 // The timestamp is only used if message version 1 is used, which requires kafka 0.8.2.
 const ReceiveTime int64 = -1
 
+// GroupGenerationUndefined is a special value for the group generation field of
+// Offset Commit Requests that should be used when a consumer group does not rely
+// on Kafka for partition management.
+const GroupGenerationUndefined = -1
+
 type offsetCommitRequestBlock struct {
 	Offset    int64
 	Timestamp int64
@@ -46,7 +51,7 @@ type OffsetCommitRequest struct {
 	// - 1 (kafka 0.8.2 and later)
 	// - 2 (kafka 0.8.3 and later)
 	IVersion int16
-	Blocks  map[string]map[int32]*offsetCommitRequestBlock
+	Blocks   map[string]map[int32]*offsetCommitRequestBlock
 }
 
 func (r *OffsetCommitRequest) Encode(pe packetEncoder) error {
@@ -159,6 +164,10 @@ func (r *OffsetCommitRequest) Version() int16 {
 	return r.IVersion
 }
 
+func (r *OffsetCommitRequest) String() string {
+	return dump(r)
+}
+
 func (r *OffsetCommitRequest) AddBlock(topic string, partitionID int32, offset int64, timestamp int64, metadata string) {
 	if r.Blocks == nil {
 		r.Blocks = make(map[string]map[int32]*offsetCommitRequestBlock)
@@ -71,3 +71,7 @@ func (r *OffsetCommitResponse) Decode(pd packetDecoder) (err error) {
 
 	return nil
 }
+
+func (r *OffsetCommitResponse) String() string {
+	return dump(r)
+}
@@ -2,18 +2,31 @@ package sarama
 
 type OffsetFetchRequest struct {
 	ConsumerGroup string
-	IVersion       int16
-	Partitions    map[string][]int32
+
+	// Version can be:
+	// - 0 (kafka 0.8.1 and later, reading offsets from zookeeper)
+	// - 1 (kafka 0.8.2 and later, reading offsets from kafka)
+	// - 2 (kafka 0.10.2 and later, allowing nil Partitions and adding an error to the response)
+	// - 3 (kafka 0.11 and later, adding throttle time to the response)
+	IVersion int16
+
+	// Partitions to fetch the offsets of; from version 2 on, nil fetches every partition the
+	// group has committed an offset for
+	Partitions map[string][]int32
 }
 
 func (r *OffsetFetchRequest) Encode(pe packetEncoder) (err error) {
-	if r.IVersion < 0 || r.IVersion > 1 {
+	if r.IVersion < 0 || r.IVersion > 3 {
 		return PacketEncodingError{"invalid or unsupported OffsetFetchRequest version field"}
 	}
 
 	if err = pe.putString(r.ConsumerGroup); err != nil {
 		return err
 	}
+	if r.IVersion >= 2 && r.Partitions == nil {
+		pe.putInt32(-1)
+		return nil
+	}
 	if err = pe.putArrayLength(len(r.Partitions)); err != nil {
 		return err
 	}
@@ -32,11 +45,18 @@ func (r *OffsetFetchRequest) Decode(pd packetDecoder) (err error) {
 	if r.ConsumerGroup, err = pd.getString(); err != nil {
 		return err
 	}
-	partitionCount, err := pd.getArrayLength()
-	if err != nil {
+	var partitionCount int
+	if r.IVersion >= 2 {
+		// a null array asks for every partition, which getArrayLength does not allow
+		n, err := pd.getInt32()
+		if err != nil {
+			return err
+		}
+		partitionCount = int(n)
+	} else if partitionCount, err = pd.getArrayLength(); err != nil {
 		return err
 	}
-	if partitionCount == 0 {
+	if partitionCount <= 0 {
 		return nil
 	}
 	r.Partitions = make(map[string][]int32)
@@ -62,6 +82,10 @@ func (r *OffsetFetchRequest) Version() int16 {
 	return r.IVersion
 }
 
+func (r *OffsetFetchRequest) String() string {
+	return dump(r)
+}
+
 func (r *OffsetFetchRequest) AddPartition(topic string, partitionID int32) {
 	if r.Partitions == nil {
 		r.Partitions = make(map[string][]int32)
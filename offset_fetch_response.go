@@ -1,5 +1,7 @@
 package sarama
 
+import "time"
+
 type OffsetFetchResponseBlock struct {
 	Offset   int64
 	Metadata string
@@ -40,10 +42,19 @@ func (r *OffsetFetchResponseBlock) Encode(pe packetEncoder) (err error) {
 }
 
 type OffsetFetchResponse struct {
-	Blocks map[string]map[int32]*OffsetFetchResponseBlock
+	ThrottleTime time.Duration // only provided if Version >= 3
+	Blocks       map[string]map[int32]*OffsetFetchResponseBlock
+	Err          KError // errors of the whole group, only provided if Version >= 2
+
+	// Version must be set to that of the request before decoding
+	IVersion int16
 }
 
 func (r *OffsetFetchResponse) Encode(pe packetEncoder) error {
+	if r.IVersion >= 3 {
+		pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	}
+
 	if err := pe.putArrayLength(len(r.Blocks)); err != nil {
 		return err
 	}
@@ -61,16 +72,30 @@ func (r *OffsetFetchResponse) Encode(pe packetEncoder) error {
 			}
 		}
 	}
+
+	if r.IVersion >= 2 {
+		pe.putInt16(int16(r.Err))
+	}
 	return nil
 }
 
 func (r *OffsetFetchResponse) Decode(pd packetDecoder) (err error) {
+	if r.IVersion >= 3 {
+		millis, err := pd.getInt32()
+		if err != nil {
+			return err
+		}
+		r.ThrottleTime = time.Duration(millis) * time.Millisecond
+	}
+
 	numTopics, err := pd.getArrayLength()
-	if err != nil || numTopics == 0 {
+	if err != nil {
 		return err
 	}
 
-	r.Blocks = make(map[string]map[int32]*OffsetFetchResponseBlock, numTopics)
+	if numTopics > 0 {
+		r.Blocks = make(map[string]map[int32]*OffsetFetchResponseBlock, numTopics)
+	}
 	for i := 0; i < numTopics; i++ {
 		name, err := pd.getString()
 		if err != nil {
@@ -103,9 +128,21 @@ func (r *OffsetFetchResponse) Decode(pd packetDecoder) (err error) {
 		}
 	}
 
+	if r.IVersion >= 2 {
+		kerr, err := pd.getInt16()
+		if err != nil {
+			return err
+		}
+		r.Err = KError(kerr)
+	}
+
 	return nil
 }
 
+func (r *OffsetFetchResponse) String() string {
+	return dump(r)
+}
+
 func (r *OffsetFetchResponse) GetBlock(topic string, partition int32) *OffsetFetchResponseBlock {
 	if r.Blocks == nil {
 		return nil
@@ -1,10 +1,28 @@
 package sarama
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 var (
 	emptyOffsetFetchResponse = []byte{
 		0x00, 0x00, 0x00, 0x00}
+
+	offsetFetchResponseV3 = []byte{
+		0x00, 0x00, 0x00, 0x64,
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x01, 't',
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05,
+		0x00, 0x02, 'm', 'd',
+		0x00, 0x00,
+		0x00, 0x00}
+
+	offsetFetchResponseGroupError = []byte{
+		0x00, 0x00, 0x00, 0x00,
+		0x00, 0x0E}
 )
 
 func TestEmptyOffsetFetchResponse(t *testing.T) {
@@ -20,3 +38,25 @@ func TestNormalOffsetFetchResponse(t *testing.T) {
 	// unpredictable map traversal order.
 	testResponse(t, "normal", &response, nil)
 }
+
+func TestOffsetFetchResponseV2(t *testing.T) {
+	response := &OffsetFetchResponse{IVersion: 2}
+	testDecodable(t, "group error", response, offsetFetchResponseGroupError)
+	if response.Err != ErrOffsetsLoadInProgress || len(response.Blocks) != 0 {
+		t.Error("Decoding group error failed, got", response.Err, response.Blocks)
+	}
+	testEncodable(t, "group error", response, offsetFetchResponseGroupError)
+}
+
+func TestOffsetFetchResponseV3(t *testing.T) {
+	response := &OffsetFetchResponse{IVersion: 3}
+	testDecodable(t, "version 3", response, offsetFetchResponseV3)
+	if response.ThrottleTime != 100*time.Millisecond {
+		t.Error("Decoding ThrottleTime failed, got", response.ThrottleTime)
+	}
+	block := response.GetBlock("t", 0)
+	if block == nil || block.Offset != 5 || block.Metadata != "md" || block.Err != ErrNoError {
+		t.Error("Decoding block failed, got", block)
+	}
+	testEncodable(t, "version 3", response, offsetFetchResponseV3)
+}
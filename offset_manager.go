@@ -26,6 +26,10 @@ type offsetManager struct {
 	conf   *Config
 	group  string
 
+	// set when the offsets belong to a member of a Kafka-coordinated group, see ConsumerGroup
+	memberID   string
+	generation int32
+
 	lock sync.Mutex
 	poms map[string]map[int32]*partitionOffsetManager
 	boms map[*Broker]*brokerOffsetManager
@@ -34,17 +38,23 @@ type offsetManager struct {
 // NewOffsetManagerFromClient creates a new OffsetManager from the given client.
 // It is still necessary to call Close() on the underlying client when finished with the partition manager.
 func NewOffsetManagerFromClient(group string, client Client) (OffsetManager, error) {
+	return newOffsetManagerFromClient(group, "", GroupGenerationUndefined, client)
+}
+
+func newOffsetManagerFromClient(group, memberID string, generation int32, client Client) (*offsetManager, error) {
 	// Check that we are not dealing with a closed Client before processing any other arguments
 	if client.Closed() {
 		return nil, ErrClosedClient
 	}
 
 	om := &offsetManager{
-		client: client,
-		conf:   client.Config(),
-		group:  group,
-		poms:   make(map[string]map[int32]*partitionOffsetManager),
-		boms:   make(map[*Broker]*brokerOffsetManager),
+		client:     client,
+		conf:       client.Config(),
+		group:      group,
+		memberID:   memberID,
+		generation: generation,
+		poms:       make(map[string]map[int32]*partitionOffsetManager),
+		boms:       make(map[*Broker]*brokerOffsetManager),
 	}
 
 	return om, nil
@@ -257,8 +267,14 @@ func (pom *partitionOffsetManager) selectBroker() error {
 }
 
 func (pom *partitionOffsetManager) fetchInitialOffset(retries int) error {
+	// version 0 reads offsets from zookeeper, we always want the kafka-stored ones
+	version, err := pom.broker.broker.requestVersion(9, 1)
+	if err != nil {
+		return err
+	}
+
 	request := new(OffsetFetchRequest)
-	request.IVersion = 1
+	request.IVersion = version
 	request.ConsumerGroup = pom.parent.group
 	request.AddPartition(pom.topic, pom.partition)
 
@@ -267,7 +283,11 @@ func (pom *partitionOffsetManager) fetchInitialOffset(retries int) error {
 		return err
 	}
 
+	// from version 2 on, errors of the whole group come without any partition
 	block := response.GetBlock(pom.topic, pom.partition)
+	if response.Err != ErrNoError {
+		block = &OffsetFetchResponseBlock{Err: response.Err}
+	}
 	if block == nil {
 		return ErrIncompleteResponse
 	}
@@ -325,6 +345,20 @@ func (pom *partitionOffsetManager) MarkOffset(offset int64, metadata string) {
 	}
 }
 
+// abandonDirty gives up on committing the marked offset, when the group has moved on to a
+// generation this member is not part of and so is no longer allowed to commit it
+func (pom *partitionOffsetManager) abandonDirty() {
+	pom.lock.Lock()
+	defer pom.lock.Unlock()
+
+	pom.dirty = false
+
+	select {
+	case pom.clean <- none{}:
+	default:
+	}
+}
+
 func (pom *partitionOffsetManager) updateCommitted(offset int64, metadata string) {
 	pom.lock.Lock()
 	defer pom.lock.Unlock()
@@ -425,7 +459,14 @@ func (bom *brokerOffsetManager) mainLoop() {
 }
 
 func (bom *brokerOffsetManager) flushToBroker() {
-	request := bom.constructRequest()
+	// version 0 commits offsets to zookeeper, we always want to store them in kafka
+	version, err := bom.broker.requestVersion(8, 1)
+	if err != nil {
+		bom.abort(err)
+		return
+	}
+
+	request := bom.constructRequest(version)
 	if request == nil {
 		return
 	}
@@ -466,6 +507,9 @@ func (bom *brokerOffsetManager) flushToBroker() {
 		case ErrUnknownTopicOrPartition, ErrNotLeaderForPartition, ErrLeaderNotAvailable:
 			delete(bom.subscriptions, s)
 			s.rebalance <- none{}
+		case ErrIllegalGeneration, ErrUnknownMemberID, ErrRebalanceInProgress:
+			s.handleError(err)
+			s.abandonDirty()
 		default:
 			s.handleError(err)
 			delete(bom.subscriptions, s)
@@ -474,16 +518,26 @@ func (bom *brokerOffsetManager) flushToBroker() {
 	}
 }
 
-func (bom *brokerOffsetManager) constructRequest() *OffsetCommitRequest {
+func (bom *brokerOffsetManager) constructRequest(version int16) *OffsetCommitRequest {
 	r := &OffsetCommitRequest{
-		IVersion:       1,
-		ConsumerGroup: bom.parent.group,
+		IVersion:                version,
+		ConsumerGroup:           bom.parent.group,
+		ConsumerGroupGeneration: bom.parent.generation,
+		ConsumerID:              bom.parent.memberID,
+	}
+
+	// only version 1 carries a per-partition timestamp, later versions use a retention time
+	// for the whole request instead, and -1 means to use the broker's configured retention
+	timestamp := ReceiveTime
+	if version >= 2 {
+		timestamp = 0
+		r.RetentionTime = -1
 	}
 
 	for s := range bom.subscriptions {
 		s.lock.Lock()
 		if s.dirty {
-			r.AddBlock(s.topic, s.partition, s.offset, ReceiveTime, s.metadata)
+			r.AddBlock(s.topic, s.partition, s.offset, timestamp, s.metadata)
 		}
 		s.lock.Unlock()
 	}
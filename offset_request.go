@@ -2,30 +2,44 @@ package sarama
 
 type offsetRequestBlock struct {
 	Time       int64
-	MaxOffsets int32
+	MaxOffsets int32 // only in version 0, version 1 always returns a single offset
 }
 
-func (r *offsetRequestBlock) Encode(pe packetEncoder) error {
+func (r *offsetRequestBlock) encode(pe packetEncoder, version int16) error {
 	pe.putInt64(int64(r.Time))
-	pe.putInt32(r.MaxOffsets)
+	if version == 0 {
+		pe.putInt32(r.MaxOffsets)
+	}
 	return nil
 }
 
-func (r *offsetRequestBlock) Decode(pd packetDecoder) (err error) {
+func (r *offsetRequestBlock) decode(pd packetDecoder, version int16) (err error) {
 	if r.Time, err = pd.getInt64(); err != nil {
 		return err
 	}
-	if r.MaxOffsets, err = pd.getInt32(); err != nil {
-		return err
+	if version == 0 {
+		if r.MaxOffsets, err = pd.getInt32(); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
 type OffsetRequest struct {
 	Blocks map[string]map[int32]*offsetRequestBlock
+
+	// Version can be:
+	// - 0 (kafka 0.8 and later, returning the offsets of the segments that start before the time)
+	// - 1 (kafka 0.10.1 and later, returning the earliest offset whose timestamp is at or after the
+	//   time)
+	IVersion int16
 }
 
 func (r *OffsetRequest) Encode(pe packetEncoder) error {
+	if r.IVersion < 0 || r.IVersion > 1 {
+		return PacketEncodingError{"invalid or unsupported OffsetRequest version field"}
+	}
+
 	pe.putInt32(-1) // replica ID is always -1 for clients
 	err := pe.putArrayLength(len(r.Blocks))
 	if err != nil {
@@ -42,7 +56,7 @@ func (r *OffsetRequest) Encode(pe packetEncoder) error {
 		}
 		for partition, block := range partitions {
 			pe.putInt32(partition)
-			if err = block.Encode(pe); err != nil {
+			if err = block.encode(pe, r.IVersion); err != nil {
 				return err
 			}
 		}
@@ -79,7 +93,7 @@ func (r *OffsetRequest) Decode(pd packetDecoder) error {
 				return err
 			}
 			block := &offsetRequestBlock{}
-			if err := block.Decode(pd); err != nil {
+			if err := block.decode(pd, r.IVersion); err != nil {
 				return err
 			}
 			r.Blocks[topic][partition] = block
@@ -93,9 +107,15 @@ func (r *OffsetRequest) Key() int16 {
 }
 
 func (r *OffsetRequest) Version() int16 {
-	return 0
+	return r.IVersion
+}
+
+func (r *OffsetRequest) String() string {
+	return dump(r)
 }
 
+// AddBlock asks for the offsets of the given partition at the given time, in milliseconds since
+// the epoch, or OffsetNewest or OffsetOldest. maxOffsets is ignored from version 1.
 func (r *OffsetRequest) AddBlock(topic string, partitionID int32, time int64, maxOffsets int32) {
 	if r.Blocks == nil {
 		r.Blocks = make(map[string]map[int32]*offsetRequestBlock)
@@ -15,6 +15,14 @@ var (
 		0x00, 0x00, 0x00, 0x04,
 		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01,
 		0x00, 0x00, 0x00, 0x02}
+
+	offsetRequestOneBlockV1 = []byte{
+		0xFF, 0xFF, 0xFF, 0xFF,
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x03, 'b', 'a', 'r',
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x00, 0x04,
+		0x00, 0x00, 0x01, 0x58, 0x1F, 0xAA, 0x3C, 0x00}
 )
 
 func TestOffsetRequest(t *testing.T) {
@@ -24,3 +32,11 @@ func TestOffsetRequest(t *testing.T) {
 	request.AddBlock("foo", 4, 1, 2)
 	testRequest(t, "one block", request, offsetRequestOneBlock)
 }
+
+func TestOffsetRequestV1(t *testing.T) {
+	request := &OffsetRequest{IVersion: 1}
+	testRequest(t, "no blocks", request, offsetRequestNoBlocks)
+
+	request.AddBlock("bar", 4, 1478000000000, 0)
+	testRequest(t, "one block", request, offsetRequestOneBlockV1)
+}
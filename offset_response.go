@@ -1,30 +1,48 @@
 package sarama
 
 type OffsetResponseBlock struct {
-	Err     KError
-	Offsets []int64
+	Err       KError
+	Offsets   []int64 // only in version 0
+	Timestamp int64   // only in version 1 and later, -1 if no message matched
+	Offset    int64   // only in version 1 and later, -1 if no message matched
 }
 
-func (r *OffsetResponseBlock) Decode(pd packetDecoder) (err error) {
+func (r *OffsetResponseBlock) decode(pd packetDecoder, version int16) (err error) {
 	tmp, err := pd.getInt16()
 	if err != nil {
 		return err
 	}
 	r.Err = KError(tmp)
 
-	r.Offsets, err = pd.getInt64Array()
+	if version == 0 {
+		r.Offsets, err = pd.getInt64Array()
+		return err
+	}
 
+	if r.Timestamp, err = pd.getInt64(); err != nil {
+		return err
+	}
+	r.Offset, err = pd.getInt64()
 	return err
 }
 
-func (r *OffsetResponseBlock) Encode(pe packetEncoder) (err error) {
+func (r *OffsetResponseBlock) encode(pe packetEncoder, version int16) (err error) {
 	pe.putInt16(int16(r.Err))
 
-	return pe.putInt64Array(r.Offsets)
+	if version == 0 {
+		return pe.putInt64Array(r.Offsets)
+	}
+
+	pe.putInt64(r.Timestamp)
+	pe.putInt64(r.Offset)
+	return nil
 }
 
 type OffsetResponse struct {
 	Blocks map[string]map[int32]*OffsetResponseBlock
+
+	// IVersion must be set to that of the request before decoding.
+	IVersion int16
 }
 
 func (r *OffsetResponse) Decode(pd packetDecoder) (err error) {
@@ -54,7 +72,7 @@ func (r *OffsetResponse) Decode(pd packetDecoder) (err error) {
 			}
 
 			block := new(OffsetResponseBlock)
-			err = block.Decode(pd)
+			err = block.decode(pd, r.IVersion)
 			if err != nil {
 				return err
 			}
@@ -77,6 +95,21 @@ func (r *OffsetResponse) GetBlock(topic string, partition int32) *OffsetResponse
 	return r.Blocks[topic][partition]
 }
 
+// offset returns the single offset a version 0 request asking for one offset or any version 1
+// request got back, or ErrOffsetOutOfRange if there is none.
+func (r *OffsetResponseBlock) offset(version int16) (int64, error) {
+	if version >= 1 {
+		if r.Offset < 0 {
+			return -1, ErrOffsetOutOfRange
+		}
+		return r.Offset, nil
+	}
+	if len(r.Offsets) != 1 {
+		return -1, ErrOffsetOutOfRange
+	}
+	return r.Offsets[0], nil
+}
+
 /*
 // [0 0 0 1 ntopics
 0 8 109 121 95 116 111 112 105 99 topic
@@ -106,7 +139,7 @@ func (r *OffsetResponse) Encode(pe packetEncoder) (err error) {
 		}
 		for partition, block := range partitions {
 			pe.putInt32(partition)
-			if err = block.Encode(pe); err != nil {
+			if err = block.encode(pe, r.IVersion); err != nil {
 				return err
 			}
 		}
@@ -115,6 +148,10 @@ func (r *OffsetResponse) Encode(pe packetEncoder) (err error) {
 	return nil
 }
 
+func (r *OffsetResponse) String() string {
+	return dump(r)
+}
+
 // testing API
 
 func (r *OffsetResponse) AddTopicPartition(topic string, partition int32, offset int64) {
@@ -126,5 +163,5 @@ func (r *OffsetResponse) AddTopicPartition(topic string, partition int32, offset
 		byTopic = make(map[int32]*OffsetResponseBlock)
 		r.Blocks[topic] = byTopic
 	}
-	byTopic[partition] = &OffsetResponseBlock{Offsets: []int64{offset}}
+	byTopic[partition] = &OffsetResponseBlock{Offsets: []int64{offset}, Timestamp: -1, Offset: offset}
 }
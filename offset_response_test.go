@@ -19,6 +19,20 @@ var (
 		0x00, 0x00, 0x00, 0x02,
 		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05,
 		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x06}
+
+	offsetResponseV1 = []byte{
+		0x00, 0x00, 0x00, 0x01,
+
+		0x00, 0x01, 'z',
+		0x00, 0x00, 0x00, 0x02,
+		0x00, 0x00, 0x00, 0x02,
+		0x00, 0x00,
+		0x00, 0x00, 0x01, 0x58, 0x1F, 0xAA, 0x3C, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x07,
+		0x00, 0x00, 0x00, 0x03,
+		0x00, 0x00,
+		0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF,
+		0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}
 )
 
 func TestEmptyOffsetResponse(t *testing.T) {
@@ -60,3 +74,28 @@ func TestNormalOffsetResponse(t *testing.T) {
 	}
 
 }
+
+func TestOffsetResponseV1(t *testing.T) {
+	response := OffsetResponse{IVersion: 1}
+
+	testDecodable(t, "v1", &response, offsetResponseV1)
+
+	block := response.GetBlock("z", 2)
+	if block == nil || block.Err != ErrNoError {
+		t.Fatal("Decoding produced no block or an error for topic z partition 2.")
+	}
+	if block.Timestamp != 1478000000000 || block.Offset != 7 {
+		t.Error("Decoding produced invalid timestamp or offset for topic z partition 2:", block.Timestamp, block.Offset)
+	}
+	if offset, err := block.offset(response.IVersion); err != nil || offset != 7 {
+		t.Error("Expected offset 7, got", offset, err)
+	}
+
+	block = response.GetBlock("z", 3)
+	if block == nil || block.Offset != -1 {
+		t.Fatal("Decoding produced an invalid block for topic z partition 3.")
+	}
+	if _, err := block.offset(response.IVersion); err != ErrOffsetOutOfRange {
+		t.Error("Expected ErrOffsetOutOfRange when no message matched, got", err)
+	}
+}
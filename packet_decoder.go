@@ -6,20 +6,36 @@ package sarama
 type packetDecoder interface {
 	// Primitives
 	getInt8() (int8, error)
+	getBool() (bool, error)
 	getInt16() (int16, error)
 	getInt32() (int32, error)
 	getInt64() (int64, error)
+	getVarint() (int64, error)
+	getUVarint() (uint64, error)
 	getArrayLength() (int, error)
+	getCompactArrayLength() (int, error)
 
 	// Collections
 	getBytes() ([]byte, error)
+	getVarintBytes() ([]byte, error)
+	getCompactBytes() ([]byte, error)
+	getRawBytes(length int) ([]byte, error)
 	getString() (string, error)
+	getNullableString() (*string, error)
+	getCompactString() (string, error)
+	getNullableCompactString() (*string, error)
+	getStringArray() ([]string, error)
 	getInt32Array() ([]int32, error)
 	getInt64Array() ([]int64, error)
+	getCompactInt32Array() ([]int32, error)
+
+	// Tagged fields, see packetEncoder
+	getTaggedFields() (map[uint64][]byte, error)
 
 	// Subsets
 	remaining() int
 	getSubset(length int) (packetDecoder, error)
+	peekInt8(offset int) (int8, error)
 
 	// Stacks, see PushDecoder
 	push(in pushDecoder) error
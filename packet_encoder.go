@@ -6,17 +6,32 @@ package sarama
 type packetEncoder interface {
 	// Primitives
 	putInt8(in int8)
+	putBool(in bool)
 	putInt16(in int16)
 	putInt32(in int32)
 	putInt64(in int64)
+	putVarint(in int64)
+	putUVarint(in uint64)
 	putArrayLength(in int) error
+	putCompactArrayLength(in int)
 
 	// Collections
 	putBytes(in []byte) error
+	putVarintBytes(in []byte) error
+	putCompactBytes(in []byte) error
 	putRawBytes(in []byte) error
 	putString(in string) error
+	putNullableString(in *string) error
+	putCompactString(in string) error
+	putNullableCompactString(in *string) error
+	putStringArray(in []string) error
 	putInt32Array(in []int32) error
 	putInt64Array(in []int64) error
+	putCompactInt32Array(in []int32) error
+
+	// Tagged fields, the extension point of flexible versions (KIP-482): a map from tag to
+	// the raw bytes of the field, written in ascending order of tag
+	putTaggedFields(in map[uint64][]byte) error
 
 	// Stacks, see PushEncoder
 	push(in pushEncoder)
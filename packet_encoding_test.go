@@ -0,0 +1,117 @@
+package sarama
+
+import (
+	"reflect"
+	"testing"
+)
+
+type compactFieldsEncoder struct {
+	str      string
+	nullable *string
+	bytes    []byte
+	ints     []int32
+	tagged   map[uint64][]byte
+}
+
+func (c *compactFieldsEncoder) Encode(pe packetEncoder) error {
+	if err := pe.putCompactString(c.str); err != nil {
+		return err
+	}
+	if err := pe.putNullableCompactString(c.nullable); err != nil {
+		return err
+	}
+	if err := pe.putCompactBytes(c.bytes); err != nil {
+		return err
+	}
+	if err := pe.putCompactInt32Array(c.ints); err != nil {
+		return err
+	}
+	return pe.putTaggedFields(c.tagged)
+}
+
+func (c *compactFieldsEncoder) Decode(pd packetDecoder) (err error) {
+	if c.str, err = pd.getCompactString(); err != nil {
+		return err
+	}
+	if c.nullable, err = pd.getNullableCompactString(); err != nil {
+		return err
+	}
+	if c.bytes, err = pd.getCompactBytes(); err != nil {
+		return err
+	}
+	if c.ints, err = pd.getCompactInt32Array(); err != nil {
+		return err
+	}
+	c.tagged, err = pd.getTaggedFields()
+	return err
+}
+
+var (
+	compactFields = []byte{
+		0x04, 'a', 'b', 'c', // compact string
+		0x00,             // null compact string
+		0x03, 0x01, 0x02, // compact bytes
+		0x02, 0x00, 0x00, 0x00, 0x07, // compact int32 array
+		0x02,             // two tagged fields
+		0x00, 0x01, 0xAA, // tag 0
+		0x81, 0x01, 0x00, // tag 129, empty
+	}
+
+	emptyCompactFields = []byte{
+		0x01, // empty compact string
+		0x01, // empty nullable compact string
+		0x00, // null compact bytes
+		0x01, // empty compact int32 array
+		0x00, // no tagged fields
+	}
+)
+
+func TestCompactFieldsEncoding(t *testing.T) {
+	fields := &compactFieldsEncoder{
+		str:    "abc",
+		bytes:  []byte{0x01, 0x02},
+		ints:   []int32{7},
+		tagged: map[uint64][]byte{129: {}, 0: {0xAA}},
+	}
+	testEncodable(t, "compact fields", fields, compactFields)
+
+	decoded := new(compactFieldsEncoder)
+	testDecodable(t, "compact fields", decoded, compactFields)
+	if !reflect.DeepEqual(fields, decoded) {
+		t.Errorf("Decoding compact fields produced %+v, expected %+v", decoded, fields)
+	}
+
+	empty := ""
+	fields = &compactFieldsEncoder{nullable: &empty, ints: []int32{}}
+	testEncodable(t, "empty compact fields", fields, emptyCompactFields)
+
+	decoded = new(compactFieldsEncoder)
+	testDecodable(t, "empty compact fields", decoded, emptyCompactFields)
+	if decoded.nullable == nil || *decoded.nullable != "" {
+		t.Error("Decoding produced a null string where there was an empty one")
+	}
+	if decoded.bytes != nil || decoded.tagged != nil {
+		t.Error("Decoding produced values where there were none")
+	}
+}
+
+func TestCompactFieldsDecodingInsufficientData(t *testing.T) {
+	for i := 1; i < len(compactFields); i++ {
+		err := Decode(compactFields[:i], new(compactFieldsEncoder))
+		if err != ErrInsufficientData {
+			t.Errorf("Expected ErrInsufficientData when decoding %d bytes, got %v", i, err)
+		}
+	}
+}
+
+func TestCompactFieldsDecodingTagOrder(t *testing.T) {
+	for _, tagged := range [][]byte{
+		{0x02, 0x01, 0x00, 0x01, 0x00}, // tag 1 twice
+		{0x02, 0x02, 0x00, 0x01, 0x00}, // tag 2 before tag 1
+	} {
+		err := Decode(append(emptyCompactFields[:4:4], tagged...), new(compactFieldsEncoder))
+		if _, ok := err.(PacketDecodingError); !ok {
+			t.Errorf("Expected a PacketDecodingError for tagged fields % x, got %v", tagged, err)
+		}
+	}
+}
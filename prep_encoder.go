@@ -1,6 +1,7 @@
 package sarama
 
 import (
+	"encoding/binary"
 	"fmt"
 	"math"
 )
@@ -15,6 +16,10 @@ func (pe *prepEncoder) putInt8(in int8) {
 	pe.length += 1
 }
 
+func (pe *prepEncoder) putBool(in bool) {
+	pe.length += 1
+}
+
 func (pe *prepEncoder) putInt16(in int16) {
 	pe.length += 2
 }
@@ -27,6 +32,16 @@ func (pe *prepEncoder) putInt64(in int64) {
 	pe.length += 8
 }
 
+func (pe *prepEncoder) putVarint(in int64) {
+	var buf [binary.MaxVarintLen64]byte
+	pe.length += binary.PutVarint(buf[:], in)
+}
+
+func (pe *prepEncoder) putUVarint(in uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	pe.length += binary.PutUvarint(buf[:], in)
+}
+
 func (pe *prepEncoder) putArrayLength(in int) error {
 	if in > math.MaxInt32 {
 		return PacketEncodingError{fmt.Sprintf("array too long (%d)", in)}
@@ -35,6 +50,11 @@ func (pe *prepEncoder) putArrayLength(in int) error {
 	return nil
 }
 
+func (pe *prepEncoder) putCompactArrayLength(in int) {
+	// compact lengths are stored as length+1, so that zero can mean null
+	pe.putUVarint(uint64(in + 1))
+}
+
 // arrays
 
 func (pe *prepEncoder) putBytes(in []byte) error {
@@ -49,6 +69,26 @@ func (pe *prepEncoder) putBytes(in []byte) error {
 	return nil
 }
 
+func (pe *prepEncoder) putVarintBytes(in []byte) error {
+	if in == nil {
+		pe.putVarint(-1)
+		return nil
+	}
+	pe.putVarint(int64(len(in)))
+	pe.length += len(in)
+	return nil
+}
+
+func (pe *prepEncoder) putCompactBytes(in []byte) error {
+	if in == nil {
+		pe.putCompactArrayLength(-1)
+		return nil
+	}
+	pe.putCompactArrayLength(len(in))
+	pe.length += len(in)
+	return nil
+}
+
 func (pe *prepEncoder) putRawBytes(in []byte) error {
 	if len(in) > math.MaxInt32 {
 		return PacketEncodingError{fmt.Sprintf("byteslice too long (%d)", len(in))}
@@ -66,6 +106,43 @@ func (pe *prepEncoder) putString(in string) error {
 	return nil
 }
 
+func (pe *prepEncoder) putNullableString(in *string) error {
+	if in == nil {
+		pe.length += 2
+		return nil
+	}
+	return pe.putString(*in)
+}
+
+func (pe *prepEncoder) putCompactString(in string) error {
+	pe.putCompactArrayLength(len(in))
+	pe.length += len(in)
+	return nil
+}
+
+func (pe *prepEncoder) putNullableCompactString(in *string) error {
+	if in == nil {
+		pe.putCompactArrayLength(-1)
+		return nil
+	}
+	return pe.putCompactString(*in)
+}
+
+func (pe *prepEncoder) putStringArray(in []string) error {
+	err := pe.putArrayLength(len(in))
+	if err != nil {
+		return err
+	}
+
+	for _, str := range in {
+		if err := pe.putString(str); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (pe *prepEncoder) putInt32Array(in []int32) error {
 	err := pe.putArrayLength(len(in))
 	if err != nil {
@@ -84,6 +161,25 @@ func (pe *prepEncoder) putInt64Array(in []int64) error {
 	return nil
 }
 
+func (pe *prepEncoder) putCompactInt32Array(in []int32) error {
+	if in == nil {
+		return PacketEncodingError{"expected non-null array"}
+	}
+	pe.putCompactArrayLength(len(in))
+	pe.length += 4 * len(in)
+	return nil
+}
+
+func (pe *prepEncoder) putTaggedFields(in map[uint64][]byte) error {
+	pe.putUVarint(uint64(len(in)))
+	for tag, field := range in {
+		pe.putUVarint(tag)
+		pe.putUVarint(uint64(len(field)))
+		pe.length += len(field)
+	}
+	return nil
+}
+
 // stackable
 
 func (pe *prepEncoder) push(in pushEncoder) {
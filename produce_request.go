@@ -17,14 +17,65 @@ const (
 )
 
 type ProduceRequest struct {
-	RequiredAcks RequiredAcks
-	Timeout      int32
-	MsgSets      map[string]map[int32]*MessageSet
+	TransactionalID *string // v3 or later
+	RequiredAcks    RequiredAcks
+	Timeout         int32
+
+	// Version can be:
+	// - 0 (kafka 0.8 and later)
+	// - 1 (kafka 0.9 and later)
+	// - 2 (kafka 0.10 and later, carrying version 1 messages)
+	// - 3 (kafka 0.11 and later, carrying RecordBatches instead of MessageSets)
+	// - 4 and 5 (kafka 1.0 and later), 6 (kafka 2.0 and later) and 7 (kafka 2.1
+	//   and later, required for ZSTD compressed batches), all laid out as 3
+	IVersion      int16
+	MsgSets       map[string]map[int32]*MessageSet  // v0 to v2
+	RecordBatches map[string]map[int32]*RecordBatch // v3 or later
 }
 
 func (p *ProduceRequest) Encode(pe packetEncoder) error {
+	if p.IVersion < 0 || p.IVersion > 7 {
+		return PacketEncodingError{"invalid or unsupported ProduceRequest version field"}
+	}
+
+	if p.IVersion >= 3 {
+		if err := pe.putNullableString(p.TransactionalID); err != nil {
+			return err
+		}
+	}
 	pe.putInt16(int16(p.RequiredAcks))
 	pe.putInt32(p.Timeout)
+
+	if p.IVersion >= 3 {
+		err := pe.putArrayLength(len(p.RecordBatches))
+		if err != nil {
+			return err
+		}
+		for topic, partitions := range p.RecordBatches {
+			err = pe.putString(topic)
+			if err != nil {
+				return err
+			}
+			err = pe.putArrayLength(len(partitions))
+			if err != nil {
+				return err
+			}
+			for id, batch := range partitions {
+				pe.putInt32(id)
+				pe.push(&lengthField{})
+				err = batch.Encode(pe)
+				if err != nil {
+					return err
+				}
+				err = pe.pop()
+				if err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
 	err := pe.putArrayLength(len(p.MsgSets))
 	if err != nil {
 		return err
@@ -55,6 +106,14 @@ func (p *ProduceRequest) Encode(pe packetEncoder) error {
 }
 
 func (p *ProduceRequest) Decode(pd packetDecoder) error {
+	if p.IVersion >= 3 {
+		transactionalID, err := pd.getNullableString()
+		if err != nil {
+			return err
+		}
+		p.TransactionalID = transactionalID
+	}
+
 	requiredAcks, err := pd.getInt16()
 	if err != nil {
 		return err
@@ -70,6 +129,9 @@ func (p *ProduceRequest) Decode(pd packetDecoder) error {
 	if topicCount == 0 {
 		return nil
 	}
+	if p.IVersion >= 3 {
+		return p.decodeRecordBatches(pd, topicCount)
+	}
 	p.MsgSets = make(map[string]map[int32]*MessageSet)
 	for i := 0; i < topicCount; i++ {
 		topic, err := pd.getString()
@@ -105,12 +167,51 @@ func (p *ProduceRequest) Decode(pd packetDecoder) error {
 	return nil
 }
 
+func (p *ProduceRequest) decodeRecordBatches(pd packetDecoder, topicCount int) error {
+	p.RecordBatches = make(map[string]map[int32]*RecordBatch)
+	for i := 0; i < topicCount; i++ {
+		topic, err := pd.getString()
+		if err != nil {
+			return err
+		}
+		partitionCount, err := pd.getArrayLength()
+		if err != nil {
+			return err
+		}
+		p.RecordBatches[topic] = make(map[int32]*RecordBatch)
+		for j := 0; j < partitionCount; j++ {
+			partition, err := pd.getInt32()
+			if err != nil {
+				return err
+			}
+			batchSize, err := pd.getInt32()
+			if err != nil {
+				return err
+			}
+			batchDecoder, err := pd.getSubset(int(batchSize))
+			if err != nil {
+				return err
+			}
+			batch := &RecordBatch{}
+			if err = batch.Decode(batchDecoder); err != nil {
+				return err
+			}
+			p.RecordBatches[topic][partition] = batch
+		}
+	}
+	return nil
+}
+
 func (p *ProduceRequest) Key() int16 {
 	return 0
 }
 
 func (p *ProduceRequest) Version() int16 {
-	return 0
+	return p.IVersion
+}
+
+func (p *ProduceRequest) String() string {
+	return dump(p)
 }
 
 func (p *ProduceRequest) AddMessage(topic string, partition int32, msg *Message) {
@@ -143,3 +244,15 @@ func (p *ProduceRequest) AddSet(topic string, partition int32, set *MessageSet)
 
 	p.MsgSets[topic][partition] = set
 }
+
+func (p *ProduceRequest) AddBatch(topic string, partition int32, batch *RecordBatch) {
+	if p.RecordBatches == nil {
+		p.RecordBatches = make(map[string]map[int32]*RecordBatch)
+	}
+
+	if p.RecordBatches[topic] == nil {
+		p.RecordBatches[topic] = make(map[int32]*RecordBatch)
+	}
+
+	p.RecordBatches[topic][partition] = batch
+}
@@ -2,6 +2,7 @@ package sarama
 
 import (
 	"testing"
+	"time"
 )
 
 var (
@@ -45,3 +46,22 @@ func TestProduceRequest(t *testing.T) {
 	request.AddMessage("topic", 0xAD, &Message{Codec: CompressionNone, Key: nil, Value: []byte{0x00, 0xEE}})
 	testRequest(t, "one message", request, produceRequestOneMessage)
 }
+
+func TestProduceRequestV3(t *testing.T) {
+	request := &ProduceRequest{IVersion: 3, RequiredAcks: 0x123, Timeout: 0x444}
+	batch := &RecordBatch{ProducerID: -1, ProducerEpoch: -1, FirstSequence: -1}
+	batch.AddRecord(0, time.Unix(1479847795, 0), nil, []byte("hello"), []RecordHeader{{Key: []byte("h"), Value: []byte("v")}})
+	request.AddBatch("topic", 0xAD, batch)
+
+	expected := []byte{
+		0xFF, 0xFF, // no transactional id
+		0x01, 0x23,
+		0x00, 0x00, 0x04, 0x44,
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x05, 't', 'o', 'p', 'i', 'c',
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x00, 0x00, 0xAD,
+		0x00, 0x00, 0x00, byte(len(oneRecordBatch))}
+	expected = append(expected, oneRecordBatch...)
+	testRequest(t, "one record batch", request, expected)
+}
@@ -1,11 +1,17 @@
 package sarama
 
+import "time"
+
 type ProduceResponseBlock struct {
 	Err    KError
 	Offset int64
+	// only provided if Version >= 2 and the broker is configured with `LogAppendTime`
+	Timestamp time.Time
+	// only provided if Version >= 5
+	LogStartOffset int64
 }
 
-func (pr *ProduceResponseBlock) Decode(pd packetDecoder) (err error) {
+func (pr *ProduceResponseBlock) Decode(pd packetDecoder, version int16) (err error) {
 	tmp, err := pd.getInt16()
 	if err != nil {
 		return err
@@ -17,11 +23,45 @@ func (pr *ProduceResponseBlock) Decode(pd packetDecoder) (err error) {
 		return err
 	}
 
+	if version >= 2 {
+		millis, err := pd.getInt64()
+		if err != nil {
+			return err
+		}
+		pr.Timestamp = timeFromMilliseconds(millis)
+	}
+
+	if version >= 5 {
+		pr.LogStartOffset, err = pd.getInt64()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (pr *ProduceResponseBlock) Encode(pe packetEncoder, version int16) error {
+	pe.putInt16(int16(pr.Err))
+	pe.putInt64(pr.Offset)
+
+	if version >= 2 {
+		pe.putInt64(millisecondsSinceEpoch(pr.Timestamp))
+	}
+
+	if version >= 5 {
+		pe.putInt64(pr.LogStartOffset)
+	}
+
 	return nil
 }
 
 type ProduceResponse struct {
-	Blocks map[string]map[int32]*ProduceResponseBlock
+	Blocks       map[string]map[int32]*ProduceResponseBlock
+	ThrottleTime time.Duration // only provided if Version >= 1
+
+	// Version must be set to that of the request before decoding
+	IVersion int16
 }
 
 func (pr *ProduceResponse) Decode(pd packetDecoder) (err error) {
@@ -51,7 +91,7 @@ func (pr *ProduceResponse) Decode(pd packetDecoder) (err error) {
 			}
 
 			block := new(ProduceResponseBlock)
-			err = block.Decode(pd)
+			err = block.Decode(pd, pr.IVersion)
 			if err != nil {
 				return err
 			}
@@ -59,6 +99,14 @@ func (pr *ProduceResponse) Decode(pd packetDecoder) (err error) {
 		}
 	}
 
+	if pr.IVersion >= 1 {
+		millis, err := pd.getInt32()
+		if err != nil {
+			return err
+		}
+		pr.ThrottleTime = time.Duration(millis) * time.Millisecond
+	}
+
 	return nil
 }
 
@@ -78,13 +126,21 @@ func (pr *ProduceResponse) Encode(pe packetEncoder) error {
 		}
 		for id, prb := range partitions {
 			pe.putInt32(id)
-			pe.putInt16(int16(prb.Err))
-			pe.putInt64(prb.Offset)
+			if err = prb.Encode(pe, pr.IVersion); err != nil {
+				return err
+			}
 		}
 	}
+	if pr.IVersion >= 1 {
+		pe.putInt32(int32(pr.ThrottleTime / time.Millisecond))
+	}
 	return nil
 }
 
+func (pr *ProduceResponse) String() string {
+	return dump(pr)
+}
+
 func (pr *ProduceResponse) GetBlock(topic string, partition int32) *ProduceResponseBlock {
 	if pr.Blocks == nil {
 		return nil
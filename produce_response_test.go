@@ -1,6 +1,9 @@
 package sarama
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 var (
 	produceResponseNoBlocks = []byte{
@@ -22,6 +25,33 @@ var (
 		0x00, 0x00, 0x00, 0x02,
 		0x00, 0x02,
 		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+
+	produceResponseV2 = []byte{
+		0x00, 0x00, 0x00, 0x01,
+
+		0x00, 0x03, 'f', 'o', 'o',
+		0x00, 0x00, 0x00, 0x01,
+
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xFF,
+		0x00, 0x00, 0x01, 0x58, 0x8d, 0xcd, 0x59, 0x38, // timestamp
+
+		0x00, 0x00, 0x00, 0x64} // throttle time
+
+	produceResponseV5 = []byte{
+		0x00, 0x00, 0x00, 0x01,
+
+		0x00, 0x03, 'f', 'o', 'o',
+		0x00, 0x00, 0x00, 0x01,
+
+		0x00, 0x00, 0x00, 0x01,
+		0x00, 0x00,
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0xFF,
+		0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, // timestamp
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, // log start offset
+
+		0x00, 0x00, 0x00, 0x00} // throttle time
 )
 
 func TestProduceResponse(t *testing.T) {
@@ -65,3 +95,42 @@ func TestProduceResponse(t *testing.T) {
 		}
 	}
 }
+
+func TestProduceResponseV2(t *testing.T) {
+	response := ProduceResponse{IVersion: 2}
+
+	testDecodable(t, "v2", &response, produceResponseV2)
+	if response.ThrottleTime != 100*time.Millisecond {
+		t.Error("Decoding failed for ThrottleTime, got:", response.ThrottleTime)
+	}
+	block := response.GetBlock("foo", 1)
+	if block == nil {
+		t.Fatal("Decoding did not produce a block for foo/1")
+	}
+	if block.Offset != 0xFF {
+		t.Error("Decoding failed for foo/1/Offset, got:", block.Offset)
+	}
+	if !block.Timestamp.Equal(time.Unix(1479847795, 0)) {
+		t.Error("Decoding failed for foo/1/Timestamp, got:", block.Timestamp)
+	}
+
+	testEncodable(t, "v2", &response, produceResponseV2)
+}
+
+func TestProduceResponseV5(t *testing.T) {
+	response := ProduceResponse{IVersion: 5}
+
+	testDecodable(t, "v5", &response, produceResponseV5)
+	block := response.GetBlock("foo", 1)
+	if block == nil {
+		t.Fatal("Decoding did not produce a block for foo/1")
+	}
+	if block.LogStartOffset != 0x10 {
+		t.Error("Decoding failed for foo/1/LogStartOffset, got:", block.LogStartOffset)
+	}
+	if !block.Timestamp.IsZero() {
+		t.Error("Decoding produced a timestamp where there was none, got:", block.Timestamp)
+	}
+
+	testEncodable(t, "v5", &response, produceResponseV5)
+}
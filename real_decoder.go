@@ -23,6 +23,14 @@ func (rd *realDecoder) getInt8() (int8, error) {
 	return tmp, nil
 }
 
+func (rd *realDecoder) getBool() (bool, error) {
+	b, err := rd.getInt8()
+	if err != nil {
+		return false, err
+	}
+	return b != 0, nil
+}
+
 func (rd *realDecoder) getInt16() (int16, error) {
 	if rd.remaining() < 2 {
 		rd.off = len(rd.raw)
@@ -53,6 +61,34 @@ func (rd *realDecoder) getInt64() (int64, error) {
 	return tmp, nil
 }
 
+func (rd *realDecoder) getVarint() (int64, error) {
+	tmp, n := binary.Varint(rd.raw[rd.off:])
+	if n == 0 {
+		rd.off = len(rd.raw)
+		return -1, ErrInsufficientData
+	}
+	if n < 0 {
+		rd.off -= n
+		return -1, PacketDecodingError{"invalid varint"}
+	}
+	rd.off += n
+	return tmp, nil
+}
+
+func (rd *realDecoder) getUVarint() (uint64, error) {
+	tmp, n := binary.Uvarint(rd.raw[rd.off:])
+	if n == 0 {
+		rd.off = len(rd.raw)
+		return 0, ErrInsufficientData
+	}
+	if n < 0 {
+		rd.off -= n
+		return 0, PacketDecodingError{"invalid uvarint"}
+	}
+	rd.off += n
+	return tmp, nil
+}
+
 func (rd *realDecoder) getArrayLength() (int, error) {
 	if rd.remaining() < 4 {
 		rd.off = len(rd.raw)
@@ -69,6 +105,22 @@ func (rd *realDecoder) getArrayLength() (int, error) {
 	return tmp, nil
 }
 
+// getCompactArrayLength returns -1 for a null array
+func (rd *realDecoder) getCompactArrayLength() (int, error) {
+	tmp, err := rd.getUVarint()
+	if err != nil {
+		return -1, err
+	}
+	n := int(tmp) - 1
+	if tmp > math.MaxInt32 {
+		return -1, PacketDecodingError{"invalid array length"}
+	} else if n > rd.remaining() {
+		rd.off = len(rd.raw)
+		return -1, ErrInsufficientData
+	}
+	return n, nil
+}
+
 // collections
 
 func (rd *realDecoder) getBytes() ([]byte, error) {
@@ -97,6 +149,50 @@ func (rd *realDecoder) getBytes() ([]byte, error) {
 	return tmpStr, nil
 }
 
+func (rd *realDecoder) getVarintBytes() ([]byte, error) {
+	tmp, err := rd.getVarint()
+	if err != nil {
+		return nil, err
+	}
+
+	n := int(tmp)
+
+	switch {
+	case n < -1:
+		return nil, PacketDecodingError{"invalid byteslice length"}
+	case n == -1:
+		return nil, nil
+	case n == 0:
+		return make([]byte, 0), nil
+	}
+
+	return rd.getRawBytes(n)
+}
+
+func (rd *realDecoder) getCompactBytes() ([]byte, error) {
+	n, err := rd.getCompactArrayLength()
+	if err != nil || n == -1 {
+		return nil, err
+	}
+	if n == 0 {
+		return make([]byte, 0), nil
+	}
+	return rd.getRawBytes(n)
+}
+
+func (rd *realDecoder) getRawBytes(length int) ([]byte, error) {
+	if length < 0 {
+		return nil, PacketDecodingError{"invalid byteslice length"}
+	} else if length > rd.remaining() {
+		rd.off = len(rd.raw)
+		return nil, ErrInsufficientData
+	}
+
+	start := rd.off
+	rd.off += length
+	return rd.raw[start:rd.off], nil
+}
+
 func (rd *realDecoder) getString() (string, error) {
 	tmp, err := rd.getInt16()
 
@@ -123,6 +219,67 @@ func (rd *realDecoder) getString() (string, error) {
 	return tmpStr, nil
 }
 
+func (rd *realDecoder) getNullableString() (*string, error) {
+	tmp, err := rd.getInt16()
+	if err != nil || tmp == -1 {
+		return nil, err
+	}
+	rd.off -= 2
+
+	str, err := rd.getString()
+	return &str, err
+}
+
+func (rd *realDecoder) getCompactString() (string, error) {
+	n, err := rd.getCompactArrayLength()
+	if err != nil || n <= 0 {
+		return "", err
+	}
+	tmp, err := rd.getRawBytes(n)
+	return string(tmp), err
+}
+
+func (rd *realDecoder) getNullableCompactString() (*string, error) {
+	n, err := rd.getCompactArrayLength()
+	if err != nil || n == -1 {
+		return nil, err
+	}
+	tmp, err := rd.getRawBytes(n)
+	if err != nil {
+		return nil, err
+	}
+	str := string(tmp)
+	return &str, nil
+}
+
+func (rd *realDecoder) getStringArray() ([]string, error) {
+	if rd.remaining() < 4 {
+		rd.off = len(rd.raw)
+		return nil, ErrInsufficientData
+	}
+	n := int(binary.BigEndian.Uint32(rd.raw[rd.off:]))
+	rd.off += 4
+
+	if n == 0 {
+		return nil, nil
+	}
+
+	if n < 0 {
+		return nil, PacketDecodingError{"invalid array length"}
+	}
+
+	ret := make([]string, n)
+	for i := range ret {
+		str, err := rd.getString()
+		if err != nil {
+			return nil, err
+		}
+
+		ret[i] = str
+	}
+	return ret, nil
+}
+
 func (rd *realDecoder) getInt32Array() ([]int32, error) {
 	if rd.remaining() < 4 {
 		rd.off = len(rd.raw)
@@ -181,6 +338,62 @@ func (rd *realDecoder) getInt64Array() ([]int64, error) {
 	return ret, nil
 }
 
+func (rd *realDecoder) getCompactInt32Array() ([]int32, error) {
+	n, err := rd.getCompactArrayLength()
+	if err != nil || n <= 0 {
+		return nil, err
+	}
+
+	if rd.remaining() < 4*n {
+		rd.off = len(rd.raw)
+		return nil, ErrInsufficientData
+	}
+
+	ret := make([]int32, n)
+	for i := range ret {
+		ret[i] = int32(binary.BigEndian.Uint32(rd.raw[rd.off:]))
+		rd.off += 4
+	}
+	return ret, nil
+}
+
+func (rd *realDecoder) getTaggedFields() (map[uint64][]byte, error) {
+	n, err := rd.getUVarint()
+	if err != nil || n == 0 {
+		return nil, err
+	}
+	if n > uint64(rd.remaining()) {
+		rd.off = len(rd.raw)
+		return nil, ErrInsufficientData
+	}
+
+	fields := make(map[uint64][]byte, n)
+	var previous uint64
+	for i := uint64(0); i < n; i++ {
+		tag, err := rd.getUVarint()
+		if err != nil {
+			return nil, err
+		}
+		// tags must be strictly increasing, which also rules out duplicates
+		if i > 0 && tag <= previous {
+			return nil, PacketDecodingError{"invalid tagged field order"}
+		}
+		previous = tag
+		size, err := rd.getUVarint()
+		if err != nil {
+			return nil, err
+		}
+		if size > uint64(rd.remaining()) {
+			rd.off = len(rd.raw)
+			return nil, ErrInsufficientData
+		}
+		if fields[tag], err = rd.getRawBytes(int(size)); err != nil {
+			return nil, err
+		}
+	}
+	return fields, nil
+}
+
 // subsets
 
 func (rd *realDecoder) remaining() int {
@@ -200,6 +413,13 @@ func (rd *realDecoder) getSubset(length int) (packetDecoder, error) {
 	return &realDecoder{raw: rd.raw[start:rd.off]}, nil
 }
 
+func (rd *realDecoder) peekInt8(offset int) (int8, error) {
+	if rd.remaining() < offset+1 {
+		return -1, ErrInsufficientData
+	}
+	return int8(rd.raw[rd.off+offset]), nil
+}
+
 // stacks
 
 func (rd *realDecoder) push(in pushDecoder) error {
@@ -1,6 +1,9 @@
 package sarama
 
-import "encoding/binary"
+import (
+	"encoding/binary"
+	"sort"
+)
 
 type realEncoder struct {
 	raw   []byte
@@ -15,6 +18,14 @@ func (re *realEncoder) putInt8(in int8) {
 	re.off += 1
 }
 
+func (re *realEncoder) putBool(in bool) {
+	if in {
+		re.putInt8(1)
+	} else {
+		re.putInt8(0)
+	}
+}
+
 func (re *realEncoder) putInt16(in int16) {
 	binary.BigEndian.PutUint16(re.raw[re.off:], uint16(in))
 	re.off += 2
@@ -30,11 +41,24 @@ func (re *realEncoder) putInt64(in int64) {
 	re.off += 8
 }
 
+func (re *realEncoder) putVarint(in int64) {
+	re.off += binary.PutVarint(re.raw[re.off:], in)
+}
+
+func (re *realEncoder) putUVarint(in uint64) {
+	re.off += binary.PutUvarint(re.raw[re.off:], in)
+}
+
 func (re *realEncoder) putArrayLength(in int) error {
 	re.putInt32(int32(in))
 	return nil
 }
 
+func (re *realEncoder) putCompactArrayLength(in int) {
+	// compact lengths are stored as length+1, so that zero can mean null
+	re.putUVarint(uint64(in + 1))
+}
+
 // collection
 
 func (re *realEncoder) putRawBytes(in []byte) error {
@@ -54,6 +78,26 @@ func (re *realEncoder) putBytes(in []byte) error {
 	return nil
 }
 
+func (re *realEncoder) putVarintBytes(in []byte) error {
+	if in == nil {
+		re.putVarint(-1)
+		return nil
+	}
+	re.putVarint(int64(len(in)))
+	copy(re.raw[re.off:], in)
+	re.off += len(in)
+	return nil
+}
+
+func (re *realEncoder) putCompactBytes(in []byte) error {
+	if in == nil {
+		re.putCompactArrayLength(-1)
+		return nil
+	}
+	re.putCompactArrayLength(len(in))
+	return re.putRawBytes(in)
+}
+
 func (re *realEncoder) putString(in string) error {
 	re.putInt16(int16(len(in)))
 	copy(re.raw[re.off:], in)
@@ -61,6 +105,44 @@ func (re *realEncoder) putString(in string) error {
 	return nil
 }
 
+func (re *realEncoder) putNullableString(in *string) error {
+	if in == nil {
+		re.putInt16(-1)
+		return nil
+	}
+	return re.putString(*in)
+}
+
+func (re *realEncoder) putCompactString(in string) error {
+	re.putCompactArrayLength(len(in))
+	copy(re.raw[re.off:], in)
+	re.off += len(in)
+	return nil
+}
+
+func (re *realEncoder) putNullableCompactString(in *string) error {
+	if in == nil {
+		re.putCompactArrayLength(-1)
+		return nil
+	}
+	return re.putCompactString(*in)
+}
+
+func (re *realEncoder) putStringArray(in []string) error {
+	err := re.putArrayLength(len(in))
+	if err != nil {
+		return err
+	}
+
+	for _, val := range in {
+		if err := re.putString(val); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (re *realEncoder) putInt32Array(in []int32) error {
 	err := re.putArrayLength(len(in))
 	if err != nil {
@@ -83,6 +165,35 @@ func (re *realEncoder) putInt64Array(in []int64) error {
 	return nil
 }
 
+func (re *realEncoder) putCompactInt32Array(in []int32) error {
+	if in == nil {
+		return PacketEncodingError{"expected non-null array"}
+	}
+	re.putCompactArrayLength(len(in))
+	for _, val := range in {
+		re.putInt32(val)
+	}
+	return nil
+}
+
+func (re *realEncoder) putTaggedFields(in map[uint64][]byte) error {
+	tags := make([]uint64, 0, len(in))
+	for tag := range in {
+		tags = append(tags, tag)
+	}
+	sort.Sort(uint64Slice(tags))
+
+	re.putUVarint(uint64(len(tags)))
+	for _, tag := range tags {
+		re.putUVarint(tag)
+		re.putUVarint(uint64(len(in[tag])))
+		if err := re.putRawBytes(in[tag]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // stacks
 
 func (re *realEncoder) push(in pushEncoder) {
@@ -0,0 +1,115 @@
+package sarama
+
+import "time"
+
+// RecordHeader stores a key and value for a record header, a piece of metadata
+// attached to a record (requires Kafka 0.11).
+type RecordHeader struct {
+	Key   []byte
+	Value []byte
+}
+
+func (h *RecordHeader) encode(pe packetEncoder) error {
+	if err := pe.putVarintBytes(h.Key); err != nil {
+		return err
+	}
+	return pe.putVarintBytes(h.Value)
+}
+
+func (h *RecordHeader) decode(pd packetDecoder) (err error) {
+	if h.Key, err = pd.getVarintBytes(); err != nil {
+		return err
+	}
+	h.Value, err = pd.getVarintBytes()
+	return err
+}
+
+// Record is a single message in a RecordBatch. Its offset and timestamp are stored as deltas from
+// the first offset and timestamp of the batch.
+type Record struct {
+	Attributes     int8
+	TimestampDelta time.Duration
+	OffsetDelta    int64
+	Key            []byte
+	Value          []byte
+	Headers        []RecordHeader
+}
+
+func (r *Record) Encode(pe packetEncoder) error {
+	// the record is prefixed by its length as a varint, so we need to know it up front
+	var prep prepEncoder
+	if err := r.encodeBody(&prep); err != nil {
+		return err
+	}
+	pe.putVarint(int64(prep.length))
+	return r.encodeBody(pe)
+}
+
+func (r *Record) encodeBody(pe packetEncoder) error {
+	pe.putInt8(r.Attributes)
+	pe.putVarint(int64(r.TimestampDelta / time.Millisecond))
+	pe.putVarint(r.OffsetDelta)
+	if err := pe.putVarintBytes(r.Key); err != nil {
+		return err
+	}
+	if err := pe.putVarintBytes(r.Value); err != nil {
+		return err
+	}
+	pe.putVarint(int64(len(r.Headers)))
+	for i := range r.Headers {
+		if err := r.Headers[i].encode(pe); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Record) Decode(pd packetDecoder) (err error) {
+	length, err := pd.getVarint()
+	if err != nil {
+		return err
+	}
+	if pd, err = pd.getSubset(int(length)); err != nil {
+		return err
+	}
+
+	if r.Attributes, err = pd.getInt8(); err != nil {
+		return err
+	}
+	timestampDelta, err := pd.getVarint()
+	if err != nil {
+		return err
+	}
+	r.TimestampDelta = time.Duration(timestampDelta) * time.Millisecond
+	if r.OffsetDelta, err = pd.getVarint(); err != nil {
+		return err
+	}
+	if r.Key, err = pd.getVarintBytes(); err != nil {
+		return err
+	}
+	if r.Value, err = pd.getVarintBytes(); err != nil {
+		return err
+	}
+
+	numHeaders, err := pd.getVarint()
+	if err != nil {
+		return err
+	}
+	if numHeaders < 0 || int(numHeaders) > pd.remaining() {
+		return PacketDecodingError{"invalid header count"}
+	}
+	r.Headers = nil
+	if numHeaders > 0 {
+		r.Headers = make([]RecordHeader, numHeaders)
+	}
+	for i := range r.Headers {
+		if err = r.Headers[i].decode(pd); err != nil {
+			return err
+		}
+	}
+
+	if pd.remaining() != 0 {
+		return PacketDecodingError{"invalid record length"}
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package sarama
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// the codec occupies three bits of the attributes of a record batch, to make room for LZ4 and later
+	recordBatchCodecMask     int16 = 0x07
+	recordBatchTimestampMask int16 = 0x08
+	isTransactionalMask      int16 = 0x10
+	controlMask              int16 = 0x20
+)
+
+// RecordBatch is the format in which messages are stored and transmitted since Kafka 0.11
+// (message format version 2, superseding MessageSet). The producer id, epoch and sequence number
+// support idempotent and transactional producers; a batch not written by one of those leaves
+// them at -1.
+type RecordBatch struct {
+	FirstOffset          int64
+	PartitionLeaderEpoch int32
+	Codec                CompressionCodec
+	LogAppendTime        bool // whether the broker assigned MaxTimestamp rather than the producer
+	IsTransactional      bool
+	Control              bool // whether the batch holds control records (such as transaction markers)
+	LastOffsetDelta      int32
+	FirstTimestamp       time.Time
+	MaxTimestamp         time.Time
+	ProducerID           int64
+	ProducerEpoch        int16
+	FirstSequence        int32
+	Records              []*Record
+	CompressionLevel     int // the level to compress the records at, see Config.Producer.CompressionLevel
+
+	compressedRecords []byte
+}
+
+func (b *RecordBatch) Encode(pe packetEncoder) error {
+	pe.putInt64(b.FirstOffset)
+	pe.push(&lengthField{})
+	pe.putInt32(b.PartitionLeaderEpoch)
+	pe.putInt8(2) // the magic byte of message format version 2
+	pe.push(newCRC32Field(crcCastagnoli))
+
+	attributes := int16(b.Codec) & recordBatchCodecMask
+	if b.LogAppendTime {
+		attributes |= recordBatchTimestampMask
+	}
+	if b.IsTransactional {
+		attributes |= isTransactionalMask
+	}
+	if b.Control {
+		attributes |= controlMask
+	}
+	pe.putInt16(attributes)
+
+	pe.putInt32(b.LastOffsetDelta)
+	pe.putInt64(millisecondsSinceEpoch(b.FirstTimestamp))
+	pe.putInt64(millisecondsSinceEpoch(b.MaxTimestamp))
+	pe.putInt64(b.ProducerID)
+	pe.putInt16(b.ProducerEpoch)
+	pe.putInt32(b.FirstSequence)
+
+	if err := pe.putArrayLength(len(b.Records)); err != nil {
+		return err
+	}
+
+	// like Message, we compress during the first (length-calculating) pass and reuse the result
+	payload := b.compressedRecords
+	b.compressedRecords = nil
+	if payload == nil {
+		raw, err := b.encodeRecords()
+		if err != nil {
+			return err
+		}
+		if payload, err = compress(b.Codec, b.CompressionLevel, raw); err != nil {
+			return err
+		}
+		b.compressedRecords = payload
+	}
+	if err := pe.putRawBytes(payload); err != nil {
+		return err
+	}
+
+	if err := pe.pop(); err != nil {
+		return err
+	}
+	return pe.pop()
+}
+
+func (b *RecordBatch) encodeRecords() ([]byte, error) {
+	var prep prepEncoder
+	for _, r := range b.Records {
+		if err := r.Encode(&prep); err != nil {
+			return nil, err
+		}
+	}
+
+	realEnc := realEncoder{raw: make([]byte, prep.length)}
+	for _, r := range b.Records {
+		if err := r.Encode(&realEnc); err != nil {
+			return nil, err
+		}
+	}
+	return realEnc.raw, nil
+}
+
+func (b *RecordBatch) Decode(pd packetDecoder) (err error) {
+	if b.FirstOffset, err = pd.getInt64(); err != nil {
+		return err
+	}
+
+	batchLength, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	// as with message sets, the broker may return a partial batch at the end of a fetch
+	if pd, err = pd.getSubset(int(batchLength)); err != nil {
+		return err
+	}
+
+	if b.PartitionLeaderEpoch, err = pd.getInt32(); err != nil {
+		return err
+	}
+	magic, err := pd.getInt8()
+	if err != nil {
+		return err
+	}
+	if magic != 2 {
+		return PacketDecodingError{fmt.Sprintf("unexpected record batch magic byte (%d)", magic)}
+	}
+
+	if err = pd.push(newCRC32Field(crcCastagnoli)); err != nil {
+		return err
+	}
+
+	attributes, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	b.Codec = CompressionCodec(attributes & recordBatchCodecMask)
+	b.LogAppendTime = attributes&recordBatchTimestampMask != 0
+	b.IsTransactional = attributes&isTransactionalMask != 0
+	b.Control = attributes&controlMask != 0
+
+	if b.LastOffsetDelta, err = pd.getInt32(); err != nil {
+		return err
+	}
+	firstTimestamp, err := pd.getInt64()
+	if err != nil {
+		return err
+	}
+	b.FirstTimestamp = timeFromMilliseconds(firstTimestamp)
+	maxTimestamp, err := pd.getInt64()
+	if err != nil {
+		return err
+	}
+	b.MaxTimestamp = timeFromMilliseconds(maxTimestamp)
+	if b.ProducerID, err = pd.getInt64(); err != nil {
+		return err
+	}
+	if b.ProducerEpoch, err = pd.getInt16(); err != nil {
+		return err
+	}
+	if b.FirstSequence, err = pd.getInt32(); err != nil {
+		return err
+	}
+
+	numRecords, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	if numRecords < 0 {
+		return PacketDecodingError{"invalid record count"}
+	}
+
+	payload, err := pd.getRawBytes(pd.remaining())
+	if err != nil {
+		return err
+	}
+	if err = pd.pop(); err != nil {
+		return err
+	}
+
+	raw, err := decompress(b.Codec, payload)
+	if err != nil {
+		return err
+	}
+	// every record takes at least a byte, so a larger count can only come from a corrupt batch
+	if int(numRecords) > len(raw) {
+		return PacketDecodingError{"invalid record count"}
+	}
+
+	recordsDecoder := &realDecoder{raw: raw}
+	b.Records = make([]*Record, numRecords)
+	for i := range b.Records {
+		b.Records[i] = new(Record)
+		if err = b.Records[i].Decode(recordsDecoder); err != nil {
+			if err == ErrInsufficientData {
+				// the whole batch is here, so the records cannot be cut short
+				return PacketDecodingError{"invalid record batch"}
+			}
+			return err
+		}
+	}
+	if recordsDecoder.remaining() != 0 {
+		return PacketDecodingError{"invalid record batch"}
+	}
+
+	return nil
+}
+
+// AddRecord appends a record to the batch, computing its offset and timestamp deltas from the
+// first record.
+func (b *RecordBatch) AddRecord(offset int64, timestamp time.Time, key, value []byte, headers []RecordHeader) {
+	if len(b.Records) == 0 {
+		b.FirstOffset = offset
+		b.FirstTimestamp = timestamp
+	}
+	if timestamp.After(b.MaxTimestamp) {
+		b.MaxTimestamp = timestamp
+	}
+
+	delta := offset - b.FirstOffset
+	b.Records = append(b.Records, &Record{
+		OffsetDelta:    delta,
+		TimestampDelta: timestamp.Sub(b.FirstTimestamp),
+		Key:            key,
+		Value:          value,
+		Headers:        headers,
+	})
+	if int32(delta) > b.LastOffsetDelta {
+		b.LastOffsetDelta = int32(delta)
+	}
+}
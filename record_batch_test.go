@@ -0,0 +1,151 @@
+package sarama
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+var (
+	oneRecordBatch = []byte{
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, // first offset
+		0x00, 0x00, 0x00, 0x41, // length
+		0x00, 0x00, 0x00, 0x00, // partition leader epoch
+		0x02,                   // magic
+		0xc9, 0x0a, 0x3c, 0x74, // CRC32C
+		0x00, 0x00, // attributes
+		0x00, 0x00, 0x00, 0x00, // last offset delta
+		0x00, 0x00, 0x01, 0x58, 0x8d, 0xcd, 0x59, 0x38, // first timestamp
+		0x00, 0x00, 0x01, 0x58, 0x8d, 0xcd, 0x59, 0x38, // max timestamp
+		0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, // producer id
+		0xff, 0xff, // producer epoch
+		0xff, 0xff, 0xff, 0xff, // first sequence
+		0x00, 0x00, 0x00, 0x01, // number of records
+		// record
+		0x1e, // length
+		0x00, // attributes
+		0x00, // timestamp delta
+		0x00, // offset delta
+		0x01, // key (null)
+		0x0a, // value length
+		'h', 'e', 'l', 'l', 'o',
+		0x02,      // number of headers
+		0x02, 'h', // header key
+		0x02, 'v', // header value
+	}
+)
+
+func TestRecordBatchEncoding(t *testing.T) {
+	batch := &RecordBatch{ProducerID: -1, ProducerEpoch: -1, FirstSequence: -1}
+	batch.AddRecord(0, time.Unix(1479847795, 0), nil, []byte("hello"), []RecordHeader{{Key: []byte("h"), Value: []byte("v")}})
+	testEncodable(t, "one record", batch, oneRecordBatch)
+}
+
+func TestRecordBatchDecoding(t *testing.T) {
+	batch := new(RecordBatch)
+	testDecodable(t, "one record", batch, oneRecordBatch)
+
+	if batch.ProducerID != -1 || batch.Codec != CompressionNone || batch.Control {
+		t.Error("Decoding produced incorrect batch attributes")
+	}
+	if !batch.FirstTimestamp.Equal(time.Unix(1479847795, 0)) {
+		t.Error("Decoding produced incorrect first timestamp:", batch.FirstTimestamp)
+	}
+	if len(batch.Records) != 1 {
+		t.Fatal("Decoding produced", len(batch.Records), "records but expected 1")
+	}
+	record := batch.Records[0]
+	if record.Key != nil || !bytes.Equal(record.Value, []byte("hello")) {
+		t.Error("Decoding produced incorrect record key or value")
+	}
+	if len(record.Headers) != 1 || string(record.Headers[0].Key) != "h" || string(record.Headers[0].Value) != "v" {
+		t.Error("Decoding produced incorrect record headers:", record.Headers)
+	}
+}
+
+func TestRecordBatchCorruption(t *testing.T) {
+	corrupt := make([]byte, len(oneRecordBatch))
+	copy(corrupt, oneRecordBatch)
+	corrupt[len(corrupt)-1] = 'w'
+
+	if err := Decode(corrupt, new(RecordBatch)); err == nil {
+		t.Error("Expected the CRC mismatch to be detected")
+	}
+	if err := Decode(oneRecordBatch[:40], new(RecordBatch)); err != ErrInsufficientData {
+		t.Error("Expected ErrInsufficientData for a truncated batch, got", err)
+	}
+}
+
+func TestRecordBatchCompressedRoundTrip(t *testing.T) {
+	for _, codec := range []CompressionCodec{CompressionGZIP, CompressionSnappy, CompressionLZ4, CompressionZSTD} {
+		batch := &RecordBatch{Codec: codec, ProducerID: -1, ProducerEpoch: -1, FirstSequence: -1}
+		for i := 0; i < 10; i++ {
+			batch.AddRecord(int64(100+i), time.Unix(1479847795, int64(i)*int64(time.Millisecond)), []byte("key"), []byte("value"), nil)
+		}
+
+		buf, err := Encode(batch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded := new(RecordBatch)
+		if err = Decode(buf, decoded); err != nil {
+			t.Fatal(err)
+		}
+
+		if decoded.Codec != codec || decoded.FirstOffset != 100 || decoded.LastOffsetDelta != 9 {
+			t.Error("Decoding produced incorrect batch metadata for codec", codec)
+		}
+		if len(decoded.Records) != 10 {
+			t.Fatal("Decoding produced", len(decoded.Records), "records but expected 10")
+		}
+		if decoded.Records[9].OffsetDelta != 9 || decoded.Records[9].TimestampDelta != 9*time.Millisecond {
+			t.Error("Decoding produced incorrect deltas for codec", codec)
+		}
+	}
+}
+
+func TestRecordBatchCompressionLevel(t *testing.T) {
+	for _, codec := range []CompressionCodec{CompressionGZIP, CompressionZSTD} {
+		sizes := make(map[int]int)
+		for _, level := range []int{1, 9} {
+			batch := &RecordBatch{Codec: codec, CompressionLevel: level}
+			for i := 0; i < 100; i++ {
+				batch.AddRecord(int64(i), time.Unix(1479847795, 0), nil, []byte(fmt.Sprintf(`{"id":%d,"name":"value %d"}`, i, i*i)), nil)
+			}
+			buf, err := Encode(batch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = Decode(buf, new(RecordBatch)); err != nil {
+				t.Fatal(err)
+			}
+			sizes[level] = len(buf)
+		}
+		// zstd's faster levels can beat its slower ones on input this small, so only gzip is compared
+		if codec == CompressionGZIP && sizes[9] > sizes[1] {
+			t.Errorf("Expected level 9 to compress at least as well as level 1, got %v", sizes)
+		}
+	}
+}
+
+func TestRecordBatchGZIPLevelZero(t *testing.T) {
+	value := bytes.Repeat([]byte("compress me if you can "), 100)
+
+	stored, err := compress(CompressionGZIP, CompressionLevelZero, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// after the 10 byte gzip header, the deflate block type bits are 00 for a stored block
+	if len(stored) < 11 || stored[10]&0x06 != 0 || !bytes.Contains(stored, value) {
+		t.Error("Expected level 0 to store the data in uncompressed deflate blocks")
+	}
+
+	compressed, err := compress(CompressionGZIP, CompressionLevelDefault, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(compressed) >= len(value) {
+		t.Error("Expected the default level to compress the data, got", len(compressed), "bytes")
+	}
+}
@@ -51,7 +51,7 @@ func (r *Request) Decode(pd packetDecoder) (err error) {
 
 	r.Body = allocateBody(key, version)
 	if r.Body == nil {
-		return PacketDecodingError{fmt.Sprintf("unknown request key (%d)", key)}
+		return PacketDecodingError{fmt.Sprintf("unknown request key (%d) or version (%d)", key, version)}
 	}
 	return r.Body.Decode(pd)
 }
@@ -79,22 +79,290 @@ func DecodeRequest(r io.Reader) (req *Request, err error) {
 	return req, nil
 }
 
+// allocateBody returns an empty request with the given key and version, ready to be decoded, or nil
+// if Sarama does not know that request or version.
 func allocateBody(key, version int16) RequestBody {
+	if !knownRequestVersion(key, version) {
+		return nil
+	}
+
 	switch key {
 	case 0:
-		return &ProduceRequest{}
+		return &ProduceRequest{IVersion: version}
 	case 1:
-		return &FetchRequest{}
+		return &FetchRequest{IVersion: version}
 	case 2:
-		return &OffsetRequest{}
+		return &OffsetRequest{IVersion: version}
 	case 3:
-		return &MetadataRequest{}
+		return &MetadataRequest{IVersion: version}
 	case 8:
 		return &OffsetCommitRequest{IVersion: version}
 	case 9:
-		return &OffsetFetchRequest{}
+		return &OffsetFetchRequest{IVersion: version}
 	case 10:
-		return &ConsumerMetadataRequest{}
+		return &ConsumerMetadataRequest{IVersion: version}
+	case 11:
+		return &JoinGroupRequest{IVersion: version}
+	case 12:
+		return &HeartbeatRequest{IVersion: version}
+	case 13:
+		return &LeaveGroupRequest{IVersion: version}
+	case 14:
+		return &SyncGroupRequest{IVersion: version}
+	case 15:
+		return &DescribeGroupsRequest{IVersion: version}
+	case 16:
+		return &ListGroupsRequest{IVersion: version}
+	case 17:
+		return &SaslHandshakeRequest{IVersion: version}
+	case 18:
+		return &ApiVersionsRequest{}
+	case 19:
+		return &CreateTopicsRequest{IVersion: version}
+	case 20:
+		return &DeleteTopicsRequest{IVersion: version}
+	case 22:
+		return &InitProducerIDRequest{IVersion: version}
+	case 24:
+		return &AddPartitionsToTxnRequest{IVersion: version}
+	case 25:
+		return &AddOffsetsToTxnRequest{IVersion: version}
+	case 26:
+		return &EndTxnRequest{IVersion: version}
+	case 28:
+		return &TxnOffsetCommitRequest{IVersion: version}
+	case 32:
+		return &DescribeConfigsRequest{IVersion: version}
+	case 33:
+		return &AlterConfigsRequest{IVersion: version}
+	case 36:
+		return &SaslAuthenticateRequest{IVersion: version}
+	case 37:
+		return &CreatePartitionsRequest{IVersion: version}
+	case 44:
+		return &IncrementalAlterConfigsRequest{IVersion: version}
 	}
 	return nil
 }
+
+// knownRequestVersion reports whether Sarama knows how to encode and decode the given version of
+// the request with the given key and of its response, that is whether it is at most the version
+// brokers running the newest Kafka version Sarama knows would be sent.
+func knownRequestVersion(key, version int16) bool {
+	return version >= 0 && version <= maxRequestVersion(key, MaxVersion)
+}
+
+// maxRequestVersion returns the highest version of the request with the given key that Sarama
+// knows how to encode and that brokers running the given version of Kafka understand, or -1 if
+// those brokers do not know the request at all.
+func maxRequestVersion(key int16, kafkaVersion KafkaVersion) int16 {
+	switch key {
+	case 0:
+		// version 1 adds throttle time to the response, version 2 carries version 1 messages and
+		// version 3 record batches; version 5 adds the log start offset to the response and
+		// version 7 is the first to allow ZSTD
+		if kafkaVersion.IsAtLeast(V2_1_0_0) {
+			return 7
+		}
+		if kafkaVersion.IsAtLeast(V2_0_0_0) {
+			return 6
+		}
+		if kafkaVersion.IsAtLeast(V1_0_0_0) {
+			return 5
+		}
+		if kafkaVersion.IsAtLeast(V0_11_0_0) {
+			return 3
+		}
+		if kafkaVersion.IsAtLeast(V0_10_0_0) {
+			return 2
+		}
+		if kafkaVersion.IsAtLeast(V0_9_0_0) {
+			return 1
+		}
+		return 0
+	case 1:
+		// likewise, plus a response size limit in version 3
+		if kafkaVersion.IsAtLeast(V2_1_0_0) {
+			return 10
+		}
+		if kafkaVersion.IsAtLeast(V2_0_0_0) {
+			return 8
+		}
+		if kafkaVersion.IsAtLeast(V1_1_0_0) {
+			return 7
+		}
+		if kafkaVersion.IsAtLeast(V1_0_0_0) {
+			return 6
+		}
+		if kafkaVersion.IsAtLeast(V0_11_0_0) {
+			return 5
+		}
+		if kafkaVersion.IsAtLeast(V0_10_1_0) {
+			return 3
+		}
+		if kafkaVersion.IsAtLeast(V0_10_0_0) {
+			return 2
+		}
+		if kafkaVersion.IsAtLeast(V0_9_0_0) {
+			return 1
+		}
+		return 0
+	case 2:
+		// version 1 looks offsets up by message timestamp
+		if kafkaVersion.IsAtLeast(V0_10_1_0) {
+			return 1
+		}
+		return 0
+	case 3:
+		// version 1 adds the controller, racks and internal topics to the response
+		if kafkaVersion.IsAtLeast(V0_10_0_0) {
+			return 1
+		}
+		return 0
+	case 8:
+		if kafkaVersion.IsAtLeast(V0_9_0_0) {
+			return 2
+		}
+		return 1
+	case 9:
+		// version 2 adds an error for the whole group to the response and version 3 throttle time
+		if kafkaVersion.IsAtLeast(V0_11_0_0) {
+			return 3
+		}
+		if kafkaVersion.IsAtLeast(V0_10_2_0) {
+			return 2
+		}
+		return 1
+	case 10:
+		// version 1 can also look for transaction coordinators
+		if kafkaVersion.IsAtLeast(V0_11_0_0) {
+			return 1
+		}
+		return 0
+	case 11:
+		// version 1 adds a rebalance timeout and version 2 throttle time to the response
+		if kafkaVersion.IsAtLeast(V0_11_0_0) {
+			return 2
+		}
+		if kafkaVersion.IsAtLeast(V0_10_1_0) {
+			return 1
+		}
+		return 0
+	case 12, 13, 14:
+		// version 1 adds throttle time to the response
+		if kafkaVersion.IsAtLeast(V0_11_0_0) {
+			return 1
+		}
+		return 0
+	case 15, 16:
+		// version 1 adds throttle time to the response
+		if kafkaVersion.IsAtLeast(V2_0_0_0) {
+			return 2
+		}
+		if kafkaVersion.IsAtLeast(V0_11_0_0) {
+			return 1
+		}
+		if kafkaVersion.IsAtLeast(V0_9_0_0) {
+			return 0
+		}
+		return -1
+	case 17:
+		// version 1 moves the authentication bytes that follow into SaslAuthenticateRequests
+		if kafkaVersion.IsAtLeast(V1_0_0_0) {
+			return 1
+		}
+		if kafkaVersion.IsAtLeast(V0_10_0_0) {
+			return 0
+		}
+		return -1
+	case 19:
+		// version 1 adds ValidateOnly and version 2 throttle time to the response
+		if kafkaVersion.IsAtLeast(V2_0_0_0) {
+			return 3
+		}
+		if kafkaVersion.IsAtLeast(V1_0_0_0) {
+			return 2
+		}
+		if kafkaVersion.IsAtLeast(V0_11_0_0) {
+			return 1
+		}
+		if kafkaVersion.IsAtLeast(V0_10_1_0) {
+			return 0
+		}
+		return -1
+	case 20:
+		// version 1 adds throttle time to the response
+		if kafkaVersion.IsAtLeast(V2_1_0_0) {
+			return 3
+		}
+		if kafkaVersion.IsAtLeast(V2_0_0_0) {
+			return 2
+		}
+		if kafkaVersion.IsAtLeast(V0_11_0_0) {
+			return 1
+		}
+		if kafkaVersion.IsAtLeast(V0_10_1_0) {
+			return 0
+		}
+		return -1
+	case 22:
+		if kafkaVersion.IsAtLeast(V2_0_0_0) {
+			return 1
+		}
+		if kafkaVersion.IsAtLeast(V0_11_0_0) {
+			return 0
+		}
+		return -1
+	case 24, 25, 26, 28:
+		if kafkaVersion.IsAtLeast(V2_0_0_0) {
+			return 1
+		}
+		if kafkaVersion.IsAtLeast(V0_11_0_0) {
+			return 0
+		}
+		return -1
+	case 32:
+		// version 1 adds synonyms and the source of each entry to the response
+		if kafkaVersion.IsAtLeast(V2_0_0_0) {
+			return 2
+		}
+		if kafkaVersion.IsAtLeast(V1_1_0_0) {
+			return 1
+		}
+		if kafkaVersion.IsAtLeast(V0_11_0_0) {
+			return 0
+		}
+		return -1
+	case 33:
+		if kafkaVersion.IsAtLeast(V2_0_0_0) {
+			return 1
+		}
+		if kafkaVersion.IsAtLeast(V0_11_0_0) {
+			return 0
+		}
+		return -1
+	case 36:
+		// version 1 adds the session lifetime to the response
+		if kafkaVersion.IsAtLeast(V2_0_0_0) {
+			return 1
+		}
+		if kafkaVersion.IsAtLeast(V1_0_0_0) {
+			return 0
+		}
+		return -1
+	case 37:
+		if kafkaVersion.IsAtLeast(V2_0_0_0) {
+			return 1
+		}
+		if kafkaVersion.IsAtLeast(V1_0_0_0) {
+			return 0
+		}
+		return -1
+	case 44:
+		if kafkaVersion.IsAtLeast(V2_3_0_0) {
+			return 0
+		}
+		return -1
+	}
+	return 0
+}
@@ -51,7 +51,7 @@ func testRequest(t *testing.T, name string, rb RequestBody, expected []byte) {
 		t.Error("Encoding", name, "failed\ngot ", packet, "\nwant", expected)
 	}
 	// Decoder request
-	decoded, err := decodeRequest(bytes.NewReader(packet))
+	decoded, err := DecodeRequest(bytes.NewReader(packet))
 	if err != nil {
 		t.Error("Failed to decode request", err)
 	} else if decoded.CorrelationID != 123 || decoded.ClientID != "foo" {
@@ -0,0 +1,131 @@
+package sarama
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// ResponseBody is the body of a response. Unlike a request, a response does not say which request
+// it answers, so its key and version must be known to decode it.
+type ResponseBody interface {
+	Encoder
+	Decoder
+}
+
+// Response is a response frame as sent by a broker: the correlation ID of the request it answers,
+// followed by its body.
+type Response struct {
+	CorrelationID int32
+	Body          ResponseBody
+}
+
+func (r *Response) Encode(pe packetEncoder) (err error) {
+	pe.push(&lengthField{})
+	pe.putInt32(r.CorrelationID)
+	err = r.Body.Encode(pe)
+	if err != nil {
+		return err
+	}
+	return pe.pop()
+}
+
+// DecodeResponse reads a response frame from r and decodes it as the response to the given version
+// of the request with the given key.
+func DecodeResponse(r io.Reader, key, version int16) (res *Response, err error) {
+	lengthBytes := make([]byte, 4)
+	if _, err := io.ReadFull(r, lengthBytes); err != nil {
+		return nil, err
+	}
+
+	length := int32(binary.BigEndian.Uint32(lengthBytes))
+	if length < 4 || length > MaxResponseSize {
+		return nil, PacketDecodingError{fmt.Sprintf("message of length %d too large or too small", length)}
+	}
+
+	encodedRes := make([]byte, length)
+	if _, err := io.ReadFull(r, encodedRes); err != nil {
+		return nil, err
+	}
+
+	body := allocateResponseBody(key, version)
+	if body == nil {
+		return nil, PacketDecodingError{fmt.Sprintf("unknown response key (%d) or version (%d)", key, version)}
+	}
+
+	res = &Response{
+		CorrelationID: int32(binary.BigEndian.Uint32(encodedRes)),
+		Body:          body,
+	}
+	if err := Decode(encodedRes[4:], res.Body); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// allocateResponseBody returns an empty response to the given version of the request with the given
+// key, ready to be decoded, or nil if Sarama does not know that request or version. It is the
+// counterpart of allocateBody.
+func allocateResponseBody(key, version int16) ResponseBody {
+	if !knownRequestVersion(key, version) {
+		return nil
+	}
+
+	switch key {
+	case 0:
+		return &ProduceResponse{IVersion: version}
+	case 1:
+		return &FetchResponse{IVersion: version}
+	case 2:
+		return &OffsetResponse{IVersion: version}
+	case 3:
+		return &MetadataResponse{IVersion: version}
+	case 8:
+		return &OffsetCommitResponse{}
+	case 9:
+		return &OffsetFetchResponse{IVersion: version}
+	case 10:
+		return &ConsumerMetadataResponse{IVersion: version}
+	case 11:
+		return &JoinGroupResponse{IVersion: version}
+	case 12:
+		return &HeartbeatResponse{IVersion: version}
+	case 13:
+		return &LeaveGroupResponse{IVersion: version}
+	case 14:
+		return &SyncGroupResponse{IVersion: version}
+	case 15:
+		return &DescribeGroupsResponse{IVersion: version}
+	case 16:
+		return &ListGroupsResponse{IVersion: version}
+	case 17:
+		return &SaslHandshakeResponse{}
+	case 18:
+		return &ApiVersionsResponse{}
+	case 19:
+		return &CreateTopicsResponse{IVersion: version}
+	case 20:
+		return &DeleteTopicsResponse{IVersion: version}
+	case 22:
+		return &InitProducerIDResponse{}
+	case 24:
+		return &AddPartitionsToTxnResponse{}
+	case 25:
+		return &AddOffsetsToTxnResponse{}
+	case 26:
+		return &EndTxnResponse{}
+	case 28:
+		return &TxnOffsetCommitResponse{}
+	case 32:
+		return &DescribeConfigsResponse{IVersion: version}
+	case 33:
+		return &AlterConfigsResponse{}
+	case 36:
+		return &SaslAuthenticateResponse{IVersion: version}
+	case 37:
+		return &CreatePartitionsResponse{IVersion: version}
+	case 44:
+		return &IncrementalAlterConfigsResponse{}
+	}
+	return nil
+}
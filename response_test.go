@@ -0,0 +1,93 @@
+package sarama
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecodeResponse(t *testing.T) {
+	body := &ProduceResponse{IVersion: 7, ThrottleTime: 100 * time.Millisecond}
+	body.AddTopicPartition("foo", 1, ErrNotLeaderForPartition)
+
+	packet, err := Encode(&Response{CorrelationID: 123, Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeResponse(bytes.NewReader(packet), 0, body.IVersion)
+	if err != nil {
+		t.Fatal("Failed to decode response", err)
+	}
+	if decoded.CorrelationID != 123 {
+		t.Error("Expected correlation ID 123, got", decoded.CorrelationID)
+	}
+	if !reflect.DeepEqual(body, decoded.Body) {
+		t.Errorf("Decoded response does not match the encoded one\nencoded: %v\ndecoded: %v", body, decoded.Body)
+	}
+
+	if _, err := DecodeResponse(bytes.NewReader(packet), 0, 8); err == nil {
+		t.Error("Expected an error decoding an unknown version of ProduceResponse")
+	}
+	if _, err := DecodeResponse(bytes.NewReader(packet), 0x666, 0); err == nil {
+		t.Error("Expected an error decoding the response to an unknown request")
+	}
+}
+
+// TestRequestResponseRegistry checks that every request Sarama knows has a response, and that both
+// decode what they encode at every version.
+func TestRequestResponseRegistry(t *testing.T) {
+	for key := int16(0); key < 64; key++ {
+		if allocateBody(key, 0) == nil {
+			if allocateResponseBody(key, 0) != nil {
+				t.Errorf("Unexpected response for unknown request key %d", key)
+			}
+			continue
+		}
+
+		max := maxRequestVersion(key, MaxVersion)
+		for version := int16(0); version <= max; version++ {
+			request := allocateBody(key, version)
+			if request == nil || request.Version() != version {
+				t.Errorf("Expected version %d of request key %d", version, key)
+				continue
+			}
+			testReencodable(t, request, allocateBody(key, version))
+
+			response := allocateResponseBody(key, version)
+			if response == nil {
+				t.Errorf("Missing response to version %d of request key %d", version, key)
+				continue
+			}
+			testReencodable(t, response, allocateResponseBody(key, version))
+		}
+
+		if allocateBody(key, max+1) != nil || allocateResponseBody(key, max+1) != nil {
+			t.Errorf("Unexpected request or response for unknown version %d of request key %d", max+1, key)
+		}
+	}
+}
+
+// testReencodable encodes in, decodes the result into out and checks it encodes back to the same
+// bytes.
+func testReencodable(t *testing.T, in Encoder, out interface {
+	Encoder
+	Decoder
+}) {
+	packet, err := Encode(in)
+	if err != nil {
+		t.Errorf("Encoding %T failed: %v", in, err)
+		return
+	}
+	if err := Decode(packet, out); err != nil {
+		t.Errorf("Decoding %T failed: %v", out, err)
+		return
+	}
+	reencoded, err := Encode(out)
+	if err != nil {
+		t.Errorf("Encoding decoded %T failed: %v", out, err)
+	} else if !bytes.Equal(packet, reencoded) {
+		t.Errorf("Decoded %T does not encode back to the same bytes\ngot  %v\nwant %v", out, reencoded, packet)
+	}
+}
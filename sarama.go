@@ -1,8 +1,10 @@
 /*
 Package sarama provides client libraries for the Kafka 0.8 protocol. The AsyncProducer object is the high-level
 API for producing messages asynchronously; the SyncProducer provides a blocking API for the same purpose.
-The Consumer object is the high-level API for consuming messages. The Client object provides metadata
-management functionality that is shared between the higher-level objects.
+The Consumer object is the high-level API for consuming messages, and the ConsumerGroup builds on it to share
+the partitions of a set of topics among the members of a group coordinated by Kafka. The Client object provides metadata
+management functionality that is shared between the higher-level objects, and the ClusterAdmin builds on it
+to manage the topics of the cluster.
 
 For lower-level needs, the Broker and Request/Response objects permit precise control over each connection
 and message sent on the wire.
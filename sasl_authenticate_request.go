@@ -0,0 +1,47 @@
+package sarama
+
+import "encoding/json"
+
+// SaslAuthenticateRequest carries the authentication bytes of a SASL mechanism after a version 1
+// handshake, one request per step of the exchange.
+type SaslAuthenticateRequest struct {
+	SaslAuthBytes []byte
+
+	// Version can be:
+	// - 0 (kafka 1.0 and later)
+	// - 1 (kafka 2.0 and later, the response includes the session lifetime)
+	IVersion int16
+}
+
+func (r *SaslAuthenticateRequest) Encode(pe packetEncoder) error {
+	if r.IVersion < 0 || r.IVersion > 1 {
+		return PacketEncodingError{"invalid or unsupported SaslAuthenticateRequest version field"}
+	}
+	return pe.putBytes(r.SaslAuthBytes)
+}
+
+func (r *SaslAuthenticateRequest) Decode(pd packetDecoder) (err error) {
+	r.SaslAuthBytes, err = pd.getBytes()
+	return err
+}
+
+func (r *SaslAuthenticateRequest) Key() int16 {
+	return 36
+}
+
+func (r *SaslAuthenticateRequest) Version() int16 {
+	return r.IVersion
+}
+
+func (r *SaslAuthenticateRequest) String() string {
+	return dump(r)
+}
+
+// MarshalJSON gives the JSON view of the request with only the size of the authentication bytes,
+// which hold the password with SASL/PLAIN.
+func (r *SaslAuthenticateRequest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		SaslAuthBytesSize int
+		IVersion          int16
+	}{len(r.SaslAuthBytes), r.IVersion})
+}
@@ -0,0 +1,35 @@
+package sarama
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+var saslAuthenticateRequest = []byte{
+	0, 0, 0, 3, 'f', 'o', 'o',
+}
+
+func TestSaslAuthenticateRequest(t *testing.T) {
+	request := &SaslAuthenticateRequest{SaslAuthBytes: []byte("foo")}
+	testRequest(t, "v0", request, saslAuthenticateRequest)
+
+	request = &SaslAuthenticateRequest{SaslAuthBytes: []byte("foo"), IVersion: 1}
+	testRequest(t, "v1", request, saslAuthenticateRequest)
+}
+
+func TestSaslAuthenticateRequestRedacted(t *testing.T) {
+	request := &SaslAuthenticateRequest{SaslAuthBytes: []byte("\x00user\x00hunter2"), IVersion: 1}
+
+	if dumped := request.String(); strings.Contains(dumped, "hunter2") || !strings.Contains(dumped, "<redacted, 13 bytes>") {
+		t.Error("Expected only the size of the authentication bytes in the dump, got", dumped)
+	}
+
+	marshalled, err := json.Marshal(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(marshalled) != `{"SaslAuthBytesSize":13,"IVersion":1}` {
+		t.Error("Expected only the size of the authentication bytes in the JSON, got", string(marshalled))
+	}
+}
@@ -0,0 +1,58 @@
+package sarama
+
+import "time"
+
+type SaslAuthenticateResponse struct {
+	Err             KError
+	ErrMsg          *string
+	SaslAuthBytes   []byte
+	SessionLifetime time.Duration // only provided if Version >= 1, zero if the session does not expire
+
+	// Version must be set to that of the request before decoding
+	IVersion int16
+}
+
+func (r *SaslAuthenticateResponse) Encode(pe packetEncoder) error {
+	pe.putInt16(int16(r.Err))
+	if err := pe.putNullableString(r.ErrMsg); err != nil {
+		return err
+	}
+	if err := pe.putBytes(r.SaslAuthBytes); err != nil {
+		return err
+	}
+
+	if r.IVersion >= 1 {
+		pe.putInt64(int64(r.SessionLifetime / time.Millisecond))
+	}
+
+	return nil
+}
+
+func (r *SaslAuthenticateResponse) Decode(pd packetDecoder) (err error) {
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.Err = KError(kerr)
+
+	if r.ErrMsg, err = pd.getNullableString(); err != nil {
+		return err
+	}
+	if r.SaslAuthBytes, err = pd.getBytes(); err != nil {
+		return err
+	}
+
+	if r.IVersion >= 1 {
+		millis, err := pd.getInt64()
+		if err != nil {
+			return err
+		}
+		r.SessionLifetime = time.Duration(millis) * time.Millisecond
+	}
+
+	return nil
+}
+
+func (r *SaslAuthenticateResponse) String() string {
+	return dump(r)
+}
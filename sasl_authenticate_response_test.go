@@ -0,0 +1,43 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	saslAuthenticateResponseError = []byte{
+		0, 58,
+		0, 3, 'e', 'r', 'r',
+		0, 0, 0, 0,
+	}
+
+	saslAuthenticateResponseV1 = []byte{
+		0, 0,
+		255, 255,
+		0, 0, 0, 3, 'f', 'o', 'o',
+		0, 0, 0, 0, 0, 0, 0x0e, 0x10,
+	}
+)
+
+func TestSaslAuthenticateResponse(t *testing.T) {
+	response := new(SaslAuthenticateResponse)
+	testDecodable(t, "error", response, saslAuthenticateResponseError)
+	if response.Err != ErrSASLAuthenticationFailed || response.ErrMsg == nil || *response.ErrMsg != "err" {
+		t.Error("Decoding error failed, got", response.Err, response.ErrMsg)
+	}
+	if len(response.SaslAuthBytes) != 0 {
+		t.Error("Decoding auth bytes failed, got", response.SaslAuthBytes)
+	}
+	testEncodable(t, "error", response, saslAuthenticateResponseError)
+
+	response = &SaslAuthenticateResponse{IVersion: 1}
+	testDecodable(t, "v1", response, saslAuthenticateResponseV1)
+	if response.Err != ErrNoError || response.ErrMsg != nil || string(response.SaslAuthBytes) != "foo" {
+		t.Error("Decoding v1 failed, got", response)
+	}
+	if response.SessionLifetime != 3600*time.Millisecond {
+		t.Error("Decoding session lifetime failed, got", response.SessionLifetime)
+	}
+	testEncodable(t, "v1", response, saslAuthenticateResponseV1)
+}
@@ -0,0 +1,34 @@
+package sarama
+
+type SaslHandshakeRequest struct {
+	Mechanism string
+
+	// Version can be:
+	// - 0 (kafka 0.10 and later, followed by the raw authentication bytes)
+	// - 1 (kafka 1.0 and later, followed by SaslAuthenticateRequests)
+	IVersion int16
+}
+
+func (r *SaslHandshakeRequest) Encode(pe packetEncoder) error {
+	if r.IVersion < 0 || r.IVersion > 1 {
+		return PacketEncodingError{"invalid or unsupported SaslHandshakeRequest version field"}
+	}
+	return pe.putString(r.Mechanism)
+}
+
+func (r *SaslHandshakeRequest) Decode(pd packetDecoder) (err error) {
+	r.Mechanism, err = pd.getString()
+	return err
+}
+
+func (r *SaslHandshakeRequest) Key() int16 {
+	return 17
+}
+
+func (r *SaslHandshakeRequest) Version() int16 {
+	return r.IVersion
+}
+
+func (r *SaslHandshakeRequest) String() string {
+	return dump(r)
+}
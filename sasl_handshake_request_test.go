@@ -0,0 +1,17 @@
+package sarama
+
+import "testing"
+
+var saslHandshakeRequest = []byte{
+	0, 5, 'P', 'L', 'A', 'I', 'N',
+}
+
+func TestSaslHandshakeRequest(t *testing.T) {
+	request := &SaslHandshakeRequest{Mechanism: "PLAIN"}
+	testRequest(t, "basic", request, saslHandshakeRequest)
+}
+
+func TestSaslHandshakeRequestV1(t *testing.T) {
+	request := &SaslHandshakeRequest{Mechanism: "PLAIN", IVersion: 1}
+	testRequest(t, "v1", request, saslHandshakeRequest)
+}
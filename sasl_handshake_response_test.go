@@ -0,0 +1,21 @@
+package sarama
+
+import "testing"
+
+var saslHandshakeResponse = []byte{
+	0, 33,
+	0, 0, 0, 1,
+	0, 6, 'G', 'S', 'S', 'A', 'P', 'I',
+}
+
+func TestSaslHandshakeResponse(t *testing.T) {
+	response := new(SaslHandshakeResponse)
+	testDecodable(t, "unsupported mechanism", response, saslHandshakeResponse)
+	if response.Err != ErrUnsupportedSASLMechanism {
+		t.Error("Decoding error failed, got", response.Err)
+	}
+	if len(response.EnabledMechanisms) != 1 || response.EnabledMechanisms[0] != "GSSAPI" {
+		t.Error("Decoding mechanisms failed, got", response.EnabledMechanisms)
+	}
+	testEncodable(t, "unsupported mechanism", response, saslHandshakeResponse)
+}
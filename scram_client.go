@@ -0,0 +1,181 @@
+package sarama
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+// SCRAMClient carries out the client side of a SASL/SCRAM exchange. Sarama has its own implementation
+// of RFC 5802, which is used unless Config.Net.SASL.SCRAMClientGeneratorFunc provides another one.
+type SCRAMClient interface {
+	// Begin prepares the client for a new exchange with the given credentials. The authorization ID
+	// may be empty, to act as userName.
+	Begin(userName, password, authzID string) error
+	// Step takes the last message received from the server (empty on the first call) and returns the
+	// next message to send it. It is called until it returns an error or Done returns true. It must
+	// return an error if the final message of the server does not prove that it knows the password.
+	Step(challenge string) (response string, err error)
+	// Done returns true once the exchange is complete.
+	Done() bool
+}
+
+// scramClient is the default SCRAMClient. Passwords are used as they are, without SASLprep
+// normalisation, which only makes a difference for non-ASCII passwords.
+type scramClient struct {
+	hashFn func() hash.Hash
+
+	userName, password, authzID string
+	clientNonce                 string // random unless set before the first step
+
+	step            int
+	gs2Header       string
+	clientFirstBare string
+	serverSignature []byte
+}
+
+func newSCRAMClient(mechanism SASLMechanism) SCRAMClient {
+	if mechanism == SASLTypeSCRAMSHA512 {
+		return &scramClient{hashFn: sha512.New}
+	}
+	return &scramClient{hashFn: sha256.New}
+}
+
+func (c *scramClient) Begin(userName, password, authzID string) error {
+	c.userName, c.password, c.authzID = userName, password, authzID
+	c.step = 0
+	c.serverSignature = nil
+	return nil
+}
+
+func (c *scramClient) Step(challenge string) (string, error) {
+	c.step++
+	switch c.step {
+	case 1:
+		return c.clientFirst()
+	case 2:
+		return c.clientFinal(challenge)
+	case 3:
+		return "", c.verifyServerFinal(challenge)
+	}
+	return "", fmt.Errorf("kafka: SCRAM exchange is already complete")
+}
+
+func (c *scramClient) Done() bool {
+	return c.step >= 3
+}
+
+func (c *scramClient) clientFirst() (string, error) {
+	if c.clientNonce == "" {
+		nonce := make([]byte, 18)
+		if _, err := rand.Read(nonce); err != nil {
+			return "", err
+		}
+		c.clientNonce = base64.StdEncoding.EncodeToString(nonce)
+	}
+
+	c.gs2Header = "n,,"
+	if c.authzID != "" {
+		c.gs2Header = "n,a=" + scramEscape(c.authzID) + ","
+	}
+	c.clientFirstBare = "n=" + scramEscape(c.userName) + ",r=" + c.clientNonce
+	return c.gs2Header + c.clientFirstBare, nil
+}
+
+func (c *scramClient) clientFinal(serverFirst string) (string, error) {
+	attributes := scramAttributes(serverFirst)
+	if msg, ok := attributes['e']; ok {
+		return "", fmt.Errorf("kafka: SCRAM authentication failed: %s", msg)
+	}
+
+	nonce := attributes['r']
+	if !strings.HasPrefix(nonce, c.clientNonce) || len(nonce) == len(c.clientNonce) {
+		return "", fmt.Errorf("kafka: SCRAM server nonce %q does not extend the client nonce", nonce)
+	}
+	salt, err := base64.StdEncoding.DecodeString(attributes['s'])
+	if err != nil || len(salt) == 0 {
+		return "", fmt.Errorf("kafka: invalid SCRAM salt %q", attributes['s'])
+	}
+	iterations, err := strconv.Atoi(attributes['i'])
+	if err != nil || iterations < 1 {
+		return "", fmt.Errorf("kafka: invalid SCRAM iteration count %q", attributes['i'])
+	}
+
+	saltedPassword := c.saltPassword(salt, iterations)
+	clientKey := c.hmac(saltedPassword, "Client Key")
+	storedKey := c.hashFn()
+	storedKey.Write(clientKey)
+
+	clientFinalWithoutProof := "c=" + base64.StdEncoding.EncodeToString([]byte(c.gs2Header)) + ",r=" + nonce
+	authMessage := c.clientFirstBare + "," + serverFirst + "," + clientFinalWithoutProof
+
+	clientProof := c.hmac(storedKey.Sum(nil), authMessage)
+	for i := range clientProof {
+		clientProof[i] ^= clientKey[i]
+	}
+	c.serverSignature = c.hmac(c.hmac(saltedPassword, "Server Key"), authMessage)
+
+	return clientFinalWithoutProof + ",p=" + base64.StdEncoding.EncodeToString(clientProof), nil
+}
+
+func (c *scramClient) verifyServerFinal(serverFinal string) error {
+	attributes := scramAttributes(serverFinal)
+	if msg, ok := attributes['e']; ok {
+		return fmt.Errorf("kafka: SCRAM authentication failed: %s", msg)
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(attributes['v'])
+	if err != nil || !hmac.Equal(signature, c.serverSignature) {
+		return ErrSCRAMServerSignatureMismatch
+	}
+	return nil
+}
+
+// saltPassword is the Hi function of RFC 5802, PBKDF2 with the HMAC of the mechanism's hash as the
+// pseudorandom function and an output the size of that hash.
+func (c *scramClient) saltPassword(salt []byte, iterations int) []byte {
+	mac := hmac.New(c.hashFn, []byte(c.password))
+	mac.Write(salt)
+	mac.Write([]byte{0, 0, 0, 1})
+	u := mac.Sum(nil)
+
+	result := make([]byte, len(u))
+	copy(result, u)
+	for i := 1; i < iterations; i++ {
+		mac.Reset()
+		mac.Write(u)
+		u = mac.Sum(u[:0])
+		for j := range result {
+			result[j] ^= u[j]
+		}
+	}
+	return result
+}
+
+func (c *scramClient) hmac(key []byte, message string) []byte {
+	mac := hmac.New(c.hashFn, key)
+	mac.Write([]byte(message))
+	return mac.Sum(nil)
+}
+
+// scramAttributes splits a SCRAM message into its attributes, keyed by their single-letter names.
+func scramAttributes(msg string) map[byte]string {
+	attributes := make(map[byte]string)
+	for _, field := range strings.Split(msg, ",") {
+		if len(field) >= 2 && field[1] == '=' {
+			attributes[field[0]] = field[2:]
+		}
+	}
+	return attributes
+}
+
+// scramEscape escapes the characters that RFC 5802 reserves in user names.
+func scramEscape(name string) string {
+	return strings.Replace(strings.Replace(name, "=", "=3D", -1), ",", "=2C", -1)
+}
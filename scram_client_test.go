@@ -0,0 +1,76 @@
+package sarama
+
+import "testing"
+
+// The example exchange of RFC 7677.
+const (
+	scramClientFirst = "n,,n=user,r=rOprNGfwEbeRWgbNEkqO"
+	scramServerFirst = "r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"
+	scramClientFinal = "c=biws,r=rOprNGfwEbeRWgbNEkqO%hvYDpWUa2RaTCAfuxFIlj)hNlF$k0,p=dHzbZapWIk4jUhN+Ute9ytag9zjfMHgsqmmiz7AndVQ="
+	scramServerFinal = "v=6rriTRBi23WpRR/wtup+mMhUZUn/dB5nLTJRsjl95G4="
+)
+
+func newTestSCRAMClient(t *testing.T) *scramClient {
+	client := newSCRAMClient(SASLTypeSCRAMSHA256).(*scramClient)
+	client.clientNonce = "rOprNGfwEbeRWgbNEkqO"
+	if err := client.Begin("user", "pencil", ""); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestSCRAMClientExchange(t *testing.T) {
+	client := newTestSCRAMClient(t)
+
+	if msg, err := client.Step(""); err != nil || msg != scramClientFirst {
+		t.Fatalf("Unexpected client-first message %q (%v)", msg, err)
+	}
+	if msg, err := client.Step(scramServerFirst); err != nil || msg != scramClientFinal {
+		t.Fatalf("Unexpected client-final message %q (%v)", msg, err)
+	}
+	if client.Done() {
+		t.Fatal("Expected the exchange to wait for the server-final message")
+	}
+	if _, err := client.Step(scramServerFinal); err != nil {
+		t.Fatal(err)
+	}
+	if !client.Done() {
+		t.Error("Expected the exchange to be done")
+	}
+}
+
+func TestSCRAMClientServerSignatureMismatch(t *testing.T) {
+	client := newTestSCRAMClient(t)
+
+	if _, err := client.Step(""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Step(scramServerFirst); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Step("v=AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="); err != ErrSCRAMServerSignatureMismatch {
+		t.Error("Expected ErrSCRAMServerSignatureMismatch, got", err)
+	}
+}
+
+func TestSCRAMClientRejectsForeignNonce(t *testing.T) {
+	client := newTestSCRAMClient(t)
+
+	if _, err := client.Step(""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Step("r=somethingElse,s=W22ZaJ0SNY7soEsUEjb6gQ==,i=4096"); err == nil {
+		t.Error("Expected a server nonce not extending the client nonce to be rejected")
+	}
+}
+
+func TestSCRAMClientEscapesUserName(t *testing.T) {
+	client := newTestSCRAMClient(t)
+	if err := client.Begin("a=b,c", "pencil", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if msg, err := client.Step(""); err != nil || msg != "n,,n=a=3Db=2Cc,r=rOprNGfwEbeRWgbNEkqO" {
+		t.Errorf("Unexpected client-first message %q (%v)", msg, err)
+	}
+}
@@ -0,0 +1,103 @@
+package sarama
+
+type SyncGroupRequest struct {
+	GroupID          string
+	GenerationID     int32
+	MemberID         string
+	GroupAssignments map[string][]byte // only sent by the leader
+
+	// Version can be:
+	// - 0 (kafka 0.9 and later)
+	// - 1 (kafka 0.11 and later, adding throttle time to the response)
+	IVersion int16
+}
+
+func (r *SyncGroupRequest) Encode(pe packetEncoder) error {
+	if r.IVersion < 0 || r.IVersion > 1 {
+		return PacketEncodingError{"invalid or unsupported SyncGroupRequest version field"}
+	}
+
+	if err := pe.putString(r.GroupID); err != nil {
+		return err
+	}
+	pe.putInt32(r.GenerationID)
+	if err := pe.putString(r.MemberID); err != nil {
+		return err
+	}
+
+	if err := pe.putArrayLength(len(r.GroupAssignments)); err != nil {
+		return err
+	}
+	for memberID, assignment := range r.GroupAssignments {
+		if err := pe.putString(memberID); err != nil {
+			return err
+		}
+		if err := pe.putBytes(assignment); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *SyncGroupRequest) Decode(pd packetDecoder) (err error) {
+	if r.GroupID, err = pd.getString(); err != nil {
+		return err
+	}
+	if r.GenerationID, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if r.MemberID, err = pd.getString(); err != nil {
+		return err
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return nil
+	}
+
+	r.GroupAssignments = make(map[string][]byte, n)
+	for i := 0; i < n; i++ {
+		memberID, err := pd.getString()
+		if err != nil {
+			return err
+		}
+		if r.GroupAssignments[memberID], err = pd.getBytes(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *SyncGroupRequest) Key() int16 {
+	return 14
+}
+
+func (r *SyncGroupRequest) Version() int16 {
+	return r.IVersion
+}
+
+func (r *SyncGroupRequest) String() string {
+	return dump(r)
+}
+
+func (r *SyncGroupRequest) AddGroupAssignment(memberID string, assignment []byte) {
+	if r.GroupAssignments == nil {
+		r.GroupAssignments = make(map[string][]byte)
+	}
+	r.GroupAssignments[memberID] = assignment
+}
+
+func (r *SyncGroupRequest) AddGroupAssignmentMember(memberID string, assignment *ConsumerGroupMemberAssignment) error {
+	bin, err := Encode(assignment)
+	if err != nil {
+		return err
+	}
+
+	r.AddGroupAssignment(memberID, bin)
+	return nil
+}
@@ -0,0 +1,36 @@
+package sarama
+
+import "testing"
+
+var (
+	emptySyncGroupRequest = []byte{
+		0x00, 0x03, 'f', 'o', 'o', // Group ID
+		0x00, 0x01, 0x02, 0x03, // Generation ID
+		0x00, 0x03, 'b', 'a', 'z', // Member ID
+		0x00, 0x00, 0x00, 0x00, // no assignments
+	}
+
+	populatedSyncGroupRequest = []byte{
+		0x00, 0x03, 'f', 'o', 'o', // Group ID
+		0x00, 0x01, 0x02, 0x03, // Generation ID
+		0x00, 0x03, 'b', 'a', 'z', // Member ID
+		0x00, 0x00, 0x00, 0x01, // one assignment
+		0x00, 0x03, 'b', 'a', 'z', // Member ID
+		0x00, 0x00, 0x00, 0x03, 'f', 'o', 'o', // Member assignment
+	}
+)
+
+func TestSyncGroupRequest(t *testing.T) {
+	request := new(SyncGroupRequest)
+	request.GroupID = "foo"
+	request.GenerationID = 66051
+	request.MemberID = "baz"
+	testRequest(t, "empty", request, emptySyncGroupRequest)
+
+	request.AddGroupAssignment("baz", []byte("foo"))
+	testRequest(t, "populated", request, populatedSyncGroupRequest)
+
+	// version 1 only changes the response
+	request.IVersion = 1
+	testRequest(t, "populated v1", request, populatedSyncGroupRequest)
+}
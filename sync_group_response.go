@@ -0,0 +1,67 @@
+package sarama
+
+import "time"
+
+type SyncGroupResponse struct {
+	ThrottleTime     time.Duration // only provided if Version >= 1
+	Err              KError
+	MemberAssignment []byte
+
+	// Version must be set to that of the request before decoding
+	IVersion int16
+}
+
+// GetMemberAssignment decodes the assignment of the "consumer" protocol type. An empty assignment
+// decodes to one without any topics.
+func (r *SyncGroupResponse) GetMemberAssignment() (*ConsumerGroupMemberAssignment, error) {
+	assignment := new(ConsumerGroupMemberAssignment)
+	if len(r.MemberAssignment) == 0 {
+		return assignment, nil
+	}
+	if err := Decode(r.MemberAssignment, assignment); err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+func (r *SyncGroupResponse) Encode(pe packetEncoder) error {
+	if r.IVersion >= 1 {
+		pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	}
+	pe.putInt16(int16(r.Err))
+	return pe.putBytes(r.MemberAssignment)
+}
+
+func (r *SyncGroupResponse) Decode(pd packetDecoder) (err error) {
+	if r.IVersion >= 1 {
+		millis, err := pd.getInt32()
+		if err != nil {
+			return err
+		}
+		r.ThrottleTime = time.Duration(millis) * time.Millisecond
+	}
+
+	kerr, err := pd.getInt16()
+	if err != nil {
+		return err
+	}
+	r.Err = KError(kerr)
+
+	r.MemberAssignment, err = pd.getBytes()
+	return err
+}
+
+func (r *SyncGroupResponse) String() string {
+	return dump(r)
+}
+
+// testing API
+
+func (r *SyncGroupResponse) SetMemberAssignment(assignment *ConsumerGroupMemberAssignment) error {
+	bin, err := Encode(assignment)
+	if err != nil {
+		return err
+	}
+	r.MemberAssignment = bin
+	return nil
+}
@@ -0,0 +1,66 @@
+package sarama
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var (
+	syncGroupResponseNoError = []byte{
+		0x00, 0x00, // No error
+		0x00, 0x00, 0x00, 0x1B, // Member assignment data
+		0x00, 0x00, // Version
+		0x00, 0x00, 0x00, 0x01, // Topic array length
+		0x00, 0x03, 'o', 'n', 'e', // Topic one
+		0x00, 0x00, 0x00, 0x02, // Topic one, partition array length
+		0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, // 0, 2
+		0xFF, 0xFF, 0xFF, 0xFF, // Userdata
+	}
+
+	syncGroupResponseWithErrorV1 = []byte{
+		0x00, 0x00, 0x00, 0x64, // Throttle time
+		0x00, 0x1B, // ErrRebalanceInProgress
+		0x00, 0x00, 0x00, 0x00, // No member assignment data
+	}
+)
+
+func TestSyncGroupResponse(t *testing.T) {
+	response := new(SyncGroupResponse)
+	testDecodable(t, "no error", response, syncGroupResponseNoError)
+	if response.Err != ErrNoError {
+		t.Error("Decoding Err failed: no error expected but found", response.Err)
+	}
+
+	assignment, err := response.GetMemberAssignment()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &ConsumerGroupMemberAssignment{Topics: map[string][]int32{"one": {0, 2}}}
+	if !reflect.DeepEqual(assignment, expected) {
+		t.Error("Decoding the member assignment failed, found:", assignment)
+	}
+
+	testResponse(t, "no error", response, syncGroupResponseNoError)
+}
+
+func TestSyncGroupResponseWithErrorV1(t *testing.T) {
+	response := &SyncGroupResponse{IVersion: 1}
+	testDecodable(t, "with error", response, syncGroupResponseWithErrorV1)
+	if response.ThrottleTime != 100*time.Millisecond {
+		t.Error("Decoding ThrottleTime failed, found:", response.ThrottleTime)
+	}
+	if response.Err != ErrRebalanceInProgress {
+		t.Error("Decoding Err failed: ErrRebalanceInProgress expected but found", response.Err)
+	}
+
+	assignment, err := response.GetMemberAssignment()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assignment.Topics) != 0 {
+		t.Error("Expected an empty assignment, found:", assignment)
+	}
+
+	testEncodable(t, "with error", response, syncGroupResponseWithErrorV1)
+}
@@ -12,6 +12,22 @@ type SyncProducer interface {
 	// of the produced message, or an error if the message failed to produce.
 	SendMessage(msg *ProducerMessage) (partition int32, offset int64, err error)
 
+	// BeginTxn starts a transaction. Messages are only accepted while a
+	// transaction is in progress. It requires Producer.Transaction.ID to be set.
+	BeginTxn() error
+
+	// CommitTxn commits the transaction in progress. If a message of the
+	// transaction failed, the transaction cannot be committed: the error is
+	// returned, and the transaction must be aborted.
+	CommitTxn() error
+
+	// AbortTxn aborts the transaction in progress, discarding its messages.
+	AbortTxn() error
+
+	// AddOffsetsToTxn commits the offsets of a consumer group as part of the
+	// transaction in progress.
+	AddOffsetsToTxn(offsets map[string][]*PartitionOffsetMetadata, groupID string) error
+
 	// Close shuts down the producer and flushes any messages it may have buffered.
 	// You must call this function before a producer object passes out of scope, as
 	// it may otherwise leak memory. You must call this before calling Close on the
@@ -72,6 +88,22 @@ func (sp *syncProducer) SendMessage(msg *ProducerMessage) (partition int32, offs
 	}
 }
 
+func (sp *syncProducer) BeginTxn() error {
+	return sp.producer.BeginTxn()
+}
+
+func (sp *syncProducer) CommitTxn() error {
+	return sp.producer.CommitTxn()
+}
+
+func (sp *syncProducer) AbortTxn() error {
+	return sp.producer.AbortTxn()
+}
+
+func (sp *syncProducer) AddOffsetsToTxn(offsets map[string][]*PartitionOffsetMetadata, groupID string) error {
+	return sp.producer.AddOffsetsToTxn(offsets, groupID)
+}
+
 func (sp *syncProducer) handleSuccesses() {
 	defer sp.wg.Done()
 	for msg := range sp.producer.Successes() {
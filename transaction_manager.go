@@ -0,0 +1,458 @@
+package sarama
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	noProducerID    int64 = -1
+	noProducerEpoch int16 = -1
+)
+
+// transactionManager holds the producer ID and epoch of an idempotent producer, and the sequence
+// number of the next message to each partition. Without Producer.Idempotent it holds no producer
+// ID and batches are sent without one.
+//
+// With Producer.Transaction.ID it also tracks the transaction in progress: the partitions and
+// consumer groups added to it, and the first error to make it impossible to commit.
+type transactionManager struct {
+	conf   *Config
+	client Client
+
+	producerID      int64
+	producerEpoch   int16
+	sequenceNumbers map[string]map[int32]int32
+
+	transactionalID string
+	inTransaction   bool
+	partitionsInTxn map[string]map[int32]bool
+	groupsInTxn     map[string]bool
+	txnErr          error
+
+	lock sync.Mutex
+}
+
+func newTransactionManager(conf *Config, client Client) (*transactionManager, error) {
+	txnmgr := &transactionManager{
+		conf:            conf,
+		client:          client,
+		producerID:      noProducerID,
+		producerEpoch:   noProducerEpoch,
+		sequenceNumbers: make(map[string]map[int32]int32),
+		transactionalID: conf.Producer.Transaction.ID,
+	}
+
+	if !conf.Producer.Idempotent {
+		return txnmgr, nil
+	}
+
+	if err := txnmgr.initProducerID(); err != nil {
+		return nil, err
+	}
+	return txnmgr, nil
+}
+
+// initProducerID obtains a producer ID and epoch. A transactional producer asks the coordinator of
+// its transactional ID, which returns the same producer ID as before in a new epoch, fencing off
+// earlier instances and aborting any transaction they left open. You must hold the lock before
+// calling this function, unless the transaction manager is not in use yet.
+func (t *transactionManager) initProducerID() error {
+	var response *InitProducerIDResponse
+
+	if t.isTransactional() {
+		err := t.sendToCoordinator(CoordinatorTransaction, t.transactionalID, func(coordinator *Broker) error {
+			version, err := coordinator.requestVersion(22, 0)
+			if err != nil {
+				return err
+			}
+			response, err = coordinator.InitProducerID(&InitProducerIDRequest{
+				TransactionalID:    &t.transactionalID,
+				TransactionTimeout: t.conf.Producer.Transaction.Timeout,
+				IVersion:           version,
+			})
+			if err != nil {
+				return err
+			}
+			return errorOrNil(response.Err)
+		})
+		if err != nil {
+			return err
+		}
+	} else {
+		broker := t.client.Any()
+		if broker == nil {
+			return ErrOutOfBrokers
+		}
+
+		version, err := broker.requestVersion(22, 0)
+		if err != nil {
+			return err
+		}
+		response, err = broker.InitProducerID(&InitProducerIDRequest{IVersion: version})
+		if err != nil {
+			return err
+		}
+		if response.Err != ErrNoError {
+			return response.Err
+		}
+	}
+
+	t.producerID = response.ProducerID
+	t.producerEpoch = response.ProducerEpoch
+	t.sequenceNumbers = make(map[string]map[int32]int32)
+	Logger.Printf("producer/txnmanager obtained producer ID %d (epoch %d)\n", t.producerID, t.producerEpoch)
+	return nil
+}
+
+func (t *transactionManager) isTransactional() bool {
+	return t.transactionalID != ""
+}
+
+// assignSequenceNumbers numbers the messages about to be sent to a partition, unless they were
+// numbered in the current epoch by an earlier attempt to send them, in which case they must keep
+// their numbers for the broker to recognise them as duplicates. Messages are retried ahead of any
+// that follow them, so the numbers of a batch are always consecutive.
+func (t *transactionManager) assignSequenceNumbers(topic string, partition int32, msgs []*ProducerMessage) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	partitions := t.sequenceNumbers[topic]
+	if partitions == nil {
+		partitions = make(map[int32]int32)
+		t.sequenceNumbers[topic] = partitions
+	}
+
+	for _, msg := range msgs {
+		if msg.hasSequence && msg.producerEpoch == t.producerEpoch {
+			continue
+		}
+		msg.sequenceNumber = partitions[partition]
+		msg.producerEpoch = t.producerEpoch
+		msg.hasSequence = true
+		partitions[partition]++
+	}
+}
+
+// bumpEpoch moves to a new epoch after a message numbered in the given epoch fails for good. The
+// broker never sees its sequence number, so it would reject every later message to the partition
+// as out of order; in a new epoch the sequence numbers of every partition start again from zero.
+//
+// A transactional producer cannot move to a new epoch by itself: the failure is recorded instead,
+// and the producer ID is obtained again once the transaction is aborted.
+func (t *transactionManager) bumpEpoch(failed int16, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.isTransactional() {
+		if t.inTransaction && t.txnErr == nil {
+			Logger.Printf("producer/txnmanager transaction %s must be aborted because %s\n", t.transactionalID, err)
+			t.txnErr = err
+		}
+		return
+	}
+
+	if failed != t.producerEpoch {
+		return // already bumped for an earlier message of the same batch
+	}
+
+	t.producerEpoch++
+	t.sequenceNumbers = make(map[string]map[int32]int32)
+	Logger.Printf("producer/txnmanager moved to epoch %d of producer ID %d\n", t.producerEpoch, t.producerID)
+}
+
+func (t *transactionManager) beginTxn() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	switch {
+	case !t.isTransactional():
+		return ErrNonTransactionalProducer
+	case t.inTransaction:
+		return ErrTransactionNotReady
+	}
+
+	t.inTransaction = true
+	t.partitionsInTxn = make(map[string]map[int32]bool)
+	t.groupsInTxn = make(map[string]bool)
+	t.txnErr = nil
+	return nil
+}
+
+// checkInTransaction returns the error to give the user for messages, offsets or the end of a
+// transaction while none is in progress.
+func (t *transactionManager) checkInTransaction() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	switch {
+	case !t.isTransactional():
+		return ErrNonTransactionalProducer
+	case !t.inTransaction:
+		return ErrNotInTransaction
+	}
+	return nil
+}
+
+// addPartitionsToTxn adds the partitions of a set about to be sent to the transaction in progress,
+// unless they are part of it already. The broker rejects transactional messages to partitions the
+// coordinator does not know about.
+func (t *transactionManager) addPartitionsToTxn(set *produceSet) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.isTransactional() {
+		return nil
+	}
+	if !t.inTransaction {
+		return ErrNotInTransaction
+	}
+
+	partitions := make(map[string][]int32)
+	set.eachPartition(func(topic string, partition int32, msgs []*ProducerMessage) {
+		if !t.partitionsInTxn[topic][partition] {
+			partitions[topic] = append(partitions[topic], partition)
+		}
+	})
+	if len(partitions) == 0 {
+		return nil
+	}
+
+	err := t.sendToCoordinator(CoordinatorTransaction, t.transactionalID, func(coordinator *Broker) error {
+		version, err := coordinator.requestVersion(24, 0)
+		if err != nil {
+			return err
+		}
+		response, err := coordinator.AddPartitionsToTxn(&AddPartitionsToTxnRequest{
+			TransactionalID: t.transactionalID,
+			ProducerID:      t.producerID,
+			ProducerEpoch:   t.producerEpoch,
+			TopicPartitions: partitions,
+			IVersion:        version,
+		})
+		if err != nil {
+			return err
+		}
+		return firstPartitionError(response.Errors)
+	})
+	if err != nil {
+		return err
+	}
+
+	for topic, ids := range partitions {
+		if t.partitionsInTxn[topic] == nil {
+			t.partitionsInTxn[topic] = make(map[int32]bool)
+		}
+		for _, partition := range ids {
+			t.partitionsInTxn[topic][partition] = true
+		}
+	}
+	return nil
+}
+
+// addOffsetsToTxn commits the offsets of a consumer group as part of the transaction in progress.
+// The transaction coordinator is told about the group first, so that it can complete the commit
+// along with the transaction.
+func (t *transactionManager) addOffsetsToTxn(offsets map[string][]*PartitionOffsetMetadata, groupID string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	switch {
+	case !t.isTransactional():
+		return ErrNonTransactionalProducer
+	case !t.inTransaction:
+		return ErrNotInTransaction
+	}
+
+	if !t.groupsInTxn[groupID] {
+		err := t.sendToCoordinator(CoordinatorTransaction, t.transactionalID, func(coordinator *Broker) error {
+			version, err := coordinator.requestVersion(25, 0)
+			if err != nil {
+				return err
+			}
+			response, err := coordinator.AddOffsetsToTxn(&AddOffsetsToTxnRequest{
+				TransactionalID: t.transactionalID,
+				ProducerID:      t.producerID,
+				ProducerEpoch:   t.producerEpoch,
+				GroupID:         groupID,
+				IVersion:        version,
+			})
+			if err != nil {
+				return err
+			}
+			return errorOrNil(response.Err)
+		})
+		if err != nil {
+			return err
+		}
+		t.groupsInTxn[groupID] = true
+	}
+
+	return t.sendToCoordinator(CoordinatorGroup, groupID, func(coordinator *Broker) error {
+		version, err := coordinator.requestVersion(28, 0)
+		if err != nil {
+			return err
+		}
+		response, err := coordinator.TxnOffsetCommit(&TxnOffsetCommitRequest{
+			TransactionalID: t.transactionalID,
+			GroupID:         groupID,
+			ProducerID:      t.producerID,
+			ProducerEpoch:   t.producerEpoch,
+			Topics:          offsets,
+			IVersion:        version,
+		})
+		if err != nil {
+			return err
+		}
+		return firstPartitionError(response.Topics)
+	})
+}
+
+// endTxn commits or aborts the transaction in progress. The messages of the transaction must have
+// been acknowledged, or have failed, beforehand. A transaction in which a message failed cannot be
+// committed; the error is returned and the transaction stays in progress, to be aborted.
+func (t *transactionManager) endTxn(commit bool) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	switch {
+	case !t.isTransactional():
+		return ErrNonTransactionalProducer
+	case !t.inTransaction:
+		return ErrNotInTransaction
+	case commit && t.txnErr != nil:
+		return t.txnErr
+	}
+
+	// the coordinator knows nothing of a transaction to which nothing was added
+	if len(t.partitionsInTxn) > 0 || len(t.groupsInTxn) > 0 {
+		err := t.sendToCoordinator(CoordinatorTransaction, t.transactionalID, func(coordinator *Broker) error {
+			version, err := coordinator.requestVersion(26, 0)
+			if err != nil {
+				return err
+			}
+			response, err := coordinator.EndTxn(&EndTxnRequest{
+				TransactionalID: t.transactionalID,
+				ProducerID:      t.producerID,
+				ProducerEpoch:   t.producerEpoch,
+				Commit:          commit,
+				IVersion:        version,
+			})
+			if err != nil {
+				return err
+			}
+			return errorOrNil(response.Err)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	t.inTransaction = false
+	t.partitionsInTxn = nil
+	t.groupsInTxn = nil
+
+	if t.txnErr != nil {
+		// a message failed, so the broker may be expecting a sequence number it will never see
+		t.txnErr = nil
+		return t.initProducerID()
+	}
+	return nil
+}
+
+// sendToCoordinator sends a request to the coordinator of a consumer group or transactional ID,
+// retrying while the coordinator moves, loads its state or is busy with a previous request of the
+// same transaction.
+func (t *transactionManager) sendToCoordinator(coordinatorType CoordinatorType, key string, send func(coordinator *Broker) error) error {
+	for attempt := 0; ; attempt++ {
+		var coordinator *Broker
+		var err error
+		if coordinatorType == CoordinatorTransaction {
+			coordinator, err = t.client.TransactionCoordinator(key)
+		} else {
+			coordinator, err = t.client.Coordinator(key)
+		}
+		if err == nil {
+			err = send(coordinator)
+		}
+
+		if err == nil || !isRetriableCoordinatorError(err) || attempt >= t.conf.Producer.Retry.Max {
+			return err
+		}
+
+		Logger.Printf("producer/txnmanager retrying request to coordinator for %s %s after %dms because %s\n",
+			coordinatorType, key, t.conf.Producer.Retry.Backoff/time.Millisecond, err)
+		switch err {
+		case ErrConcurrentTransactions, ErrOffsetsLoadInProgress:
+			// the coordinator is right, but not ready yet
+		case ErrConsumerCoordinatorNotAvailable, ErrNotCoordinatorForConsumer:
+			_ = t.refreshCoordinator(coordinatorType, key)
+		default:
+			if coordinator != nil {
+				_ = coordinator.Close()
+			}
+			_ = t.refreshCoordinator(coordinatorType, key)
+		}
+		time.Sleep(t.conf.Producer.Retry.Backoff)
+	}
+}
+
+func (t *transactionManager) refreshCoordinator(coordinatorType CoordinatorType, key string) error {
+	if coordinatorType == CoordinatorTransaction {
+		return t.client.RefreshTransactionCoordinator(key)
+	}
+	return t.client.RefreshCoordinator(key)
+}
+
+func isRetriableCoordinatorError(err error) bool {
+	switch err := err.(type) {
+	case KError:
+		switch err {
+		case ErrConsumerCoordinatorNotAvailable, ErrNotCoordinatorForConsumer, ErrOffsetsLoadInProgress, ErrConcurrentTransactions:
+			return true
+		}
+		return false
+	case PacketEncodingError, ConfigurationError:
+		return false
+	default:
+		// the connection to the coordinator failed
+		return err != ErrClosedClient
+	}
+}
+
+func errorOrNil(kerr KError) error {
+	if kerr == ErrNoError {
+		return nil
+	}
+	return kerr
+}
+
+// firstPartitionError returns one of the errors of a transactional request, preferring those that
+// explain why the others were not attempted.
+func firstPartitionError(errors map[string][]*PartitionError) error {
+	var result error
+	for _, partitionErrors := range errors {
+		for _, partitionError := range partitionErrors {
+			switch partitionError.Err {
+			case ErrNoError:
+			case ErrOperationNotAttempted:
+				if result == nil {
+					result = partitionError.Err
+				}
+			default:
+				return partitionError.Err
+			}
+		}
+	}
+	return result
+}
+
+// addPartitionsError fails the messages of a set whose partitions could not be added to the
+// transaction in progress. The broker the set was meant for is not at fault.
+type addPartitionsError struct {
+	err error
+}
+
+func (e addPartitionsError) Error() string {
+	return e.err.Error()
+}
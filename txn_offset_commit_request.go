@@ -0,0 +1,128 @@
+package sarama
+
+// PartitionOffsetMetadata is the offset to commit for a partition, and the metadata to store
+// with it.
+type PartitionOffsetMetadata struct {
+	Partition int32
+	Offset    int64
+	Metadata  *string
+}
+
+func (o *PartitionOffsetMetadata) encode(pe packetEncoder) error {
+	pe.putInt32(o.Partition)
+	pe.putInt64(o.Offset)
+	return pe.putNullableString(o.Metadata)
+}
+
+func (o *PartitionOffsetMetadata) decode(pd packetDecoder) (err error) {
+	if o.Partition, err = pd.getInt32(); err != nil {
+		return err
+	}
+	if o.Offset, err = pd.getInt64(); err != nil {
+		return err
+	}
+	o.Metadata, err = pd.getNullableString()
+	return err
+}
+
+// TxnOffsetCommitRequest commits the offsets of a consumer group as part of the transaction in
+// progress, so that they only become visible if it commits. It goes to the group coordinator,
+// after an AddOffsetsToTxnRequest to the transaction coordinator.
+type TxnOffsetCommitRequest struct {
+	TransactionalID string
+	GroupID         string
+	ProducerID      int64
+	ProducerEpoch   int16
+	Topics          map[string][]*PartitionOffsetMetadata
+
+	// Version can be:
+	// - 0 (kafka 0.11 and later)
+	// - 1 (kafka 2.0 and later, laid out as 0)
+	IVersion int16
+}
+
+func (r *TxnOffsetCommitRequest) Encode(pe packetEncoder) error {
+	if r.IVersion < 0 || r.IVersion > 1 {
+		return PacketEncodingError{"invalid or unsupported TxnOffsetCommitRequest version field"}
+	}
+
+	if err := pe.putString(r.TransactionalID); err != nil {
+		return err
+	}
+	if err := pe.putString(r.GroupID); err != nil {
+		return err
+	}
+	pe.putInt64(r.ProducerID)
+	pe.putInt16(r.ProducerEpoch)
+
+	if err := pe.putArrayLength(len(r.Topics)); err != nil {
+		return err
+	}
+	for topic, partitions := range r.Topics {
+		if err := pe.putString(topic); err != nil {
+			return err
+		}
+		if err := pe.putArrayLength(len(partitions)); err != nil {
+			return err
+		}
+		for _, partition := range partitions {
+			if err := partition.encode(pe); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *TxnOffsetCommitRequest) Decode(pd packetDecoder) (err error) {
+	if r.TransactionalID, err = pd.getString(); err != nil {
+		return err
+	}
+	if r.GroupID, err = pd.getString(); err != nil {
+		return err
+	}
+	if r.ProducerID, err = pd.getInt64(); err != nil {
+		return err
+	}
+	if r.ProducerEpoch, err = pd.getInt16(); err != nil {
+		return err
+	}
+
+	n, err := pd.getArrayLength()
+	if err != nil {
+		return err
+	}
+	r.Topics = make(map[string][]*PartitionOffsetMetadata, n)
+	for i := 0; i < n; i++ {
+		topic, err := pd.getString()
+		if err != nil {
+			return err
+		}
+		m, err := pd.getArrayLength()
+		if err != nil {
+			return err
+		}
+		r.Topics[topic] = make([]*PartitionOffsetMetadata, m)
+		for j := range r.Topics[topic] {
+			r.Topics[topic][j] = new(PartitionOffsetMetadata)
+			if err := r.Topics[topic][j].decode(pd); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (r *TxnOffsetCommitRequest) Key() int16 {
+	return 28
+}
+
+func (r *TxnOffsetCommitRequest) Version() int16 {
+	return r.IVersion
+}
+
+func (r *TxnOffsetCommitRequest) String() string {
+	return dump(r)
+}
@@ -0,0 +1,29 @@
+package sarama
+
+import "testing"
+
+var txnOffsetCommitRequest = []byte{
+	0, 3, 't', 'x', 'n',
+	0, 7, 'g', 'r', 'o', 'u', 'p', 'i', 'd',
+	0, 0, 0, 0, 0, 0, 31, 64, // producer ID 8000
+	0, 1, // epoch 1
+	0, 0, 0, 1,
+	0, 5, 't', 'o', 'p', 'i', 'c',
+	0, 0, 0, 1,
+	0, 0, 0, 2, // partition 2
+	0, 0, 0, 0, 0, 0, 0, 123, // offset 123
+	255, 255, // no metadata
+}
+
+func TestTxnOffsetCommitRequest(t *testing.T) {
+	request := &TxnOffsetCommitRequest{
+		TransactionalID: "txn",
+		GroupID:         "groupid",
+		ProducerID:      8000,
+		ProducerEpoch:   1,
+		Topics: map[string][]*PartitionOffsetMetadata{
+			"topic": {{Partition: 2, Offset: 123}},
+		},
+	}
+	testRequest(t, "", request, txnOffsetCommitRequest)
+}
@@ -0,0 +1,28 @@
+package sarama
+
+import "time"
+
+type TxnOffsetCommitResponse struct {
+	ThrottleTime time.Duration
+	Topics       map[string][]*PartitionError
+}
+
+func (r *TxnOffsetCommitResponse) Encode(pe packetEncoder) error {
+	pe.putInt32(int32(r.ThrottleTime / time.Millisecond))
+	return encodeTopicPartitionErrors(pe, r.Topics)
+}
+
+func (r *TxnOffsetCommitResponse) Decode(pd packetDecoder) (err error) {
+	millis, err := pd.getInt32()
+	if err != nil {
+		return err
+	}
+	r.ThrottleTime = time.Duration(millis) * time.Millisecond
+
+	r.Topics, err = decodeTopicPartitionErrors(pd)
+	return err
+}
+
+func (r *TxnOffsetCommitResponse) String() string {
+	return dump(r)
+}
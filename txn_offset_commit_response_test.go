@@ -0,0 +1,27 @@
+package sarama
+
+import (
+	"testing"
+	"time"
+)
+
+var txnOffsetCommitResponse = []byte{
+	0, 0, 0, 100,
+	0, 0, 0, 1,
+	0, 5, 't', 'o', 'p', 'i', 'c',
+	0, 0, 0, 1,
+	0, 0, 0, 2, // partition 2
+	0, 0, // no error
+}
+
+func TestTxnOffsetCommitResponse(t *testing.T) {
+	response := new(TxnOffsetCommitResponse)
+	testDecodable(t, "", response, txnOffsetCommitResponse)
+	if response.ThrottleTime != 100*time.Millisecond {
+		t.Error("Decoding throttle time failed, got", response.ThrottleTime)
+	}
+	if topics := response.Topics["topic"]; len(topics) != 1 || topics[0].Partition != 2 || topics[0].Err != ErrNoError {
+		t.Error("Decoding partition errors failed, got", response.Topics)
+	}
+	testEncodable(t, "", response, txnOffsetCommitResponse)
+}
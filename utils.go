@@ -1,6 +1,11 @@
 package sarama
 
-import "sort"
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+)
 
 type none struct{}
 
@@ -19,6 +24,21 @@ func (slice int32Slice) Swap(i, j int) {
 	slice[i], slice[j] = slice[j], slice[i]
 }
 
+// make []uint64 sortable so we can write tagged fields in order
+type uint64Slice []uint64
+
+func (slice uint64Slice) Len() int {
+	return len(slice)
+}
+
+func (slice uint64Slice) Less(i, j int) bool {
+	return slice[i] < slice[j]
+}
+
+func (slice uint64Slice) Swap(i, j int) {
+	slice[i], slice[j] = slice[j], slice[i]
+}
+
 func dupeAndSort(input []int32) []int32 {
 	ret := make([]int32, 0, len(input))
 	for _, val := range input {
@@ -87,3 +107,108 @@ func (b ByteEncoder) IEncode() ([]byte, error) {
 func (b ByteEncoder) Length() int {
 	return len(b)
 }
+
+// millisecondsSinceEpoch converts t to the representation of timestamps on the wire, where -1
+// stands for no timestamp.
+func millisecondsSinceEpoch(t time.Time) int64 {
+	if t.Before(time.Unix(0, 0)) {
+		return -1
+	}
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// timeFromMilliseconds is the inverse of millisecondsSinceEpoch.
+func timeFromMilliseconds(millis int64) time.Time {
+	if millis < 0 {
+		return time.Time{}
+	}
+	return time.Unix(millis/1000, (millis%1000)*int64(time.Millisecond))
+}
+
+// KafkaVersion instances represent versions of the upstream Kafka broker.
+type KafkaVersion struct {
+	// it's a struct rather than just typing the array directly to make it opaque and stop people
+	// generating their own arbitrary versions
+	version [4]uint
+}
+
+func newKafkaVersion(major, minor, veryMinor, patch uint) KafkaVersion {
+	return KafkaVersion{
+		version: [4]uint{major, minor, veryMinor, patch},
+	}
+}
+
+// IsAtLeast return true if and only if the version it is called on is
+// greater than or equal to the version passed in:
+//
+//	V1.IsAtLeast(V2) // false
+//	V2.IsAtLeast(V1) // true
+func (v KafkaVersion) IsAtLeast(other KafkaVersion) bool {
+	for i := range v.version {
+		if v.version[i] > other.version[i] {
+			return true
+		} else if v.version[i] < other.version[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (v KafkaVersion) String() string {
+	if v.version[0] == 0 {
+		return fmt.Sprintf("0.%d.%d.%d", v.version[1], v.version[2], v.version[3])
+	}
+	return fmt.Sprintf("%d.%d.%d", v.version[0], v.version[1], v.version[2])
+}
+
+// ParseKafkaVersion parses a version string such as "0.10.2.0" or "2.1.0" (the
+// numbering scheme Kafka switched to with 1.0.0) into a KafkaVersion.
+func ParseKafkaVersion(s string) (KafkaVersion, error) {
+	if len(s) < 5 {
+		return minVersion, fmt.Errorf("invalid version `%s`", s)
+	}
+	var major, minor, veryMinor, patch uint
+	var err error
+	if s[0] == '0' {
+		err = scanKafkaVersion(s, `^0\.\d+\.\d+\.\d+$`, "0.%d.%d.%d", [3]*uint{&minor, &veryMinor, &patch})
+	} else {
+		err = scanKafkaVersion(s, `^\d+\.\d+\.\d+$`, "%d.%d.%d", [3]*uint{&major, &minor, &veryMinor})
+	}
+	if err != nil {
+		return minVersion, err
+	}
+	return newKafkaVersion(major, minor, veryMinor, patch), nil
+}
+
+func scanKafkaVersion(s string, pattern string, format string, v [3]*uint) error {
+	if !regexp.MustCompile(pattern).MatchString(s) {
+		return fmt.Errorf("invalid version `%s`", s)
+	}
+	_, err := fmt.Sscanf(s, format, v[0], v[1], v[2])
+	return err
+}
+
+// Effective constants defining the supported kafka versions.
+var (
+	V0_8_2_0  = newKafkaVersion(0, 8, 2, 0)
+	V0_8_2_1  = newKafkaVersion(0, 8, 2, 1)
+	V0_8_2_2  = newKafkaVersion(0, 8, 2, 2)
+	V0_9_0_0  = newKafkaVersion(0, 9, 0, 0)
+	V0_9_0_1  = newKafkaVersion(0, 9, 0, 1)
+	V0_10_0_0 = newKafkaVersion(0, 10, 0, 0)
+	V0_10_0_1 = newKafkaVersion(0, 10, 0, 1)
+	V0_10_1_0 = newKafkaVersion(0, 10, 1, 0)
+	V0_10_2_0 = newKafkaVersion(0, 10, 2, 0)
+	V0_11_0_0 = newKafkaVersion(0, 11, 0, 0)
+	V1_0_0_0  = newKafkaVersion(1, 0, 0, 0)
+	V1_1_0_0  = newKafkaVersion(1, 1, 0, 0)
+	V2_0_0_0  = newKafkaVersion(2, 0, 0, 0)
+	V2_1_0_0  = newKafkaVersion(2, 1, 0, 0)
+	V2_2_0_0  = newKafkaVersion(2, 2, 0, 0)
+	V2_3_0_0  = newKafkaVersion(2, 3, 0, 0)
+	V2_4_0_0  = newKafkaVersion(2, 4, 0, 0)
+
+	minVersion = V0_8_2_0
+	// MaxVersion is the newest Kafka version whose requests Sarama knows how to encode and decode.
+	MaxVersion = V2_4_0_0
+)
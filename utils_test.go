@@ -0,0 +1,50 @@
+package sarama
+
+import "testing"
+
+func TestVersionCompare(t *testing.T) {
+	if V0_8_2_0.IsAtLeast(V0_8_2_1) {
+		t.Error("0.8.2.0 >= 0.8.2.1")
+	}
+	if !V0_8_2_1.IsAtLeast(V0_8_2_0) {
+		t.Error("! 0.8.2.1 >= 0.8.2.0")
+	}
+	if !V0_8_2_0.IsAtLeast(V0_8_2_0) {
+		t.Error("! 0.8.2.0 >= 0.8.2.0")
+	}
+	if !V0_9_0_0.IsAtLeast(V0_8_2_1) {
+		t.Error("! 0.9.0.0 >= 0.8.2.1")
+	}
+	if V0_8_2_1.IsAtLeast(V0_10_0_0) {
+		t.Error("0.8.2.1 >= 0.10.0.0")
+	}
+	if !V1_0_0_0.IsAtLeast(V0_11_0_0) {
+		t.Error("! 1.0.0 >= 0.11.0.0")
+	}
+}
+
+func TestVersionParsing(t *testing.T) {
+	valid := map[string]KafkaVersion{
+		"0.8.2.0":  V0_8_2_0,
+		"0.10.2.0": V0_10_2_0,
+		"1.0.0":    V1_0_0_0,
+		"2.1.0":    V2_1_0_0,
+	}
+	for s, expected := range valid {
+		v, err := ParseKafkaVersion(s)
+		if err != nil {
+			t.Errorf("Could not parse %s: %s", s, err)
+		} else if v != expected {
+			t.Errorf("Parsed %s as %s", s, v)
+		}
+		if v.String() != s {
+			t.Errorf("Expected %s to print as itself, got %s", s, v)
+		}
+	}
+
+	for _, s := range []string{"", "0.10", "1.0.0.0", "0.8.2", "abc.d.e", "1.0.0-rc1"} {
+		if _, err := ParseKafkaVersion(s); err == nil {
+			t.Errorf("Expected %q to be rejected", s)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package sarama
+
+import (
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+var (
+	zstdDecoder, _ = zstd.NewReader(nil)
+
+	// encoders are safe for concurrent use, so one is kept per compression level
+	zstdEncodersLock sync.Mutex
+	zstdEncoders     = make(map[int]*zstd.Encoder)
+)
+
+func getZstdEncoder(level int) (*zstd.Encoder, error) {
+	zstdEncodersLock.Lock()
+	defer zstdEncodersLock.Unlock()
+
+	if encoder := zstdEncoders[level]; encoder != nil {
+		return encoder, nil
+	}
+
+	zstdLevel := zstd.SpeedDefault
+	switch level {
+	case CompressionLevelDefault:
+	case CompressionLevelZero:
+		zstdLevel = zstd.EncoderLevelFromZstd(0)
+	default:
+		zstdLevel = zstd.EncoderLevelFromZstd(level)
+	}
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstdLevel))
+	if err != nil {
+		return nil, err
+	}
+	zstdEncoders[level] = encoder
+	return encoder, nil
+}
+
+// zstdEncode compresses src at the given level, or zstd's default for CompressionLevelDefault
+func zstdEncode(level int, src []byte) ([]byte, error) {
+	encoder, err := getZstdEncoder(level)
+	if err != nil {
+		return nil, err
+	}
+	return encoder.EncodeAll(src, nil), nil
+}
+
+// zstdDecode decompresses zstd data
+func zstdDecode(src []byte) ([]byte, error) {
+	return zstdDecoder.DecodeAll(src, nil)
+}